@@ -28,6 +28,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
@@ -97,6 +98,11 @@ routed based on paths.`,
 			if err := options.Authentication.ApplyTo(&authenticationInfo, servingInfo); err != nil {
 				return err
 			}
+			// only the audit fields of the config are used
+			var auditConfig genericapiserver.Config
+			if err := options.Audit.ApplyTo(&auditConfig); err != nil {
+				return err
+			}
 
 			// get root API identities
 			nonIdentityRootConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.RootKubeconfig}, nil).ClientConfig()
@@ -148,14 +154,24 @@ routed based on paths.`,
 			if err != nil {
 				return err
 			}
+			// audit events carry the user, hence are created after authentication, and are
+			// annotated with the shard and the logical cluster the request is proxied to.
+			handler = genericapifilters.WithAudit(handler, auditConfig.AuditBackend, auditConfig.AuditPolicyRuleEvaluator, genericfilters.BasicLongRunningRequestCheck(sets.NewString("watch"), sets.NewString()))
 			failedHandler := newUnauthorizedHandler()
 			handler = withOptionalClientCert(handler, failedHandler, authenticationInfo.Authenticator)
 
 			requestInfoFactory := requestinfo.NewFactory()
 			handler = server.WithInClusterServiceAccountRequestRewrite(handler)
 			handler = genericapifilters.WithRequestInfo(handler, requestInfoFactory)
+			handler = server.WithAuditAnnotation(handler)
 			handler = genericfilters.WithHTTPLogging(handler)
 			handler = genericfilters.WithPanicRecovery(handler, requestInfoFactory)
+			if auditConfig.AuditBackend != nil {
+				if err := auditConfig.AuditBackend.Run(ctx.Done()); err != nil {
+					return fmt.Errorf("failed to run the audit backend: %w", err)
+				}
+				defer auditConfig.AuditBackend.Shutdown()
+			}
 			doneCh, err := servingInfo.Serve(handler, time.Second*60, ctx.Done())
 			if err != nil {
 				return err
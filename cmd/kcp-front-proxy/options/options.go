@@ -32,6 +32,7 @@ import (
 type Options struct {
 	SecureServing  apiserveroptions.SecureServingOptionsWithLoopback
 	Authentication Authentication
+	Audit          *apiserveroptions.AuditOptions
 	Proxy          proxyoptions.Options
 	Logs           *logs.Options
 
@@ -43,6 +44,7 @@ func NewOptions() *Options {
 	o := &Options{
 		SecureServing:  *apiserveroptions.NewSecureServingOptions().WithLoopback(),
 		Authentication: *NewAuthentication(),
+		Audit:          apiserveroptions.NewAuditOptions(),
 		Proxy:          *proxyoptions.NewOptions(),
 		Logs:           logs.NewOptions(),
 
@@ -63,6 +65,7 @@ func NewOptions() *Options {
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.SecureServing.AddFlags(fs)
 	o.Authentication.AddFlags(fs)
+	o.Audit.AddFlags(fs)
 	o.Proxy.AddFlags(fs)
 
 	o.Logs.AddFlags(fs)
@@ -103,6 +106,7 @@ func (o *Options) Validate() []error {
 
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
+	errs = append(errs, o.Audit.Validate()...)
 	errs = append(errs, o.Proxy.Validate()...)
 
 	return errs
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+// Validate Placement creation and updates for
+// - a spec.locationResource that is a schedulable location type.
+//
+// On update, the location resource is only validated if it changes, in order to not
+// block updates of placements which have been created before the validation existed.
+
+const (
+	PluginName = "scheduling.kcp.dev/Placement"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type placement struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&placement{})
+
+// Validate validates the creation and updating of Placement resources.
+func (o *placement) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != schedulingv1alpha1.Resource("placements") {
+		return nil
+	}
+
+	p, err := toPlacement(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Update {
+		old, err := toPlacement(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(old.Spec.LocationResource, p.Spec.LocationResource) {
+			return nil
+		}
+	}
+
+	if errs := ValidatePlacement(p); len(errs) > 0 {
+		return admission.NewForbidden(a, fmt.Errorf("%v", errs))
+	}
+
+	return nil
+}
+
+func toPlacement(obj runtime.Object) (*schedulingv1alpha1.Placement, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	p := &schedulingv1alpha1.Placement{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, p); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to Placement: %w", err)
+	}
+	return p, nil
+}
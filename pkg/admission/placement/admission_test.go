@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+func createAttr(p *schedulingv1alpha1.Placement) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(p),
+		nil,
+		schedulingv1alpha1.Kind("Placement").WithVersion("v1alpha1"),
+		"",
+		p.Name,
+		schedulingv1alpha1.Resource("placements").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func updateAttr(p, old *schedulingv1alpha1.Placement) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(p),
+		helpers.ToUnstructuredOrDie(old),
+		schedulingv1alpha1.Kind("Placement").WithVersion("v1alpha1"),
+		"",
+		p.Name,
+		schedulingv1alpha1.Resource("placements").WithVersion("v1alpha1"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newPlacement(group, version, resource string) *schedulingv1alpha1.Placement {
+	return &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: schedulingv1alpha1.PlacementSpec{
+			LocationResource: schedulingv1alpha1.GroupVersionResource{
+				Group:    group,
+				Version:  version,
+				Resource: resource,
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       admission.Attributes
+		wantErr string
+	}{
+		{
+			name: "accepts sync targets",
+			a:    createAttr(newPlacement("workload.kcp.dev", "v1alpha1", "synctargets")),
+		},
+		{
+			name:    "rejects a misspelled resource",
+			a:       createAttr(newPlacement("workload.kcp.dev", "v1alpha1", "synctarget")),
+			wantErr: `spec.locationResource: Unsupported value: "synctarget.v1alpha1.workload.kcp.dev": supported values: "synctargets.v1alpha1.workload.kcp.dev"`,
+		},
+		{
+			name:    "rejects a misspelled group",
+			a:       createAttr(newPlacement("workloads.kcp.dev", "v1alpha1", "synctargets")),
+			wantErr: `Unsupported value: "synctargets.v1alpha1.workloads.kcp.dev"`,
+		},
+		{
+			name:    "rejects an unknown version",
+			a:       createAttr(newPlacement("workload.kcp.dev", "v1", "synctargets")),
+			wantErr: `Unsupported value: "synctargets.v1.workload.kcp.dev"`,
+		},
+		{
+			name: "accepts an update changing the location resource to sync targets",
+			a: updateAttr(
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctargets"),
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctarget"),
+			),
+		},
+		{
+			name: "rejects an update changing the location resource to an unknown resource",
+			a: updateAttr(
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctarget"),
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctargets"),
+			),
+			wantErr: `Unsupported value: "synctarget.v1alpha1.workload.kcp.dev"`,
+		},
+		{
+			name: "accepts an update not changing an unknown location resource",
+			a: updateAttr(
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctarget"),
+				newPlacement("workload.kcp.dev", "v1alpha1", "synctarget"),
+			),
+		},
+		{
+			name: "ignores other resources",
+			a: admission.NewAttributesRecord(
+				helpers.ToUnstructuredOrDie(newPlacement("workload.kcp.dev", "v1alpha1", "synctarget")),
+				nil,
+				schedulingv1alpha1.Kind("Location").WithVersion("v1alpha1"),
+				"",
+				"test",
+				schedulingv1alpha1.Resource("locations").WithVersion("v1alpha1"),
+				"",
+				admission.Create,
+				&metav1.CreateOptions{},
+				false,
+				&user.DefaultInfo{},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.TODO(), tt.a, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// schedulableLocationResources are the resources the placement scheduler knows how to select
+// locations of. Keep in sync with the scheduling controllers.
+var schedulableLocationResources = []schedulingv1alpha1.GroupVersionResource{
+	{
+		Group:    workloadv1alpha1.SchemeGroupVersion.Group,
+		Version:  workloadv1alpha1.SchemeGroupVersion.Version,
+		Resource: "synctargets",
+	},
+}
+
+// ValidatePlacement validates a Placement.
+func ValidatePlacement(placement *schedulingv1alpha1.Placement) field.ErrorList {
+	return ValidatePlacementSpec(&placement.Spec, field.NewPath("spec"))
+}
+
+// ValidatePlacementSpec validates the spec of a Placement.
+func ValidatePlacementSpec(spec *schedulingv1alpha1.PlacementSpec, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, ValidateLocationResource(spec.LocationResource, path.Child("locationResource"))...)
+
+	return allErrs
+}
+
+// ValidateLocationResource validates that gvr is a schedulable location type.
+func ValidateLocationResource(gvr schedulingv1alpha1.GroupVersionResource, path *field.Path) field.ErrorList {
+	supported := make([]string, 0, len(schedulableLocationResources))
+	for _, schedulable := range schedulableLocationResources {
+		if gvr == schedulable {
+			return nil
+		}
+		supported = append(supported, gvrString(schedulable))
+	}
+	return field.ErrorList{field.NotSupported(path, gvrString(gvr), supported)}
+}
+
+func gvrString(gvr schedulingv1alpha1.GroupVersionResource) string {
+	return fmt.Sprintf("%s.%s.%s", gvr.Resource, gvr.Version, gvr.Group)
+}
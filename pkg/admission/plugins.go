@@ -51,9 +51,11 @@ import (
 	kcpmutatingwebhook "github.com/kcp-dev/kcp/pkg/admission/mutatingwebhook"
 	workspacenamespacelifecycle "github.com/kcp-dev/kcp/pkg/admission/namespacelifecycle"
 	"github.com/kcp-dev/kcp/pkg/admission/permissionclaims"
+	"github.com/kcp-dev/kcp/pkg/admission/placement"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdannotations"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdgroups"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedmetadata"
+	"github.com/kcp-dev/kcp/pkg/admission/synctarget"
 	kcpvalidatingwebhook "github.com/kcp-dev/kcp/pkg/admission/validatingwebhook"
 )
 
@@ -75,6 +77,8 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	crdnooverlappinggvr.PluginName,
 	reservedmetadata.PluginName,
 	permissionclaims.PluginName,
+	synctarget.PluginName,
+	placement.PluginName,
 )
 
 func beforeWebhooks(recommended []string, plugins ...string) []string {
@@ -108,6 +112,8 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	crdnooverlappinggvr.Register(plugins)
 	reservedmetadata.Register(plugins)
 	permissionclaims.Register(plugins)
+	synctarget.Register(plugins)
+	placement.Register(plugins)
 }
 
 var defaultOnPluginsInKcp = sets.NewString(
@@ -131,6 +137,8 @@ var defaultOnPluginsInKcp = sets.NewString(
 	reservedcrdannotations.PluginName,
 	reservedcrdgroups.PluginName,
 	permissionclaims.PluginName,
+	synctarget.PluginName,
+	placement.PluginName,
 )
 
 // defaultOnKubePluginsInKube is a copy of kubeapiserveroptions.defaultOnKubePlugins.
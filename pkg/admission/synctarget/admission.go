@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctarget
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/warning"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// Validate SyncTarget creation and updates for
+// - a valid spec.namespaceSelector
+// - a valid spec.supportedAPIExportSelector
+// - non-negative spec.syncerQPS and spec.syncerBurst
+// - a valid spec.syncerImage reference
+// - valid spec.maintenanceWindows schedules and positive durations
+// - valid status.endpoints URLs.
+//
+// A warning is returned for a spec.evictAfter without spec.unschedulable.
+
+const (
+	PluginName = "workload.kcp.dev/SyncTarget"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &syncTarget{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type syncTarget struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&syncTarget{})
+
+// Validate validates the creation and updating of SyncTarget resources.
+func (o *syncTarget) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != workloadv1alpha1.Resource("synctargets") {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	st := &workloadv1alpha1.SyncTarget{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, st); err != nil {
+		return fmt.Errorf("failed to convert unstructured to SyncTarget: %w", err)
+	}
+
+	if errs := ValidateSyncTarget(st); len(errs) > 0 {
+		return admission.NewForbidden(a, fmt.Errorf("%v", errs))
+	}
+
+	for _, w := range WarningsForSyncTarget(st) {
+		warning.AddWarning(ctx, "", w)
+	}
+
+	return nil
+}
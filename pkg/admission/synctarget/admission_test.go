@@ -0,0 +1,316 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctarget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/utils/pointer"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func createAttr(st *workloadv1alpha1.SyncTarget) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(st),
+		nil,
+		workloadv1alpha1.Kind("SyncTarget").WithVersion("v1alpha1"),
+		"",
+		st.Name,
+		workloadv1alpha1.Resource("synctargets").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		a            admission.Attributes
+		wantErr      bool
+		wantWarnings int
+	}{
+		{
+			name: "accepts a sync target without namespace selector",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			}),
+		},
+		{
+			name: "accepts a valid namespace selector",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tenant", Operator: metav1.LabelSelectorOpExists},
+						},
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects an invalid namespace selector",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tenant", Operator: metav1.LabelSelectorOpIn},
+						},
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "accepts a valid supported APIExport selector",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SupportedAPIExportSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"sync": "true"},
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects an invalid supported APIExport selector",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SupportedAPIExportSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "sync", Operator: metav1.LabelSelectorOpIn},
+						},
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "accepts valid maintenance windows",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					MaintenanceWindows: []workloadv1alpha1.MaintenanceWindow{
+						{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+						{Schedule: "*/30 8-17 1,15 * 1-5", Duration: metav1.Duration{Duration: 10 * time.Minute}},
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects an invalid maintenance window schedule",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					MaintenanceWindows: []workloadv1alpha1.MaintenanceWindow{
+						{Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects a maintenance window without duration",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					MaintenanceWindows: []workloadv1alpha1.MaintenanceWindow{
+						{Schedule: "0 2 * * 6"},
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "accepts syncer rate limits",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerQPS:   pointer.Int32(5),
+					SyncerBurst: pointer.Int32(0),
+				},
+			}),
+		},
+		{
+			name: "rejects a negative syncer QPS",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerQPS: pointer.Int32(-1),
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects a negative syncer burst",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerBurst: pointer.Int32(-1),
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "accepts a syncer image with registry, tag and digest",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerImage: "registry.example.com:5000/kcp-dev/kcp/syncer:v0.8.0@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				},
+			}),
+		},
+		{
+			name: "accepts a syncer image without registry and tag",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerImage: "kcp-syncer",
+				},
+			}),
+		},
+		{
+			name: "rejects an invalid syncer image",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerImage: "ghcr.io/kcp-dev/KCP/syncer:v0.8.0",
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects a syncer image with an invalid tag",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SyncerImage: "ghcr.io/kcp-dev/kcp/syncer:-v0.8.0",
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "accepts valid endpoints",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Endpoints: []workloadv1alpha1.Endpoint{
+						{URL: "https://api.cluster.example.com:6443"},
+						{URL: "http://10.0.0.1"},
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects an endpoint without scheme",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Endpoints: []workloadv1alpha1.Endpoint{{URL: "api.cluster.example.com:6443"}},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects an endpoint without host",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Endpoints: []workloadv1alpha1.Endpoint{{URL: "https://"}},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects an unparsable endpoint",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Endpoints: []workloadv1alpha1.Endpoint{{URL: "https://api.cluster.example.com:port"}},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "warns about evictAfter on a schedulable sync target",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					EvictAfter: &metav1.Time{Time: time.Now().Add(time.Hour)},
+				},
+			}),
+			wantWarnings: 1,
+		},
+		{
+			name: "accepts evictAfter on an unschedulable sync target",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					Unschedulable: true,
+					EvictAfter:    &metav1.Time{Time: time.Now().Add(time.Hour)},
+				},
+			}),
+		},
+		{
+			name: "accepts an unschedulable sync target without evictAfter",
+			a: createAttr(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					Unschedulable: true,
+				},
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &syncTarget{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			recorder := &warningRecorder{}
+			err := o.Validate(warning.WithWarningRecorder(context.TODO(), recorder), tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, recorder.warnings, tt.wantWarnings, "unexpected warnings: %v", recorder.warnings)
+		})
+	}
+}
+
+type warningRecorder struct {
+	warnings []string
+}
+
+func (r *warningRecorder) AddWarning(_, text string) {
+	r.warnings = append(r.warnings, text)
+}
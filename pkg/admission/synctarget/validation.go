@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctarget
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cron"
+)
+
+// imageReferenceRegexp matches container image references of the form
+// [<registry>[:<port>]/]<repository>[:<tag>][@<digest>], following the grammar of
+// github.com/distribution/distribution/reference.
+var imageReferenceRegexp = regexp.MustCompile(`^` +
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*` +
+	`(?::[\w][\w.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9A-Fa-f]{32,})?` +
+	`$`)
+
+// ValidateSyncTarget validates a SyncTarget.
+func ValidateSyncTarget(syncTarget *workloadv1alpha1.SyncTarget) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, ValidateSyncTargetSpec(&syncTarget.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, ValidateSyncTargetStatus(&syncTarget.Status, field.NewPath("status"))...)
+
+	return allErrs
+}
+
+// ValidateSyncTargetSpec validates the spec of a SyncTarget.
+func ValidateSyncTargetSpec(spec *workloadv1alpha1.SyncTargetSpec, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.NamespaceSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.NamespaceSelector, path.Child("namespaceSelector"))...)
+	}
+	if spec.SupportedAPIExportSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.SupportedAPIExportSelector, path.Child("supportedAPIExportSelector"))...)
+	}
+
+	if spec.SyncerQPS != nil && *spec.SyncerQPS < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("syncerQPS"), *spec.SyncerQPS, "must be non-negative"))
+	}
+	if spec.SyncerBurst != nil && *spec.SyncerBurst < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("syncerBurst"), *spec.SyncerBurst, "must be non-negative"))
+	}
+	if spec.SyncerImage != "" && (len(spec.SyncerImage) > 255 || !imageReferenceRegexp.MatchString(spec.SyncerImage)) {
+		allErrs = append(allErrs, field.Invalid(path.Child("syncerImage"), spec.SyncerImage, "must be a valid image reference, e.g. ghcr.io/kcp-dev/kcp/syncer:v0.8.0"))
+	}
+
+	for i, window := range spec.MaintenanceWindows {
+		windowPath := path.Child("maintenanceWindows").Index(i)
+		if _, err := cron.Parse(window.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("schedule"), window.Schedule, err.Error()))
+		}
+		if window.Duration.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("duration"), window.Duration.String(), "must be positive"))
+		}
+	}
+
+	return allErrs
+}
+
+// WarningsForSyncTarget returns warnings for a valid, but likely unintended SyncTarget.
+func WarningsForSyncTarget(syncTarget *workloadv1alpha1.SyncTarget) []string {
+	var warnings []string
+
+	// until evictAfter, a schedulable sync target still gets new workloads, only to evict them shortly after.
+	if syncTarget.Spec.EvictAfter != nil && !syncTarget.Spec.Unschedulable {
+		warnings = append(warnings, fmt.Sprintf("%s is set, but %s is false: new workloads are still scheduled until they are evicted; set %s to true when evicting",
+			field.NewPath("spec", "evictAfter"), field.NewPath("spec", "unschedulable"), field.NewPath("spec", "unschedulable")))
+	}
+
+	return warnings
+}
+
+// ValidateSyncTargetStatus validates the status of a SyncTarget.
+func ValidateSyncTargetStatus(status *workloadv1alpha1.SyncTargetStatus, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, endpoint := range status.Endpoints {
+		if err := validateEndpointURL(endpoint.URL); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoints").Index(i).Child("url"), endpoint.URL, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+func validateEndpointURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("scheme must be https or http")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("host must not be empty")
+	}
+	return nil
+}
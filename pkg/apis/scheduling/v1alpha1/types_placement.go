@@ -38,6 +38,7 @@ import (
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Namespaces",type=integer,JSONPath=`.status.boundNamespaceCount`,description="Number of namespaces bound to this placement"
 type Placement struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
@@ -81,6 +82,68 @@ type PlacementSpec struct {
 	// +optional
 	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	LocationWorkspace string `json:"locationWorkspace,omitempty"`
+
+	// rebalance enables the periodic rebalancing of the namespaces bound to this placement
+	// over the sync targets of the selected location, e.g. after sync targets have been
+	// added. If unset, namespaces stay on the sync target they are scheduled to.
+	// +optional
+	Rebalance *RebalancePolicy `json:"rebalance,omitempty"`
+
+	// placementAffinity co-locates the namespaces of this placement with the namespaces
+	// of another placement in the same workspace, e.g. for data locality. If unset, the
+	// sync targets of this placement are chosen independently of other placements.
+	// +optional
+	PlacementAffinity *PlacementAffinity `json:"placementAffinity,omitempty"`
+
+	// requiredCapabilities are the capabilities a sync target must report in its
+	// status.capabilities to get the namespaces of this placement, e.g. storageclass/<name>
+	// or gpu. Namespaces are removed from sync targets which stop reporting any of them.
+	// +optional
+	RequiredCapabilities []string `json:"requiredCapabilities,omitempty"`
+}
+
+// PlacementAffinity describes the co-location of the namespaces of a placement with those
+// of another placement.
+//
+// Namespaces are preferably scheduled to the sync targets the namespaces of the referenced
+// placement are scheduled to. The location selected by the referenced placement is preferred
+// when selecting a location. Namespaces already scheduled to a sync target are not moved.
+type PlacementAffinity struct {
+	// placement is the name of the referenced Placement in the same workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Placement string `json:"placement"`
+
+	// required makes namespaces only be scheduled to the sync targets of the referenced
+	// placement. If none of them is available, namespaces are not scheduled. By default,
+	// namespaces are scheduled to other sync targets in that case.
+	//
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
+// RebalancePolicy describes how namespaces are moved between the sync targets of a location.
+//
+// Every interval, the namespaces of the most loaded sync target, relative to its allocatable
+// cpu if all sync targets report it, are moved to the least loaded one, as long as this
+// improves the balance. Sync targets which are not ready, unschedulable, evicting or in their
+// reschedule cooldown do not get namespaces moved to them.
+type RebalancePolicy struct {
+	// interval is the minimum time between two rebalancings moving namespaces.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Interval metav1.Duration `json:"interval"`
+
+	// maxNamespacesPerInterval is the maximum number of namespaces moved to another sync
+	// target per interval.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	MaxNamespacesPerInterval int32 `json:"maxNamespacesPerInterval,omitempty"`
 }
 
 type PlacementStatus struct {
@@ -94,6 +157,25 @@ type PlacementStatus struct {
 	// +optional
 	SelectedLocation *LocationReference `json:"selectedLocation,omitempty"`
 
+	// lastRebalanceTime is the time namespaces were last moved to another sync target
+	// by rebalancing according to spec.rebalance.
+	// +optional
+	LastRebalanceTime *metav1.Time `json:"lastRebalanceTime,omitempty"`
+
+	// boundNamespaces lists the names of the namespaces bound to this placement in
+	// alphabetical order. For large numbers of namespaces, only the first
+	// MaxBoundNamespaces are listed, while boundNamespaceCount holds the total.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	BoundNamespaces []string `json:"boundNamespaces,omitempty"`
+
+	// boundNamespaceCount is the total number of namespaces bound to this placement.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BoundNamespaceCount int32 `json:"boundNamespaceCount,omitempty"`
+
 	// Current processing state of the Placement.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
@@ -119,6 +201,9 @@ type LocationReference struct {
 
 type PlacementPhase string
 
+// MaxBoundNamespaces is the maximum number of namespaces listed in status.boundNamespaces.
+const MaxBoundNamespaces = 100
+
 const (
 	// PlacementPending is the phase that the location has not been selected for this placement.
 	PlacementPending = "Pending"
@@ -149,6 +234,27 @@ const (
 	// LocationNotMatchReason is a reason for PlacementReady condition that no matched location for
 	// this placement can be found.
 	LocationNotMatchReason = "LocationNoMatch"
+
+	// PlacementAffinitySatisfied is a condition type for placement representing that the sync targets
+	// of the placement referenced in spec.placementAffinity are available in the selected location.
+	PlacementAffinitySatisfied conditionsv1alpha1.ConditionType = "PlacementAffinitySatisfied"
+
+	// PlacementAffinityUnsatisfiableReason is a reason for PlacementAffinitySatisfied condition that
+	// none of the sync targets of the referenced placement is available.
+	PlacementAffinityUnsatisfiableReason = "PlacementAffinityUnsatisfiable"
+
+	// PlacementNamespacesSelected is a condition type for placement representing that the
+	// namespace selector matches at least one namespace. Its message tells how many namespaces
+	// the selector currently matches.
+	PlacementNamespacesSelected conditionsv1alpha1.ConditionType = "NamespacesSelected"
+
+	// NamespaceSelectorNotSetReason is a reason for PlacementNamespacesSelected condition that
+	// spec.namespaceSelector is not set, i.e. no namespace is selected.
+	NamespaceSelectorNotSetReason = "NamespaceSelectorNotSet"
+
+	// NoNamespaceSelectedReason is a reason for PlacementNamespacesSelected condition that
+	// spec.namespaceSelector matches no namespace.
+	NoNamespaceSelectedReason = "NoNamespaceSelected"
 )
 
 // PlacementList is a list of locations.
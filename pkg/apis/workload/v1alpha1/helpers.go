@@ -17,12 +17,45 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ClusterResourceStateLabel returns the state.workload.kcp.dev/<sync-target-name> label key
+// for the given sync target. Compare ClusterResourceStateLabelPrefix.
+func ClusterResourceStateLabel(syncTargetKey string) string {
+	return ClusterResourceStateLabelPrefix + syncTargetKey
+}
+
+// DeletionAnnotation returns the deletion.internal.workload.kcp.dev/<sync-target-name> annotation
+// key for the given sync target. Compare InternalClusterDeletionTimestampAnnotationPrefix.
+func DeletionAnnotation(syncTargetKey string) string {
+	return InternalClusterDeletionTimestampAnnotationPrefix + syncTargetKey
+}
+
+// ParseClusterResourceStateLabel returns the sync target of a state.workload.kcp.dev/<sync-target-name>
+// label key, and false if key is not such a label key.
+func ParseClusterResourceStateLabel(key string) (syncTargetKey string, ok bool) {
+	return parseSyncTargetKey(key, ClusterResourceStateLabelPrefix)
+}
+
+// ParseDeletionAnnotation returns the sync target of a deletion.internal.workload.kcp.dev/<sync-target-name>
+// annotation key, and false if key is not such an annotation key.
+func ParseDeletionAnnotation(key string) (syncTargetKey string, ok bool) {
+	return parseSyncTargetKey(key, InternalClusterDeletionTimestampAnnotationPrefix)
+}
+
+func parseSyncTargetKey(key, prefix string) (string, bool) {
+	if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(key, prefix), true
+}
+
 // GetResourceState returns the state of the resource for the given sync target, and
 // whether the state value is a valid state. A missing label is considered invalid.
 func GetResourceState(obj metav1.Object, cluster string) (state ResourceState, valid bool) {
-	value, found := obj.GetLabels()[ClusterResourceStateLabelPrefix+cluster]
+	value, found := obj.GetLabels()[ClusterResourceStateLabel(cluster)]
 	return ResourceState(value), found && (value == "" || ResourceState(value) == ResourceStateSync)
 }
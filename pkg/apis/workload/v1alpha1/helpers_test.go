@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestSyncTargetKeys(t *testing.T) {
+	if got, want := ClusterResourceStateLabel("us-east1"), "state.workload.kcp.dev/us-east1"; got != want {
+		t.Errorf("ClusterResourceStateLabel(us-east1) = %v, want %v", got, want)
+	}
+	if got, want := DeletionAnnotation("us-east1"), "deletion.internal.workload.kcp.dev/us-east1"; got != want {
+		t.Errorf("DeletionAnnotation(us-east1) = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		parse  func(string) (string, bool)
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{ParseClusterResourceStateLabel, ClusterResourceStateLabel("us-east1"), "us-east1", true},
+		{ParseClusterResourceStateLabel, ClusterResourceStateLabelPrefix, "", false},
+		{ParseClusterResourceStateLabel, DeletionAnnotation("us-east1"), "", false},
+		{ParseClusterResourceStateLabel, "us-east1", "", false},
+		{ParseDeletionAnnotation, DeletionAnnotation("us-east1"), "us-east1", true},
+		{ParseDeletionAnnotation, ClusterResourceStateLabel("us-east1"), "", false},
+	}
+	for _, tc := range tests {
+		got, ok := tc.parse(tc.key)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parsing %q = %q, %v, want %q, %v", tc.key, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
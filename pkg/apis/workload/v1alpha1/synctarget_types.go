@@ -34,7 +34,9 @@ import (
 // +kubebuilder:resource:scope=Cluster,categories=kcp
 // +kubebuilder:printcolumn:name="Location",type="string",JSONPath=`.metadata.name`,priority=1
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`,priority=2
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,priority=2
 // +kubebuilder:printcolumn:name="Synced API resources",type="string",JSONPath=`.status.syncedResources`,priority=3
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=`.status.lastSyncTime`
 type SyncTarget struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
@@ -65,6 +67,138 @@ type SyncTargetSpec struct {
 	// will be unassigned from the cluster.
 	// By default, workloads scheduled to the cluster are not evicted.
 	EvictAfter *metav1.Time `json:"evictAfter,omitempty"`
+
+	// EvictionGracePeriod spreads the eviction of workloads after EvictAfter over
+	// the given duration instead of evicting all of them at once, in order to
+	// reduce the churn on the cluster. No new workloads are scheduled to the
+	// cluster after EvictAfter. By default, all workloads are evicted at once.
+	// +optional
+	EvictionGracePeriod *metav1.Duration `json:"evictionGracePeriod,omitempty"`
+
+	// NamespaceSelector restricts the namespaces whose workloads can be scheduled
+	// to this SyncTarget. Only namespaces whose labels match the selector are
+	// scheduled to it. A nil or empty selector accepts all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// MinSyncedResources is the minimum number of resources in status.syncedResources
+	// for the SyncTarget to become Ready. By default, no resources are required.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinSyncedResources int32 `json:"minSyncedResources,omitempty"`
+
+	// RescheduleCooldown is the duration after the SyncTarget returns to Ready, after
+	// having been not Ready, during which no new workloads are scheduled to it. This
+	// prevents workloads from flapping between clusters when the SyncTarget
+	// oscillates between ready and unreachable. By default, workloads are scheduled
+	// to the SyncTarget as soon as it is Ready.
+	// +optional
+	RescheduleCooldown *metav1.Duration `json:"rescheduleCooldown,omitempty"`
+
+	// MaxImportedResources caps the number of resources the syncer imports from the
+	// cluster, in order to bound memory and import time. When more resources are
+	// available, the resources imported already are kept, the others are imported in
+	// alphabetical order up to the cap, and the APIImportComplete condition lists the
+	// skipped resources. By default, all resources are imported.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxImportedResources *int32 `json:"maxImportedResources,omitempty"`
+
+	// MaintenanceWindows are recurring periods during which the SyncTarget is cordoned
+	// automatically, i.e. no new workloads are scheduled to it as if spec.unschedulable
+	// was true. Workloads already scheduled to the SyncTarget are kept. During a window,
+	// the OutsideMaintenanceWindow condition is false.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Mode selects the direction of the SyncTarget. In Import mode, the syncer imports the
+	// APIs of the cluster into kcp for discovery, but no workloads are scheduled to the
+	// SyncTarget. In Sync mode, workloads are synced to the cluster, but its APIs are not
+	// imported as APIResourceImports. In Both mode, the default, APIs are imported and
+	// workloads are synced.
+	// +optional
+	// +kubebuilder:default=Both
+	// +kubebuilder:validation:Enum=Import;Sync;Both
+	Mode SyncTargetMode `json:"mode,omitempty"`
+
+	// SyncerQPS limits the queries per second of the syncer to the cluster, in order to
+	// not overwhelm clusters with a small API server. It overrides the --qps flag of the
+	// syncer and is read when the syncer starts. By default, or if zero, the flag applies.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SyncerQPS *int32 `json:"syncerQPS,omitempty"`
+
+	// SyncerBurst limits the burst of queries of the syncer to the cluster. It overrides
+	// the --burst flag of the syncer and is read when the syncer starts. By default, or
+	// if zero, the flag applies.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SyncerBurst *int32 `json:"syncerBurst,omitempty"`
+
+	// SyncerImage pins the container image of the syncer deployed for this SyncTarget,
+	// e.g. ghcr.io/kcp-dev/kcp/syncer:v0.8.0. The workload sync plugin uses it in the
+	// syncer deployment and rejects a different --syncer-image. By default, the image
+	// is taken from --syncer-image.
+	// +optional
+	SyncerImage string `json:"syncerImage,omitempty"`
+
+	// SupportedAPIExportSelector selects the APIExports in the workspace of the SyncTarget
+	// whose APIs are synced to the cluster. Only the APIs of the matching APIExports are
+	// served to the syncer. An empty selector matches all APIExports. By default, only the
+	// APIs of the kubernetes APIExport are synced.
+	// +optional
+	SupportedAPIExportSelector *metav1.LabelSelector `json:"supportedAPIExportSelector,omitempty"`
+
+	// NamespaceMappingStrategy selects how the syncer names the downstream namespaces of
+	// upstream namespaces. With Prefixed, the default, downstream namespaces are named
+	// kcp-<hash> of the workspace and name of the upstream namespace, such that namespaces of
+	// different workspaces do not collide. With Mirror, downstream namespaces have the name of
+	// the upstream namespace, which is meant for clusters synced from a single workspace. The
+	// strategy is read when the syncer starts, and status.namespaceMappingStrategy shows the
+	// one in effect.
+	// +optional
+	// +kubebuilder:default=Prefixed
+	// +kubebuilder:validation:Enum=Prefixed;Mirror
+	NamespaceMappingStrategy NamespaceMappingStrategy `json:"namespaceMappingStrategy,omitempty"`
+}
+
+// SyncTargetMode is the direction of a SyncTarget.
+type SyncTargetMode string
+
+const (
+	// SyncTargetModeImport only imports the APIs of the cluster, without syncing workloads to it.
+	SyncTargetModeImport SyncTargetMode = "Import"
+	// SyncTargetModeSync only syncs workloads to the cluster, without importing its APIs.
+	SyncTargetModeSync SyncTargetMode = "Sync"
+	// SyncTargetModeBoth imports the APIs of the cluster and syncs workloads to it.
+	SyncTargetModeBoth SyncTargetMode = "Both"
+)
+
+// NamespaceMappingStrategy is the naming of the downstream namespaces of upstream namespaces.
+type NamespaceMappingStrategy string
+
+const (
+	// NamespaceMappingStrategyPrefixed names downstream namespaces kcp-<hash> of the workspace
+	// and name of the upstream namespace.
+	NamespaceMappingStrategyPrefixed NamespaceMappingStrategy = "Prefixed"
+	// NamespaceMappingStrategyMirror gives downstream namespaces the name of the upstream namespace.
+	NamespaceMappingStrategyMirror NamespaceMappingStrategy = "Mirror"
+)
+
+// MaintenanceWindow is a recurring period of time.
+type MaintenanceWindow struct {
+	// Schedule is the start of the window in cron format, i.e. five fields for the
+	// minute, hour, day of month, month and day of week, evaluated in UTC. For
+	// example, "0 2 * * 6" starts the window every Saturday at 02:00 UTC.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Schedule string `json:"schedule"`
+
+	// Duration is the length of the window.
+	//
+	// +required
+	Duration metav1.Duration `json:"duration"`
 }
 
 // SyncTargetStatus communicates the observed state of the SyncTarget (from the controller).
@@ -82,16 +216,150 @@ type SyncTargetStatus struct {
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
 
+	// TransitionHistory lists the latest status changes of the conditions, oldest first.
+	// It is capped at 20 entries, dropping the oldest.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	TransitionHistory []ConditionTransition `json:"transitionHistory,omitempty"`
+
 	// +optional
 	SyncedResources []string `json:"syncedResources,omitempty"`
 
+	// ImportedVersions maps the synced resources, in the format <resource>.<group>,
+	// to the API version imported from the downstream cluster.
+	// +optional
+	ImportedVersions map[string]string `json:"importedVersions,omitempty"`
+
+	// KubernetesVersion is the Kubernetes version of the downstream cluster as
+	// reported by the syncer, e.g. v1.24.3.
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
 	// A timestamp indicating when the syncer last reported status.
 	// +optional
 	LastSyncerHeartbeatTime *metav1.Time `json:"lastSyncerHeartbeatTime,omitempty"`
 
+	// LastSyncTime is the time the syncer last synced an object between kcp and
+	// the downstream cluster. Together with lastSyncerHeartbeatTime, it tells a
+	// syncer that is connected but idle or stuck apart from one that is actively syncing.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// SyncedObjectCount is the number of objects the syncer synced between kcp
+	// and the downstream cluster since it was started.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SyncedObjectCount int64 `json:"syncedObjectCount,omitempty"`
+
+	// ResourceSyncStatus reports the sync health of each resource the syncer has
+	// synced objects of, or failed to, since it was started, sorted by group,
+	// resource and version.
+	// +optional
+	ResourceSyncStatus []ResourceSyncStatus `json:"resourceSyncStatus,omitempty"`
+
+	// EvictionProgress is the percentage of the eviction grace period passed since
+	// spec.evictAfter, i.e. approximately the percentage of workloads evicted from
+	// the cluster. It is only set after spec.evictAfter.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	EvictionProgress *int32 `json:"evictionProgress,omitempty"`
+
+	// RescheduleCooldownUntil is the time until which no new workloads are scheduled
+	// to the SyncTarget after it returned to Ready, according to spec.rescheduleCooldown.
+	// It is unset when no cooldown is in effect.
+	// +optional
+	RescheduleCooldownUntil *metav1.Time `json:"rescheduleCooldownUntil,omitempty"`
+
+	// NextMaintenanceWindow is the start of the next maintenance window of
+	// spec.maintenanceWindows that has not started yet.
+	// +optional
+	NextMaintenanceWindow *metav1.Time `json:"nextMaintenanceWindow,omitempty"`
+
 	// VirtualWorkspaces contains all syncer virtual workspace URLs.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// Endpoints contains the API endpoints of the downstream cluster as reported
+	// by the syncer. Workloads can use them to reach the downstream cluster
+	// directly, not through kcp. In contrast, VirtualWorkspaces are served by kcp
+	// for the syncer to reach kcp.
+	// +optional
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+
+	// SupportedAPIExports are the names of the APIExports whose APIs are synced to the
+	// cluster, resolved from spec.supportedAPIExportSelector, sorted by name.
+	// +optional
+	SupportedAPIExports []string `json:"supportedAPIExports,omitempty"`
+
+	// NamespaceCollisions lists the upstream namespaces the syncer cannot sync because
+	// their downstream namespace belongs to another upstream namespace, as reported by the
+	// syncer, sorted by downstream namespace. Collisions are removed when the upstream
+	// namespace can be synced again.
+	// +optional
+	NamespaceCollisions []NamespaceCollision `json:"namespaceCollisions,omitempty"`
+
+	// Capabilities are the capabilities of the cluster as reported by the syncer, sorted,
+	// i.e. storageclass/<name> for every storage class, and gpu if any node has allocatable
+	// GPUs. Placements only schedule namespaces to sync targets with all of their
+	// spec.requiredCapabilities.
+	// +optional
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// NamespaceMappingStrategy is the spec.namespaceMappingStrategy in effect, as reported
+	// by the syncer.
+	// +optional
+	NamespaceMappingStrategy NamespaceMappingStrategy `json:"namespaceMappingStrategy,omitempty"`
+
+	// SyncGeneration is the generation of the running syncer, advanced every time a syncer
+	// starts. The syncer labels the objects it syncs downstream with the generation in the
+	// workload.kcp.dev/sync-generation label, i.e. objects with another generation have not
+	// been synced by the running syncer and might be stale.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SyncGeneration int64 `json:"syncGeneration,omitempty"`
+
+	// SyncerCertificateNotAfter is the expiry time of the client certificate the syncer uses to
+	// connect to kcp, as reported by the syncer. It is not set if the syncer does not
+	// authenticate with a client certificate.
+	// +optional
+	SyncerCertificateNotAfter *metav1.Time `json:"syncerCertificateNotAfter,omitempty"`
+
+	// SyncerCertificateDaysToExpiry is the number of full days until the client certificate of
+	// the syncer expires, or 0 if it has expired.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SyncerCertificateDaysToExpiry *int64 `json:"syncerCertificateDaysToExpiry,omitempty"`
+}
+
+// ConditionTransition is a change of the status of a condition of a SyncTarget.
+type ConditionTransition struct {
+	// Type is the type of the condition.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Type conditionsv1alpha1.ConditionType `json:"type"`
+
+	// From is the status of the condition before the transition. It is empty
+	// for the first recorded status of the condition.
+	// +optional
+	From corev1.ConditionStatus `json:"from,omitempty"`
+
+	// To is the status of the condition after the transition.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	To corev1.ConditionStatus `json:"to"`
+
+	// Timestamp is the time of the transition.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// Reason is the reason of the condition after the transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
 }
 
 type VirtualWorkspace struct {
@@ -103,6 +371,74 @@ type VirtualWorkspace struct {
 	URL string `json:"url"`
 }
 
+// ResourceSyncStatus describes the sync health of a resource.
+type ResourceSyncStatus struct {
+	// Group is the API group of the resource, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version is the API version of the resource.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Version string `json:"version"`
+
+	// Resource is the name of the resource.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Resource string `json:"resource"`
+
+	// LastSyncTime is the time the syncer last synced an object of the resource.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// ErrorCount is the number of failed syncs of objects of the resource since
+	// the last successful sync. It is zero for a healthy resource.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ErrorCount int64 `json:"errorCount,omitempty"`
+
+	// LastError is the error of the last failed sync of an object of the resource.
+	// It is cleared by a successful sync.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// NamespaceCollision is an upstream namespace which is not synced because its downstream
+// namespace belongs to another upstream namespace.
+type NamespaceCollision struct {
+	// DownstreamNamespace is the namespace in the downstream cluster.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	DownstreamNamespace string `json:"downstreamNamespace"`
+
+	// Workspace is the workspace of the upstream namespace which is not synced.
+	// +optional
+	Workspace string `json:"workspace,omitempty"`
+
+	// Namespace is the upstream namespace which is not synced.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Message describes the collision, e.g. the upstream namespace the downstream
+	// namespace belongs to.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type Endpoint struct {
+	// URL is the URL of the API endpoint of the downstream cluster.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:format:URL
+	// +required
+	URL string `json:"url"`
+}
+
 // SyncTargetList is a list of SyncTarget resources
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -113,6 +449,15 @@ type SyncTargetList struct {
 	Items []SyncTarget `json:"items"`
 }
 
+const (
+	// StorageClassCapabilityPrefix prefixes the name of a storage class of the cluster in
+	// status.capabilities.
+	StorageClassCapabilityPrefix = "storageclass/"
+	// GPUCapability in status.capabilities means at least one node of the cluster has
+	// allocatable GPUs.
+	GPUCapability = "gpu"
+)
+
 // Conditions and ConditionReasons for the kcp SyncTarget object.
 const (
 	// SyncerReady means the syncer is ready to transfer resources between KCP and the SyncTarget.
@@ -124,6 +469,36 @@ const (
 	// HeartbeatHealthy means the HeartbeatManager has seen a heartbeat for the SyncTarget within the expected interval.
 	HeartbeatHealthy conditionsv1alpha1.ConditionType = "HeartbeatHealthy"
 
+	// APIImportUpToDate means the SyncTarget syncs all resources of the compute APIExport of its workspace.
+	APIImportUpToDate conditionsv1alpha1.ConditionType = "APIImportUpToDate"
+
+	// APIImportComplete means the syncer imports all the resources it is configured to sync, i.e. none is skipped
+	// due to spec.maxImportedResources.
+	APIImportComplete conditionsv1alpha1.ConditionType = "APIImportComplete"
+
+	// ResourceReportConsistent means no resource in status.allocatable exceeds the same resource in status.capacity.
+	ResourceReportConsistent conditionsv1alpha1.ConditionType = "ResourceReportConsistent"
+
+	// OutsideMaintenanceWindow means the SyncTarget is not in one of the maintenance windows of spec.maintenanceWindows,
+	// i.e. it is not cordoned by a maintenance window.
+	OutsideMaintenanceWindow conditionsv1alpha1.ConditionType = "OutsideMaintenanceWindow"
+
+	// VersionCompatible means the Kubernetes version of the downstream cluster is within the supported
+	// skew of the Kubernetes version of kcp.
+	VersionCompatible conditionsv1alpha1.ConditionType = "VersionCompatible"
+
+	// LocationMember means the labels of the SyncTarget match the instance selector of at least one Location
+	// in its workspace, i.e. workloads can be placed on it.
+	LocationMember conditionsv1alpha1.ConditionType = "LocationMember"
+
+	// NoNamespaceCollision means the syncer reports no status.namespaceCollisions, i.e. every upstream
+	// namespace is synced to a downstream namespace of its own.
+	NoNamespaceCollision conditionsv1alpha1.ConditionType = "NoNamespaceCollision"
+
+	// SyncerCertValid means the client certificate the syncer uses to connect to kcp has not expired.
+	// It is only set if the syncer reports status.syncerCertificateNotAfter.
+	SyncerCertValid conditionsv1alpha1.ConditionType = "SyncerCertValid"
+
 	// SyncTargetUnknownReason documents a SyncTarget which readiness is unknown.
 	SyncTargetUnknownReason = "SyncTargetStatusUnknown"
 
@@ -153,6 +528,39 @@ const (
 
 	// ErrorHeartbeatMissedReason indicates that a heartbeat update was not received within the configured threshold.
 	ErrorHeartbeatMissedReason = "ErrorHeartbeat"
+
+	// InsufficientSyncedResourcesReason indicates that fewer resources than spec.minSyncedResources are synced.
+	InsufficientSyncedResourcesReason = "InsufficientSyncedResources"
+
+	// MissingImportedResourcesReason indicates that resources of the compute APIExport are not synced by the SyncTarget yet.
+	MissingImportedResourcesReason = "MissingImportedResources"
+
+	// ImportTruncatedReason indicates that resources are not imported because of spec.maxImportedResources.
+	ImportTruncatedReason = "ImportTruncated"
+
+	// VersionSkewTooLargeReason indicates that the Kubernetes version of the downstream cluster differs
+	// from the one of kcp by more than the supported number of minor versions.
+	VersionSkewTooLargeReason = "VersionSkewTooLarge"
+
+	// ResourceReportInconsistentReason indicates that the syncer reports more allocatable than capacity for some resources.
+	ResourceReportInconsistentReason = "ResourceReportInconsistent"
+
+	// InMaintenanceWindowReason indicates that the SyncTarget is cordoned because one of its maintenance windows is active.
+	InMaintenanceWindowReason = "InMaintenanceWindow"
+
+	// NoMatchingLocationReason indicates that the labels of the SyncTarget match no Location in its workspace.
+	NoMatchingLocationReason = "NoMatchingLocation"
+
+	// NamespaceCollisionReason indicates that upstream namespaces are not synced because their downstream
+	// namespaces belong to other upstream namespaces.
+	NamespaceCollisionReason = "NamespaceCollision"
+
+	// SyncerCertExpiringReason indicates that the client certificate of the syncer expires within the
+	// configured warning window.
+	SyncerCertExpiringReason = "SyncerCertExpiring"
+
+	// SyncerCertExpiredReason indicates that the client certificate of the syncer has expired.
+	SyncerCertExpiredReason = "SyncerCertExpired"
 )
 
 func (in *SyncTarget) SetConditions(conditions conditionsv1alpha1.Conditions) {
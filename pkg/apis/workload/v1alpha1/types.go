@@ -105,10 +105,30 @@ const (
 	// The format for the value of this annotation is: JSON Patch (https://tools.ietf.org/html/rfc6902).
 	ClusterSpecDiffAnnotationPrefix = "experimental.spec-diff.workload.kcp.dev/"
 
+	// PreferredAPIVersionsAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/preferred-api-versions
+	//
+	// on sync targets pinning the API version of synced resources imported from the
+	// downstream cluster, instead of the version preferred by the downstream cluster.
+	//
+	// The format is a comma-separated list of <resource>.<group>=<version>, e.g.
+	// "widgets.example.io=v1beta1,deployments.apps=v1".
+	PreferredAPIVersionsAnnotationKey = "workload.kcp.dev/preferred-api-versions"
+
 	// InternalDownstreamClusterLabel is a label with the upstream cluster name applied on the downstream cluster
 	// instead of state.workload.kcp.dev/<sync-target-name> which is used upstream.
 	InternalDownstreamClusterLabel = "internal.workload.kcp.dev/cluster"
 
+	// SyncGenerationLabel is the label
+	//
+	//   workload.kcp.dev/sync-generation
+	//
+	// on downstream resources holding the status.syncGeneration of the sync target when the
+	// syncer last synced the resource. Resources with another generation than the current
+	// one of the sync target have not been synced by the running syncer.
+	SyncGenerationLabel = "workload.kcp.dev/sync-generation"
+
 	// AnnotationSkipDefaultObjectCreation is the annotation key for an apiexport or apibinding indicating the other default resources
 	// has been created already. If the created default resource is deleted, it will not be recreated.
 	AnnotationSkipDefaultObjectCreation = "workload.kcp.dev/skip-default-object-creation"
@@ -24,11 +24,98 @@ package v1alpha1
 import (
 	v1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionTransition) DeepCopyInto(out *ConditionTransition) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionTransition.
+func (in *ConditionTransition) DeepCopy() *ConditionTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Endpoint) DeepCopyInto(out *Endpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
+func (in *Endpoint) DeepCopy() *Endpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(Endpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceCollision) DeepCopyInto(out *NamespaceCollision) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceCollision.
+func (in *NamespaceCollision) DeepCopy() *NamespaceCollision {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceCollision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceSyncStatus) DeepCopyInto(out *ResourceSyncStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSyncStatus.
+func (in *ResourceSyncStatus) DeepCopy() *ResourceSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncTarget) DeepCopyInto(out *SyncTarget) {
 	*out = *in
@@ -97,6 +184,46 @@ func (in *SyncTargetSpec) DeepCopyInto(out *SyncTargetSpec) {
 		in, out := &in.EvictAfter, &out.EvictAfter
 		*out = (*in).DeepCopy()
 	}
+	if in.EvictionGracePeriod != nil {
+		in, out := &in.EvictionGracePeriod, &out.EvictionGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RescheduleCooldown != nil {
+		in, out := &in.RescheduleCooldown, &out.RescheduleCooldown
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxImportedResources != nil {
+		in, out := &in.MaxImportedResources, &out.MaxImportedResources
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.SyncerQPS != nil {
+		in, out := &in.SyncerQPS, &out.SyncerQPS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SyncerBurst != nil {
+		in, out := &in.SyncerBurst, &out.SyncerBurst
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SupportedAPIExportSelector != nil {
+		in, out := &in.SupportedAPIExportSelector, &out.SupportedAPIExportSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -142,20 +269,87 @@ func (in *SyncTargetStatus) DeepCopyInto(out *SyncTargetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TransitionHistory != nil {
+		in, out := &in.TransitionHistory, &out.TransitionHistory
+		*out = make([]ConditionTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.SyncedResources != nil {
 		in, out := &in.SyncedResources, &out.SyncedResources
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ImportedVersions != nil {
+		in, out := &in.ImportedVersions, &out.ImportedVersions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.LastSyncerHeartbeatTime != nil {
 		in, out := &in.LastSyncerHeartbeatTime, &out.LastSyncerHeartbeatTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ResourceSyncStatus != nil {
+		in, out := &in.ResourceSyncStatus, &out.ResourceSyncStatus
+		*out = make([]ResourceSyncStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EvictionProgress != nil {
+		in, out := &in.EvictionProgress, &out.EvictionProgress
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RescheduleCooldownUntil != nil {
+		in, out := &in.RescheduleCooldownUntil, &out.RescheduleCooldownUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.NextMaintenanceWindow != nil {
+		in, out := &in.NextMaintenanceWindow, &out.NextMaintenanceWindow
+		*out = (*in).DeepCopy()
+	}
 	if in.VirtualWorkspaces != nil {
 		in, out := &in.VirtualWorkspaces, &out.VirtualWorkspaces
 		*out = make([]VirtualWorkspace, len(*in))
 		copy(*out, *in)
 	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]Endpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.SupportedAPIExports != nil {
+		in, out := &in.SupportedAPIExports, &out.SupportedAPIExports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceCollisions != nil {
+		in, out := &in.NamespaceCollisions, &out.NamespaceCollisions
+		*out = make([]NamespaceCollision, len(*in))
+		copy(*out, *in)
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SyncerCertificateNotAfter != nil {
+		in, out := &in.SyncerCertificateNotAfter, &out.SyncerCertificateNotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.SyncerCertificateDaysToExpiry != nil {
+		in, out := &in.SyncerCertificateDaysToExpiry, &out.SyncerCertificateDaysToExpiry
+		*out = new(int64)
+		**out = **in
+	}
 	return
 }
 
@@ -103,10 +103,6 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 				return cmd.Help()
 			}
 
-			if len(syncerImage) == 0 {
-				return errors.New("a value must be specified for --syncer-image")
-			}
-
 			if len(kcpNamespace) == 0 {
 				return errors.New("a value must be specified for --kcp-namespace")
 			}
@@ -144,7 +140,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 		},
 	}
 	enableSyncerCmd.Flags().StringSliceVar(&userResourcesToSync, "resources", userResourcesToSync, "Resources to synchronize with kcp.")
-	enableSyncerCmd.Flags().StringVar(&syncerImage, "syncer-image", syncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer.")
+	enableSyncerCmd.Flags().StringVar(&syncerImage, "syncer-image", syncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer. Required unless the sync target pins an image in spec.syncerImage, which it must match otherwise.")
 	enableSyncerCmd.Flags().IntVar(&replicas, "replicas", replicas, "Number of replicas of the syncer deployment.")
 	enableSyncerCmd.Flags().StringVar(&kcpNamespace, "kcp-namespace", kcpNamespace, "The name of the kcp namespace to create a service account in.")
 	enableSyncerCmd.Flags().StringVarP(&outputFile, "output-file", "o", outputFile, "The manifest file to be created and applied to the physical cluster. Use - for stdout.")
@@ -86,7 +86,13 @@ func (c *Config) Sync(
 		defer outputFile.Close() // nolint: errcheck
 	}
 
-	token, syncerID, err := c.enableSyncerForWorkspace(ctx, config, syncTargetName, kcpNamespaceName)
+	token, syncTarget, err := c.enableSyncerForWorkspace(ctx, config, syncTargetName, kcpNamespaceName)
+	if err != nil {
+		return err
+	}
+	syncerID := getSyncerID(syncTarget)
+
+	image, err = syncerImage(syncTarget, image)
 	if err != nil {
 		return err
 	}
@@ -144,13 +150,31 @@ func getSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
 	return fmt.Sprintf("kcp-syncer-%s-%s", syncTarget.Name, base36hash[:8])
 }
 
+// syncerImage returns the image pinned in spec.syncerImage of the sync target, or the
+// given image from --syncer-image if the sync target does not pin one. It fails if
+// the given image differs from the pinned one.
+func syncerImage(syncTarget *workloadv1alpha1.SyncTarget, image string) (string, error) {
+	pinned := syncTarget.Spec.SyncerImage
+	switch {
+	case pinned == "" && image == "":
+		return "", fmt.Errorf("a value must be specified for --syncer-image, synctarget %q does not pin an image in spec.syncerImage", syncTarget.Name)
+	case pinned == "":
+		return image, nil
+	case image != "" && image != pinned:
+		return "", fmt.Errorf("synctarget %q pins the syncer image %q in spec.syncerImage, but --syncer-image is %q", syncTarget.Name, pinned, image)
+	default:
+		return pinned, nil
+	}
+}
+
 // enableSyncerForWorkspace creates a sync target with the given name and creates a service
 // account for the syncer in the given namespace. The expectation is that the provided config is
-// for a logical cluster (workspace). Returns the token the syncer will use to connect to kcp.
-func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Config, syncTargetName, namespace string) (string, string, error) {
+// for a logical cluster (workspace). Returns the token the syncer will use to connect to kcp
+// and the sync target.
+func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Config, syncTargetName, namespace string) (string, *workloadv1alpha1.SyncTarget, error) {
 	kcpClient, err := kcpclientset.NewForConfig(config)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create kcp client: %w", err)
+		return "", nil, fmt.Errorf("failed to create kcp client: %w", err)
 	}
 
 	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx,
@@ -158,7 +182,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		metav1.GetOptions{},
 	)
 	if err != nil && !errors.IsNotFound(err) {
-		return "", "", fmt.Errorf("failed to get synctarget %q: %w", syncTargetName, err)
+		return "", nil, fmt.Errorf("failed to get synctarget %q: %w", syncTargetName, err)
 	} else if errors.IsNotFound(err) {
 		// Create the sync target that will serve as a point of coordination between
 		// kcp and the syncer (e.g. heartbeating from the syncer and virtual cluster urls
@@ -174,7 +198,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			metav1.CreateOptions{},
 		)
 		if err != nil && !errors.IsAlreadyExists(err) {
-			return "", "", fmt.Errorf("failed to create synctarget %q: %w", syncTargetName, err)
+			return "", nil, fmt.Errorf("failed to create synctarget %q: %w", syncTargetName, err)
 		}
 	} else if err == nil {
 		// nolint: errcheck
@@ -183,7 +207,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 
 	kubeClient, err := kubernetesclientset.NewForConfig(config)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create kubernetes client: %w", err)
+		return "", nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
 	syncerID := getSyncerID(syncTarget)
@@ -205,7 +229,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 				OwnerReferences: syncTargetOwnerReferences,
 			},
 		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
-			return "", "", fmt.Errorf("failed to create ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
+			return "", nil, fmt.Errorf("failed to create ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
 		}
 	case err == nil:
 		oldData, err := json.Marshal(corev1.ServiceAccount{
@@ -214,7 +238,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			},
 		})
 		if err != nil {
-			return "", "", fmt.Errorf("failed to marshal old data for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
+			return "", nil, fmt.Errorf("failed to marshal old data for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
 		}
 
 		newData, err := json.Marshal(corev1.ServiceAccount{
@@ -225,20 +249,20 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			},
 		})
 		if err != nil {
-			return "", "", fmt.Errorf("failed to marshal new data for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
+			return "", nil, fmt.Errorf("failed to marshal new data for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
 		}
 
 		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
 		if err != nil {
-			return "", "", fmt.Errorf("failed to create patch for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
+			return "", nil, fmt.Errorf("failed to create patch for ServiceAccount %s|%s/%s: %w", syncTargetName, namespace, syncerID, err)
 		}
 
 		c.ErrOut.Write([]byte(fmt.Sprintf("Updating service account %q.\n", syncerID))) // nolint: errcheck
 		if sa, err = kubeClient.CoreV1().ServiceAccounts(namespace).Patch(ctx, sa.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
-			return "", "", fmt.Errorf("failed to patch ServiceAccount %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
+			return "", nil, fmt.Errorf("failed to patch ServiceAccount %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
 		}
 	default:
-		return "", "", fmt.Errorf("failed to get the ServiceAccount %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
+		return "", nil, fmt.Errorf("failed to get the ServiceAccount %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
 	}
 
 	// Create a cluster role that provides the syncer the minimal permissions
@@ -282,7 +306,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			},
 			Rules: rules,
 		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
-			return "", "", err
+			return "", nil, err
 		}
 	case err == nil:
 		oldData, err := json.Marshal(rbacv1.ClusterRole{
@@ -292,7 +316,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			Rules: cr.Rules,
 		})
 		if err != nil {
-			return "", "", fmt.Errorf("failed to marshal old data for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
+			return "", nil, fmt.Errorf("failed to marshal old data for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
 		}
 
 		newData, err := json.Marshal(rbacv1.ClusterRole{
@@ -304,20 +328,20 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			Rules: rules,
 		})
 		if err != nil {
-			return "", "", fmt.Errorf("failed to marshal new data for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
+			return "", nil, fmt.Errorf("failed to marshal new data for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
 		}
 
 		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
 		if err != nil {
-			return "", "", fmt.Errorf("failed to create patch for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
+			return "", nil, fmt.Errorf("failed to create patch for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
 		}
 
 		c.ErrOut.Write([]byte(fmt.Sprintf("Updating cluster role %q with\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports.\n\n", syncerID, syncerID))) // nolint: errcheck
 		if _, err = kubeClient.RbacV1().ClusterRoles().Patch(ctx, cr.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
-			return "", "", fmt.Errorf("failed to patch ClusterRole %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
+			return "", nil, fmt.Errorf("failed to patch ClusterRole %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
 		}
 	default:
-		return "", "", err
+		return "", nil, err
 	}
 
 	// Grant the service account the role created just above in the workspace
@@ -336,11 +360,11 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		syncerID,
 		metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
-		return "", "", err
+		return "", nil, err
 	}
 	if err == nil {
 		if err := kubeClient.RbacV1().ClusterRoleBindings().Delete(ctx, syncerID, metav1.DeleteOptions{}); err != nil {
-			return "", "", err
+			return "", nil, err
 		}
 	}
 
@@ -353,7 +377,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		Subjects: subjects,
 		RoleRef:  roleRef,
 	}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
-		return "", "", err
+		return "", nil, err
 	}
 
 	// Wait for the service account to be updated with the name of the token secret
@@ -371,20 +395,20 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		return true, nil
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("timed out waiting for token secret name to be set on ServiceAccount %s/%s", namespace, sa.Name)
+		return "", nil, fmt.Errorf("timed out waiting for token secret name to be set on ServiceAccount %s/%s", namespace, sa.Name)
 	}
 
 	// Retrieve the token that the syncer will use to authenticate to kcp
 	tokenSecret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, tokenSecretName, metav1.GetOptions{})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to retrieve Secret: %w", err)
+		return "", nil, fmt.Errorf("failed to retrieve Secret: %w", err)
 	}
 	saToken := tokenSecret.Data["token"]
 	if len(saToken) == 0 {
-		return "", "", fmt.Errorf("token secret %s/%s is missing a value for `token`", namespace, tokenSecretName)
+		return "", nil, fmt.Errorf("token secret %s/%s is missing a value for `token`", namespace, tokenSecretName)
 	}
 
-	return string(saToken), syncerID, nil
+	return string(saToken), syncTarget, nil
 }
 
 // mergeOwnerReference: merge a slice of ownerReference with a given ownerReferences
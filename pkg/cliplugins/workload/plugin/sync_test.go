@@ -21,6 +21,10 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 func TestNewSyncerYAML(t *testing.T) {
@@ -60,6 +64,18 @@ rules:
   - "get"
   - "watch"
   - "list"
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - "list"
+- apiGroups:
+  - "storage.k8s.io"
+  resources:
+  - storageclasses
+  verbs:
+  - "list"
 - apiGroups:
   - ""
   resources:
@@ -240,3 +256,55 @@ func TestGetGroupMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncerImage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pinned   string
+		image    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "image from flag",
+			image:    "ghcr.io/kcp-dev/kcp/syncer:v0.8.0",
+			expected: "ghcr.io/kcp-dev/kcp/syncer:v0.8.0",
+		},
+		{
+			name:     "pinned image without flag",
+			pinned:   "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+			expected: "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+		},
+		{
+			name:     "pinned image matching flag",
+			pinned:   "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+			image:    "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+			expected: "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+		},
+		{
+			name:    "pinned image differing from flag",
+			pinned:  "ghcr.io/kcp-dev/kcp/syncer:v0.7.0",
+			image:   "ghcr.io/kcp-dev/kcp/syncer:v0.8.0",
+			wantErr: true,
+		},
+		{
+			name:    "neither pinned image nor flag",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			syncTarget := &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "sync-target-name"},
+				Spec:       workloadv1alpha1.SyncTargetSpec{SyncerImage: tc.pinned},
+			}
+			actual, err := syncerImage(syncTarget, tc.image)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -53,6 +53,9 @@ type SchemaPuller interface {
 	// PullCRDs allows pulling the resources named by their plural names
 	// and make them available as CRDs in the output map.
 	PullCRDs(context context.Context, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error)
+	// PullCRDsWithPreferredVersions is like PullCRDs, but pulls the given version of a
+	// resource instead of the version preferred by the cluster, if it is served.
+	PullCRDsWithPreferredVersions(context context.Context, preferredVersions map[schema.GroupResource]string, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error)
 }
 
 type schemaPuller struct {
@@ -102,6 +105,12 @@ func newPuller(discoveryClient discovery.DiscoveryInterface, crdClient apiextens
 // and make them available as CRDs in the output map.
 // If the list of resources is empty, it will try pulling all the resources it finds.
 func (sp *schemaPuller) PullCRDs(context context.Context, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
+	return sp.PullCRDsWithPreferredVersions(context, nil, resourceNames...)
+}
+
+// PullCRDsWithPreferredVersions is like PullCRDs, but pulls the given version of a
+// resource instead of the version preferred by the cluster, if it is served.
+func (sp *schemaPuller) PullCRDsWithPreferredVersions(context context.Context, preferredVersions map[schema.GroupResource]string, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(sp.discoveryClient))
 	pullAllResources := len(resourceNames) == 0
 	resourcesToPull := sets.NewString()
@@ -115,12 +124,12 @@ func (sp *schemaPuller) PullCRDs(context context.Context, resourceNames ...strin
 		resourcesToPull.Insert(gvr.GroupResource().String())
 	}
 
-	_, apiResourcesLists, err := sp.discoveryClient.ServerGroupsAndResources()
+	_, allAPIResourcesLists, err := sp.discoveryClient.ServerGroupsAndResources()
 	if err != nil {
 		return nil, err
 	}
 	apiResourceNames := map[schema.GroupVersion]sets.String{}
-	for _, apiResourcesList := range apiResourcesLists {
+	for _, apiResourcesList := range allAPIResourcesLists {
 		gv, err := schema.ParseGroupVersion(apiResourcesList.GroupVersion)
 		if err != nil {
 			continue
@@ -134,10 +143,13 @@ func (sp *schemaPuller) PullCRDs(context context.Context, resourceNames ...strin
 	}
 
 	crds := map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition{}
-	apiResourcesLists, err = sp.discoveryClient.ServerPreferredResources()
+	apiResourcesLists, err := sp.discoveryClient.ServerPreferredResources()
 	if err != nil {
 		return nil, err
 	}
+	if len(preferredVersions) > 0 {
+		apiResourcesLists = withPreferredVersions(apiResourcesLists, allAPIResourcesLists, preferredVersions)
+	}
 	for _, apiResourcesList := range apiResourcesLists {
 		gv, err := schema.ParseGroupVersion(apiResourcesList.GroupVersion)
 		if err != nil {
@@ -328,6 +340,58 @@ type SchemaConverter struct {
 	visited     sets.String
 }
 
+// withPreferredVersions replaces the resources of the preferred API resource lists with
+// the given preferred versions found in all API resource lists.
+func withPreferredVersions(preferredLists, allLists []*metav1.APIResourceList, preferredVersions map[schema.GroupResource]string) []*metav1.APIResourceList {
+	served := map[schema.GroupVersionResource]metav1.APIResource{}
+	for _, list := range allLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range list.APIResources {
+			served[gv.WithResource(apiResource.Name)] = apiResource
+		}
+	}
+
+	var ret []*metav1.APIResourceList
+	listsByGroupVersion := map[string]*metav1.APIResourceList{}
+	add := func(gv schema.GroupVersion, apiResource metav1.APIResource) {
+		list, found := listsByGroupVersion[gv.String()]
+		if !found {
+			list = &metav1.APIResourceList{GroupVersion: gv.String()}
+			listsByGroupVersion[gv.String()] = list
+			ret = append(ret, list)
+		}
+		list.APIResources = append(list.APIResources, apiResource)
+	}
+
+	for _, list := range preferredLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			ret = append(ret, list)
+			continue
+		}
+		for _, apiResource := range list.APIResources {
+			gr := gv.WithResource(apiResource.Name).GroupResource()
+			version, found := preferredVersions[gr]
+			if !found || version == gv.Version {
+				add(gv, apiResource)
+				continue
+			}
+			preferredGVR := gr.WithVersion(version)
+			preferredResource, found := served[preferredGVR]
+			if !found {
+				klog.Errorf("preferred version %s of resource %s is not served, using %s instead", version, gr, gv.Version)
+				add(gv, apiResource)
+				continue
+			}
+			add(preferredGVR.GroupVersion(), preferredResource)
+		}
+	}
+	return ret
+}
+
 func Convert(protoSchema proto.Schema, schemaProps *apiextensionsv1.JSONSchemaProps) []error {
 	swaggerSpecDefinitionName := protoSchema.GetPath().String()
 
@@ -23,7 +23,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	"github.com/stretchr/testify/require"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -56,6 +58,68 @@ func TestPuller(t *testing.T) {
 	}
 }
 
+func TestPullerPreferredVersions(t *testing.T) {
+	crdVersion := func(name string) apiextensionsv1.CustomResourceDefinitionVersion {
+		return apiextensionsv1.CustomResourceDefinitionVersion{
+			Name:    name,
+			Served:  true,
+			Storage: name == "v1",
+			Schema: &apiextensionsv1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object", Description: "widget " + name},
+			},
+		}
+	}
+	crdClient := fake.NewSimpleClientset(&apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group:    "example.io",
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{crdVersion("v1"), crdVersion("v1beta1")},
+		},
+	})
+
+	puller, err := newPuller(&twoVersionsDiscovery{}, crdClient.ApiextensionsV1())
+	require.NoError(t, err)
+
+	widgets := schema.GroupResource{Group: "example.io", Resource: "widgets"}
+	for _, tc := range []struct {
+		name              string
+		preferredVersions map[schema.GroupResource]string
+		wantVersion       string
+	}{
+		{name: "version preferred by the cluster", wantVersion: "v1"},
+		{name: "pinned version", preferredVersions: map[schema.GroupResource]string{widgets: "v1beta1"}, wantVersion: "v1beta1"},
+		{name: "pinned version not served", preferredVersions: map[schema.GroupResource]string{widgets: "v2"}, wantVersion: "v1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			crds, err := puller.PullCRDsWithPreferredVersions(context.Background(), tc.preferredVersions)
+			require.NoError(t, err)
+			require.Contains(t, crds, widgets)
+			require.Len(t, crds[widgets].Spec.Versions, 1)
+			require.Equal(t, tc.wantVersion, crds[widgets].Spec.Versions[0].Name)
+			require.Equal(t, "widget "+tc.wantVersion, crds[widgets].Spec.Versions[0].Schema.OpenAPIV3Schema.Description)
+		})
+	}
+}
+
+// twoVersionsDiscovery serves widgets.example.io in v1 and v1beta1, preferring v1.
+type twoVersionsDiscovery struct {
+	fakeDiscovery
+}
+
+func (twoVersionsDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	widgets := metav1.APIResource{Name: "widgets", Namespaced: true, Kind: "Widget"}
+	return nil, []*metav1.APIResourceList{
+		{GroupVersion: "example.io/v1", APIResources: []metav1.APIResource{widgets}},
+		{GroupVersion: "example.io/v1beta1", APIResources: []metav1.APIResource{widgets}},
+	}, nil
+}
+
+func (twoVersionsDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return []*metav1.APIResourceList{
+		{GroupVersion: "example.io/v1", APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Kind: "Widget"}}},
+	}, nil
+}
+
 type fakeDiscovery struct{}
 
 func (fakeDiscovery) RESTClient() rest.Interface {
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// a Saturday
+	start := time.Date(2022, 6, 4, 10, 17, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		schedule string
+		want     time.Time
+		wantErr  bool
+	}{
+		{schedule: "* * * * *", want: start.Add(time.Minute)},
+		{schedule: "*/15 * * * *", want: time.Date(2022, 6, 4, 10, 30, 0, 0, time.UTC)},
+		{schedule: "5,10 11 * * *", want: time.Date(2022, 6, 4, 11, 5, 0, 0, time.UTC)},
+		{schedule: "0 2 * * 6", want: time.Date(2022, 6, 11, 2, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 * * 7", want: time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 1 * *", want: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		// either day of month or day of week match if both are restricted.
+		{schedule: "0 0 1 * 1", want: time.Date(2022, 6, 6, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 29 2 *", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 30 2 *"},
+		{schedule: "0 0 * *", wantErr: true},
+		{schedule: "60 * * * *", wantErr: true},
+		{schedule: "0 0 0 * *", wantErr: true},
+		{schedule: "5-1 * * * *", wantErr: true},
+		{schedule: "*/0 * * * *", wantErr: true},
+		{schedule: "0 0 * JAN *", wantErr: true},
+	} {
+		s, err := Parse(tc.schedule)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) succeeded, want error", tc.schedule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", tc.schedule, err)
+			continue
+		}
+		if got := s.Next(start); !got.Equal(tc.want) {
+			t.Errorf("Next(%q) = %v, want %v", tc.schedule, got, tc.want)
+		}
+	}
+}
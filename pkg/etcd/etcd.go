@@ -35,15 +35,96 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
 	"go.etcd.io/etcd/server/v3/wal"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 )
 
+// DefaultStartTimeout is the default duration to wait for the embedded etcd server to become ready.
+const DefaultStartTimeout = 60 * time.Second
+
+const (
+	// DefaultHeartbeatIntervalMs is the default raft heartbeat interval of etcd in milliseconds.
+	DefaultHeartbeatIntervalMs = 100
+	// DefaultElectionTimeoutMs is the default raft election timeout of etcd in milliseconds.
+	DefaultElectionTimeoutMs = 1000
+)
+
 type Server struct {
 	Dir string
+
+	metricsRegistry     prometheus.Registerer
+	startTimeout        time.Duration
+	inMemory            bool
+	heartbeatIntervalMs uint
+	electionTimeoutMs   uint
+	sizeLogInterval     time.Duration
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithMetricsRegistry registers the metrics of the embedded etcd server into the given
+// registry, such that they are exposed together with the metrics of the server
+// embedding etcd instead of on a separate listener. It is mutually exclusive with
+// listen metrics URLs.
+func WithMetricsRegistry(registry prometheus.Registerer) ServerOption {
+	return func(s *Server) {
+		s.metricsRegistry = registry
+	}
+}
+
+// WithStartTimeout sets the duration Run waits for the embedded etcd server to become
+// ready before giving up. It defaults to DefaultStartTimeout.
+func WithStartTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.startTimeout = timeout
+	}
+}
+
+// WithInMemory stores the data of the embedded etcd server in a unique temporary directory,
+// on tmpfs if available, that is removed on shutdown, and skips fsyncs. The data does not
+// survive a restart, hence this is only meant for tests. The data dir passed to NewServer
+// is ignored.
+func WithInMemory() ServerOption {
+	return func(s *Server) {
+		s.inMemory = true
+	}
+}
+
+// WithRaftTimeouts sets the raft heartbeat interval and election timeout of the embedded etcd
+// server in milliseconds, e.g. to raise them on slow disks where the defaults of etcd cause
+// spurious leader elections. Zero values keep the defaults of etcd. The election timeout must
+// be at least five times the heartbeat interval.
+func WithRaftTimeouts(heartbeatIntervalMs, electionTimeoutMs uint) ServerOption {
+	return func(s *Server) {
+		s.heartbeatIntervalMs = heartbeatIntervalMs
+		s.electionTimeoutMs = electionTimeoutMs
+	}
+}
+
+// WithSizeLogInterval logs the backend size and the number of keys of the embedded etcd
+// server at the given interval, for visibility into the growth of the database without
+// Prometheus. Zero disables logging, which is the default.
+func WithSizeLogInterval(interval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.sizeLogInterval = interval
+	}
+}
+
+// NewServer returns an embedded etcd server storing its data in dir.
+func NewServer(dir string, opts ...ServerOption) *Server {
+	s := &Server{Dir: dir, startTimeout: DefaultStartTimeout}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type ClientInfo struct {
@@ -57,6 +138,9 @@ type ClientInfo struct {
 
 func (s *Server) Run(ctx context.Context, peerPort, clientPort string, listenMetricsURLs []url.URL, walSizeBytes, quotaBackendBytes int64, forceNewCluster bool) (ClientInfo, error) {
 	klog.Info("Creating embedded etcd server")
+	if err := s.validateMetrics(listenMetricsURLs); err != nil {
+		return ClientInfo{}, err
+	}
 	if walSizeBytes != 0 {
 		wal.SegmentSizeBytes = walSizeBytes
 	}
@@ -68,6 +152,22 @@ func (s *Server) Run(ctx context.Context, peerPort, clientPort string, listenMet
 	cfg.Dir = s.Dir
 	cfg.AuthToken = ""
 
+	removeDir := func() {}
+	if s.inMemory {
+		dir, err := ioutil.TempDir(inMemoryBaseDir(), "kcp-etcd-")
+		if err != nil {
+			return ClientInfo{}, fmt.Errorf("failed to create in-memory etcd data dir: %w", err)
+		}
+		klog.Infof("Using in-memory embedded etcd data dir %s", dir)
+		cfg.Dir = dir
+		cfg.UnsafeNoFsync = true
+		removeDir = func() {
+			if err := os.RemoveAll(dir); err != nil {
+				klog.Errorf("Failed to remove in-memory embedded etcd data dir %s: %v", dir, err)
+			}
+		}
+	}
+
 	cfg.LPUrls = []url.URL{{Scheme: "https", Host: "localhost:" + peerPort}}
 	cfg.APUrls = []url.URL{{Scheme: "https", Host: "localhost:" + peerPort}}
 	cfg.LCUrls = []url.URL{{Scheme: "https", Host: "localhost:" + clientPort}}
@@ -75,10 +175,25 @@ func (s *Server) Run(ctx context.Context, peerPort, clientPort string, listenMet
 	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
 
 	if err := fileutil.TouchDirAll(cfg.Dir); err != nil {
+		removeDir()
+		return ClientInfo{}, err
+	}
+
+	// lock the data dir before touching its contents, a second server would corrupt it
+	lock, err := LockDataDir(cfg.Dir)
+	if err != nil {
+		removeDir()
 		return ClientInfo{}, err
 	}
+	unlock := func() {
+		if err := lock.Close(); err != nil {
+			klog.Errorf("Failed to unlock embedded etcd data dir %s: %v", cfg.Dir, err)
+		}
+		removeDir()
+	}
 
 	if err := generateClientAndServerCerts([]string{"localhost"}, filepath.Join(cfg.Dir, "secrets")); err != nil {
+		unlock()
 		return ClientInfo{}, err
 	}
 	cfg.PeerTLSInfo.ServerName = "localhost"
@@ -105,15 +220,32 @@ func (s *Server) Run(ctx context.Context, peerPort, clientPort string, listenMet
 	if quotaBackendBytes > 0 {
 		cfg.QuotaBackendBytes = quotaBackendBytes
 	}
+	if s.heartbeatIntervalMs > 0 {
+		cfg.TickMs = s.heartbeatIntervalMs
+	}
+	if s.electionTimeoutMs > 0 {
+		cfg.ElectionMs = s.electionTimeoutMs
+	}
 
 	e, err := embed.StartEtcd(cfg)
 	if err != nil {
+		unlock()
 		return ClientInfo{}, err
 	}
+	if s.metricsRegistry != nil {
+		// etcd registers its metrics into the global Prometheus registry.
+		collector := &gathererCollector{gatherer: prometheus.DefaultGatherer, prefixes: etcdMetricPrefixes}
+		if err := s.metricsRegistry.Register(collector); err != nil {
+			e.Close()
+			unlock()
+			return ClientInfo{}, fmt.Errorf("failed to register embedded etcd metrics: %w", err)
+		}
+	}
 	// Shutdown when context is closed
 	go func() {
 		<-ctx.Done()
 		e.Close()
+		unlock()
 	}()
 
 	clientConfig, err := cfg.ClientTLSInfo.ClientConfig()
@@ -121,21 +253,83 @@ func (s *Server) Run(ctx context.Context, peerPort, clientPort string, listenMet
 		return ClientInfo{}, err
 	}
 
-	select {
-	case <-e.Server.ReadyNotify():
-		return ClientInfo{
-			Endpoints:     []string{cfg.ACUrls[0].String()},
-			TLS:           clientConfig,
-			CertFile:      cfg.ClientTLSInfo.CertFile,
-			KeyFile:       cfg.ClientTLSInfo.KeyFile,
-			TrustedCAFile: cfg.ClientTLSInfo.TrustedCAFile,
-		}, nil
-	case <-time.After(60 * time.Second):
+	if err := waitForReady(e.Server.ReadyNotify(), e.Err(), s.startTimeout); err != nil {
 		e.Server.Stop() // trigger a shutdown
-		return ClientInfo{}, fmt.Errorf("server took too long to start")
-	case e := <-e.Err():
-		return ClientInfo{}, e
+		return ClientInfo{}, err
+	}
+
+	if s.sizeLogInterval > 0 {
+		client := v3client.New(e.Server)
+		go func() {
+			defer client.Close()
+			logBackendSize(ctx, client, cfg.ACUrls[0].Host, s.sizeLogInterval)
+		}()
+	}
+
+	return ClientInfo{
+		Endpoints:     []string{cfg.ACUrls[0].String()},
+		TLS:           clientConfig,
+		CertFile:      cfg.ClientTLSInfo.CertFile,
+		KeyFile:       cfg.ClientTLSInfo.KeyFile,
+		TrustedCAFile: cfg.ClientTLSInfo.TrustedCAFile,
+	}, nil
+}
+
+// inMemoryBaseDir returns the directory to create in-memory data dirs in, i.e. /dev/shm if
+// available, and the default temporary directory otherwise.
+func inMemoryBaseDir() string {
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		return "/dev/shm"
 	}
+	return ""
+}
+
+// waitForReady blocks until the etcd member reports ready, it fails to start, or the timeout passes.
+func waitForReady(ready <-chan struct{}, errs <-chan error, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return nil
+	case err := <-errs:
+		return fmt.Errorf("embedded etcd failed to start: %w", err)
+	case <-timer.C:
+		return fmt.Errorf("embedded etcd did not become ready within %s, check the etcd logs above for the cause", timeout)
+	}
+}
+
+// logBackendSize logs the backend size reported by the maintenance API and the number of
+// keys of etcd every interval until ctx is done.
+func logBackendSize(ctx context.Context, client *clientv3.Client, endpoint string, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		status, err := client.Status(ctx, endpoint)
+		if err != nil {
+			klog.Errorf("Failed to get the embedded etcd status: %v", err)
+			return
+		}
+		keys, err := client.Get(ctx, "", clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			klog.Errorf("Failed to count the embedded etcd keys: %v", err)
+			return
+		}
+		klog.Infof("Embedded etcd backend size is %d bytes, %d bytes in use, with %d keys", status.DbSize, status.DbSizeInUse, keys.Count)
+	}, interval)
+}
+
+// validateMetrics checks that the embedded etcd metrics are exposed either on a
+// separate listener or through the metrics registry, but not both.
+func (s *Server) validateMetrics(listenMetricsURLs []url.URL) error {
+	if s.metricsRegistry == nil {
+		return nil
+	}
+	if len(listenMetricsURLs) > 0 {
+		return fmt.Errorf("listen metrics URLs and a metrics registry are mutually exclusive")
+	}
+	if s.metricsRegistry == prometheus.DefaultRegisterer {
+		return fmt.Errorf("embedded etcd metrics are already registered into the default Prometheus registry")
+	}
+	return nil
 }
 
 func generateClientAndServerCerts(hosts []string, dir string) error {
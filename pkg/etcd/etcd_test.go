@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/klog/v2"
+)
+
+func TestWaitForReady(t *testing.T) {
+	t.Run("times out on a non-starting instance", func(t *testing.T) {
+		err := waitForReady(make(chan struct{}), make(chan error), 10*time.Millisecond)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did not become ready within 10ms")
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		ready := make(chan struct{})
+		close(ready)
+		require.NoError(t, waitForReady(ready, make(chan error), time.Minute))
+	})
+
+	t.Run("failed to start", func(t *testing.T) {
+		errs := make(chan error, 1)
+		startErr := errors.New("boom")
+		errs <- startErr
+		err := waitForReady(make(chan struct{}), errs, time.Minute)
+		require.True(t, errors.Is(err, startErr), "unexpected error: %v", err)
+	})
+}
+
+func TestInMemory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewServer("", WithInMemory(), WithStartTimeout(30*time.Second))
+	info, err := s.Run(ctx, freePort(t), freePort(t), nil, 0, 0, false)
+	require.NoError(t, err)
+
+	// <dir>/secrets/peer/key.pem
+	dir := filepath.Dir(filepath.Dir(filepath.Dir(info.KeyFile)))
+	_, err = os.Stat(dir)
+	require.NoError(t, err, "expected the temporary data dir to exist while running")
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(dir)
+		return os.IsNotExist(err)
+	}, 10*time.Second, 50*time.Millisecond, "expected the temporary data dir %s to be removed on shutdown", dir)
+}
+
+func TestSizeLogInterval(t *testing.T) {
+	var out syncBuffer
+	klog.LogToStderr(false)
+	klog.SetOutput(&out)
+	defer func() {
+		klog.SetOutput(os.Stderr)
+		klog.LogToStderr(true)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewServer("", WithInMemory(), WithStartTimeout(30*time.Second), WithSizeLogInterval(50*time.Millisecond))
+	_, err := s.Run(ctx, freePort(t), freePort(t), nil, 0, 0, false)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "Embedded etcd backend size is")
+	}, 10*time.Second, 50*time.Millisecond, "expected the backend size to be logged")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+)
+
+// lockFileName is the name of the file in the embedded etcd data directory that is
+// locked while an embedded etcd server uses the directory.
+const lockFileName = "LOCK"
+
+// ErrDataDirInUse is returned when the embedded etcd data directory is locked by
+// another embedded etcd server.
+var ErrDataDirInUse = errors.New("etcd data dir already in use")
+
+// LockDataDir locks the given embedded etcd data directory against concurrent use by
+// another process. The directory must exist. The lock is released by closing the
+// returned file, or when the process exits.
+func LockDataDir(dir string) (*fileutil.LockedFile, error) {
+	lockFile := filepath.Join(dir, lockFileName)
+	l, err := fileutil.TryLockFile(lockFile, os.O_WRONLY|os.O_CREATE, fileutil.PrivateFileMode)
+	if errors.Is(err, fileutil.ErrLocked) {
+		return nil, fmt.Errorf("%w: %s is locked by another embedded etcd server", ErrDataDirInUse, lockFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock etcd data dir %s: %w", dir, err)
+	}
+	return l, nil
+}
+
+// CheckDataDirUnused returns an error if the given embedded etcd data directory is
+// locked by a running embedded etcd server. A directory that does not exist yet is
+// unused.
+func CheckDataDirUnused(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	l, err := LockDataDir(dir)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+)
+
+func TestLockDataDir(t *testing.T) {
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	locks := make([]*fileutil.LockedFile, 2)
+	errs := make([]error, 2)
+	for i := range locks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			locks[i], errs[i] = LockDataDir(dir)
+		}(i)
+	}
+	wg.Wait()
+
+	var held *fileutil.LockedFile
+	var inUse int
+	for i := range locks {
+		if errs[i] == nil {
+			held = locks[i]
+			continue
+		}
+		require.True(t, errors.Is(errs[i], ErrDataDirInUse), "unexpected error: %v", errs[i])
+		inUse++
+	}
+	require.NotNil(t, held, "one acquisition must succeed")
+	require.Equal(t, 1, inUse, "one acquisition must fail")
+	require.True(t, errors.Is(CheckDataDirUnused(dir), ErrDataDirInUse))
+
+	require.NoError(t, held.Close())
+	require.NoError(t, CheckDataDirUnused(dir))
+	l, err := LockDataDir(dir)
+	require.NoError(t, err, "the data dir must be lockable after release")
+	require.NoError(t, l.Close())
+
+	require.NoError(t, CheckDataDirUnused(filepath.Join(dir, "missing")))
+}
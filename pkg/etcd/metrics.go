@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"k8s.io/klog/v2"
+)
+
+// etcdMetricPrefixes are the prefixes of the metrics the embedded etcd server
+// registers into the global Prometheus registry.
+var etcdMetricPrefixes = []string{"etcd_", "grpc_server_"}
+
+// gathererCollector collects the metrics of a Gatherer whose names start with one
+// of prefixes. It is an unchecked collector, i.e. it does not describe its metrics
+// upfront, because they are only known when gathering.
+type gathererCollector struct {
+	gatherer prometheus.Gatherer
+	prefixes []string
+}
+
+var _ prometheus.Collector = &gathererCollector{}
+
+func (c *gathererCollector) Describe(chan<- *prometheus.Desc) {
+}
+
+func (c *gathererCollector) Collect(ch chan<- prometheus.Metric) {
+	families, err := c.gatherer.Gather()
+	if err != nil {
+		// Gather returns as many metrics as possible, even on error.
+		klog.Errorf("Failed to gather embedded etcd metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if !c.matches(family.GetName()) {
+			continue
+		}
+		desc := prometheus.NewDesc(family.GetName(), family.GetHelp(), nil, nil)
+		for _, m := range family.GetMetric() {
+			ch <- &gatheredMetric{desc: desc, metric: m}
+		}
+	}
+}
+
+func (c *gathererCollector) matches(name string) bool {
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// gatheredMetric is a prometheus.Metric for an already gathered metric.
+type gatheredMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+func (m *gatheredMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m *gatheredMetric) Write(out *dto.Metric) error {
+	out.Label = m.metric.Label
+	out.Gauge = m.metric.Gauge
+	out.Counter = m.metric.Counter
+	out.Summary = m.metric.Summary
+	out.Untyped = m.metric.Untyped
+	out.Histogram = m.metric.Histogram
+	out.TimestampMs = m.metric.TimestampMs
+	return nil
+}
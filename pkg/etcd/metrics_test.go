@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGathererCollector(t *testing.T) {
+	source := prometheus.NewRegistry()
+	etcdCounter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "etcd_server_proposals_applied_total", Help: "applied"}, []string{"member"})
+	etcdCounter.WithLabelValues("a").Add(3)
+	otherGauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "other_gauge", Help: "other"})
+	source.MustRegister(etcdCounter, otherGauge)
+
+	target := prometheus.NewRegistry()
+	require.NoError(t, target.Register(&gathererCollector{gatherer: source, prefixes: etcdMetricPrefixes}))
+
+	families, err := target.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, "etcd_server_proposals_applied_total", families[0].GetName())
+	require.Equal(t, "applied", families[0].GetHelp())
+	require.Len(t, families[0].GetMetric(), 1)
+	require.Equal(t, "member", families[0].GetMetric()[0].GetLabel()[0].GetName())
+	require.Equal(t, float64(3), families[0].GetMetric()[0].GetCounter().GetValue())
+}
+
+func TestValidateMetrics(t *testing.T) {
+	listenMetricsURLs := []url.URL{{Scheme: "http", Host: "localhost:2381"}}
+
+	require.NoError(t, NewServer("").validateMetrics(nil))
+	require.NoError(t, NewServer("").validateMetrics(listenMetricsURLs))
+	require.NoError(t, NewServer("", WithMetricsRegistry(prometheus.NewRegistry())).validateMetrics(nil))
+	require.Error(t, NewServer("", WithMetricsRegistry(prometheus.NewRegistry())).validateMetrics(listenMetricsURLs))
+	require.Error(t, NewServer("", WithMetricsRegistry(prometheus.DefaultRegisterer)).validateMetrics(nil))
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+// WithUpdateCoalescing coalesces the update events of the given resources per object
+// over the given window: the first update of an object starts the window, and only
+// the last update within the window is delivered to the event handlers, with the
+// old object of the first update. Delete events flush a pending update of the object
+// before the delete is delivered. Add events are delivered immediately.
+//
+// This changes the event semantics for the given resources: intermediate states of an
+// object are not observed, update events are delayed by up to the window, and they are
+// delivered from a timer rather than the informer, i.e. possibly concurrently with and
+// out of order with respect to events of other objects.
+func WithUpdateCoalescing(window time.Duration, gvrs ...schema.GroupVersionResource) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		if factory.updateCoalescingWindows == nil {
+			factory.updateCoalescingWindows = map[schema.GroupVersionResource]time.Duration{}
+		}
+		for _, gvr := range gvrs {
+			factory.updateCoalescingWindows[gvr] = window
+		}
+		return factory
+	}
+}
+
+// updateCoalescer coalesces the update events of an informer per object key.
+type updateCoalescer struct {
+	clock      clock.WithDelayedExecution
+	window     time.Duration
+	updateFunc func(oldObj, newObj interface{})
+	deleteFunc func(obj interface{})
+
+	// lock protects pending and stopped, and serializes the delivery of events, such
+	// that a flushed update is never delivered after the delete of the same object.
+	lock    sync.Mutex
+	pending map[string]*pendingUpdate
+	stopped bool
+}
+
+type pendingUpdate struct {
+	oldObj, newObj interface{}
+	timer          clock.Timer
+}
+
+func newUpdateCoalescer(clock clock.WithDelayedExecution, window time.Duration, updateFunc func(oldObj, newObj interface{}), deleteFunc func(obj interface{})) *updateCoalescer {
+	return &updateCoalescer{
+		clock:      clock,
+		window:     window,
+		updateFunc: updateFunc,
+		deleteFunc: deleteFunc,
+		pending:    map[string]*pendingUpdate{},
+	}
+}
+
+// OnUpdate records the update, delivering it when the window of the object passed.
+func (c *updateCoalescer) OnUpdate(oldObj, newObj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(newObj)
+	if err != nil {
+		c.updateFunc(oldObj, newObj)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return
+	}
+	if p, ok := c.pending[key]; ok {
+		p.newObj = newObj
+		return
+	}
+	p := &pendingUpdate{oldObj: oldObj, newObj: newObj}
+	p.timer = c.clock.AfterFunc(c.window, func() { c.expire(key, p) })
+	c.pending[key] = p
+}
+
+// OnDelete delivers a pending update of the object, followed by the delete.
+func (c *updateCoalescer) OnDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		c.deleteFunc(obj)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.flushLockHeld(key)
+	c.deleteFunc(obj)
+}
+
+// flushAll delivers all pending updates.
+func (c *updateCoalescer) flushAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.pending {
+		c.flushLockHeld(key)
+	}
+}
+
+// stop drops all pending updates, and ignores further updates. Deletes are still
+// delivered. It is called when the informer is removed, such that no updates are
+// delivered from timers of an informer that is gone.
+func (c *updateCoalescer) stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stopped = true
+	for key, p := range c.pending {
+		p.timer.Stop()
+		delete(c.pending, key)
+	}
+}
+
+// expire delivers the pending update p of key once its window passed, unless it has
+// been flushed or dropped in the meantime.
+func (c *updateCoalescer) expire(key string, p *pendingUpdate) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.pending[key] != p {
+		return
+	}
+	delete(c.pending, key)
+	c.updateFunc(p.oldObj, p.newObj)
+}
+
+func (c *updateCoalescer) flushLockHeld(key string) {
+	p, ok := c.pending[key]
+	if !ok {
+		return
+	}
+	delete(c.pending, key)
+	p.timer.Stop()
+	c.updateFunc(p.oldObj, p.newObj)
+}
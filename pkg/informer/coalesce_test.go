@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestUpdateCoalescer(t *testing.T) {
+	withVersion := func(obj *unstructured.Unstructured, rv string) *unstructured.Unstructured {
+		obj = obj.DeepCopy()
+		obj.SetResourceVersion(rv)
+		return obj
+	}
+	foo := newService("default", "foo")
+	bar := newService("default", "bar")
+
+	// the fake clock delivers expired updates synchronously from Step
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	var events []string
+	c := newUpdateCoalescer(fakeClock, time.Minute,
+		func(oldObj, newObj interface{}) {
+			o, n := oldObj.(*unstructured.Unstructured), newObj.(*unstructured.Unstructured)
+			events = append(events, "update "+n.GetName()+" "+o.GetResourceVersion()+"->"+n.GetResourceVersion())
+		},
+		func(obj interface{}) {
+			events = append(events, "delete "+obj.(*unstructured.Unstructured).GetName())
+		},
+	)
+
+	t.Run("updates within the window are coalesced per object", func(t *testing.T) {
+		events = nil
+		c.OnUpdate(withVersion(foo, "1"), withVersion(foo, "2"))
+		c.OnUpdate(withVersion(bar, "1"), withVersion(bar, "2"))
+		fakeClock.Step(30 * time.Second)
+		c.OnUpdate(withVersion(foo, "2"), withVersion(foo, "3"))
+		c.OnUpdate(withVersion(foo, "3"), withVersion(foo, "4"))
+		require.Empty(t, events, "updates must be delayed")
+
+		fakeClock.Step(30 * time.Second)
+		require.ElementsMatch(t, []string{"update foo 1->4", "update bar 1->2"}, events)
+
+		fakeClock.Step(time.Minute)
+		require.Len(t, events, 2, "coalesced updates must be delivered once")
+	})
+
+	t.Run("delete flushes the pending update", func(t *testing.T) {
+		events = nil
+		c.OnUpdate(withVersion(foo, "4"), withVersion(foo, "5"))
+		c.OnDelete(withVersion(foo, "5"))
+		require.Equal(t, []string{"update foo 4->5", "delete foo"}, events)
+
+		fakeClock.Step(time.Minute)
+		require.Equal(t, []string{"update foo 4->5", "delete foo"}, events, "flushed update must not be delivered again")
+	})
+
+	t.Run("a new window is started after a flush", func(t *testing.T) {
+		events = nil
+		c.OnUpdate(withVersion(foo, "5"), withVersion(foo, "6"))
+		c.OnDelete(withVersion(foo, "6"))
+		c.OnUpdate(withVersion(foo, "6"), withVersion(foo, "7"))
+		fakeClock.Step(30 * time.Second)
+		require.Equal(t, []string{"update foo 5->6", "delete foo"}, events)
+
+		fakeClock.Step(30 * time.Second)
+		require.Equal(t, []string{"update foo 5->6", "delete foo", "update foo 6->7"}, events)
+	})
+
+	t.Run("delete without pending update", func(t *testing.T) {
+		events = nil
+		c.OnDelete(bar)
+		require.Equal(t, []string{"delete bar"}, events)
+	})
+}
+
+func TestUpdateCoalescerStop(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	var events []string
+	c := newUpdateCoalescer(fakeClock, time.Minute,
+		func(oldObj, newObj interface{}) {
+			events = append(events, "update "+newObj.(*unstructured.Unstructured).GetName())
+		},
+		func(obj interface{}) {
+			events = append(events, "delete "+obj.(*unstructured.Unstructured).GetName())
+		},
+	)
+
+	foo := newService("default", "foo")
+	c.OnUpdate(foo, foo)
+	c.stop()
+	fakeClock.Step(time.Minute)
+	require.Empty(t, events, "pending updates must be dropped")
+
+	c.OnUpdate(foo, foo)
+	fakeClock.Step(time.Minute)
+	require.Empty(t, events, "updates after stop must be ignored")
+	require.False(t, fakeClock.HasWaiters(), "no timers must be pending")
+
+	c.OnDelete(foo)
+	require.Equal(t, []string{"delete foo"}, events)
+}
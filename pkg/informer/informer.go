@@ -18,17 +18,25 @@ package informer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/kcp-dev/logicalcluster"
 
 	corev1 "k8s.io/api/core/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -37,9 +45,10 @@ import (
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/klog/v2"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2/klogr"
+	"k8s.io/utils/clock"
 
-	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 )
 
@@ -61,25 +70,104 @@ type DynamicDiscoverySharedInformerFactory struct {
 	pollInterval    time.Duration
 	indexers        cache.Indexers
 
+	tweakListOptions TweakListOptionsFunc
+
+	watchBookmarks bool
+
+	namespaceNameIndex bool
+
+	disableNamespaceIndex bool
+
+	fallbackDisco discovery.DiscoveryInterface
+
+	excludeNamespaces sets.String
+
+	workspaceSelector labels.Selector
+
+	includeSubresources sets.String
+
+	updateCoalescingWindows map[schema.GroupVersionResource]time.Duration
+
+	// gvrAliases maps alias GVRs to the canonical GVR whose informer serves them.
+	gvrAliases map[schema.GroupVersionResource]schema.GroupVersionResource
+
+	// informerCreationLimiter bounds the rate of informers added by discovery. If nil, all
+	// discovered types are informed on immediately.
+	informerCreationLimiter flowcontrol.PassiveRateLimiter
+
+	// maxInformers caps the number of informers added by discovery. 0 means unlimited.
+	maxInformers int
+	// pinnedGVRs are never evicted to stay below maxInformers.
+	pinnedGVRs map[schema.GroupVersionResource]struct{}
+
+	// baseGVRs are informed on by discovery whether discovered or not, and are never evicted.
+	baseGVRs map[schema.GroupVersionResource]struct{}
+
+	// drainTimeout bounds how long shutdown waits for buffered and in-flight events to be
+	// delivered to the event handlers before stopping the informers. 0 means not waiting.
+	drainTimeout time.Duration
+	// inflightEvents is the number of events being delivered to the event handlers.
+	inflightEvents int64
+
+	// initialListDone is called once per informer after its initial list was delivered to the
+	// event handlers. If nil, initial lists are not tracked.
+	initialListDone func(gvr schema.GroupVersionResource)
+
+	clock clock.WithDelayedExecution
+
+	logger logr.Logger
+
+	// ctx is the base context of the factory. All informers are stopped when it is done.
+	ctx context.Context
+
 	// handlersLock protects multiple writers racing to update handlers.
 	handlersLock sync.Mutex
 	handlers     atomic.Value
 
+	// discoveryEvents buffers the changes of the informed GVRs by discovery, dropping the
+	// oldest events when full. It is closed on shutdown.
+	discoveryEvents chan DiscoveryEvent
+
 	mu               sync.RWMutex
 	informers        map[schema.GroupVersionResource]informers.GenericInformer
 	startedInformers map[schema.GroupVersionResource]bool
 	informerStops    map[schema.GroupVersionResource]chan struct{}
 	terminating      bool
+	discoveryPaused  bool
+
+	// draining is set while shutdown drains the events, such that concurrent shutdowns return.
+	draining bool
+	// coalescers are the update coalescers of the informers, flushed by shutdown, and
+	// stopped when their informer is removed.
+	coalescers map[schema.GroupVersionResource]*updateCoalescer
+
+	// lastActive holds the time of the last event of every informer as Unix nanoseconds, or
+	// of its creation, to evict the least recently active informer. Only set with maxInformers.
+	lastActive map[schema.GroupVersionResource]*int64
+	// evictedGVRs are not informed on by discovery until requested via InformerForResource,
+	// such that evicted informers are not recreated by the next discovery run.
+	evictedGVRs map[schema.GroupVersionResource]struct{}
 }
 
+// ErrFactoryTerminating is returned when informers are requested from a factory that
+// is shutting down.
+var ErrFactoryTerminating = errors.New("dynamic discovery shared informer factory is terminating")
+
 // InformerForResource returns the GenericInformer for gvr, creating it if needed. The GenericInformer must be started
 // by calling Start on the DynamicDiscoverySharedInformerFactory before the GenericInformer can be used.
+// ErrFactoryTerminating is returned after the factory has been shut down.
 func (d *DynamicDiscoverySharedInformerFactory) InformerForResource(gvr schema.GroupVersionResource) (informers.GenericInformer, error) {
+	gvr = d.canonicalGVR(gvr)
+
 	// See if we already have it
 	d.mu.RLock()
 	inf := d.informers[gvr]
+	terminating := d.terminating
 	d.mu.RUnlock()
 
+	if terminating {
+		return nil, ErrFactoryTerminating
+	}
 	if inf != nil {
 		return inf, nil
 	}
@@ -91,9 +179,77 @@ func (d *DynamicDiscoverySharedInformerFactory) InformerForResource(gvr schema.G
 	return d.informerForResourceLockHeld(gvr)
 }
 
+// InformerForResources returns the GenericInformers for gvrs, creating them if needed under a single
+// acquisition of the write lock. The informers created successfully are returned together with the
+// first error encountered, if any.
+func (d *DynamicDiscoverySharedInformerFactory) InformerForResources(gvrs []schema.GroupVersionResource) (map[schema.GroupVersionResource]informers.GenericInformer, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ret := make(map[schema.GroupVersionResource]informers.GenericInformer, len(gvrs))
+	var firstErr error
+	for _, gvr := range gvrs {
+		inf, err := d.informerForResourceLockHeld(gvr)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ret[gvr] = inf
+	}
+	return ret, firstErr
+}
+
+// RecreateInformer stops the running informer for gvr and replaces it with a fresh one, which lists the resource
+// from scratch, e.g. after its schema changed incompatibly. The event handlers and indexers of the factory are
+// registered with the new informer like with any other, i.e. handlers receive add events for all objects again,
+// but no delete events for the objects of the old informer. The other informers keep running. An error is
+// returned if the factory has no started informer for gvr, and ErrFactoryTerminating after the factory has been
+// shut down.
+func (d *DynamicDiscoverySharedInformerFactory) RecreateInformer(ctx context.Context, gvr schema.GroupVersionResource) (informers.GenericInformer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	gvr = d.canonicalGVR(gvr)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.terminating {
+		return nil, ErrFactoryTerminating
+	}
+	if !d.startedInformers[gvr] {
+		return nil, fmt.Errorf("no active informer for %s", gvr)
+	}
+
+	d.logger.Info("Recreating dynamic informer", "gvr", gvr.String())
+
+	d.removeInformerLockHeld(gvr)
+
+	inf, err := d.informerForResourceLockHeld(gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go inf.Informer().Run(stop)
+
+	d.informerStops[gvr] = stop
+	d.startedInformers[gvr] = true
+
+	return inf, nil
+}
+
 // informerForResourceLockHeld returns the GenericInformer for gvr, creating it if needed. The caller must have the write
 // lock before calling this method.
 func (d *DynamicDiscoverySharedInformerFactory) informerForResourceLockHeld(gvr schema.GroupVersionResource) (informers.GenericInformer, error) {
+	if d.terminating {
+		return nil, ErrFactoryTerminating
+	}
+
+	gvr = d.canonicalGVR(gvr)
+
 	// In case it was created in between the initial check while the rlock was held and when the write lock was
 	// acquired, return it instead of creating a 2nd copy and overwriting.
 	inf := d.informers[gvr]
@@ -101,7 +257,15 @@ func (d *DynamicDiscoverySharedInformerFactory) informerForResourceLockHeld(gvr
 		return inf, nil
 	}
 
-	klog.Infof("Adding dynamic informer for %q", gvr)
+	d.logger.Info("Adding dynamic informer", "gvr", gvr.String())
+
+	indexers := cache.Indexers{}
+	if !d.disableNamespaceIndex {
+		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
+	}
+	if d.namespaceNameIndex {
+		indexers[ByNamespaceNameIndex] = IndexByNamespaceName
+	}
 
 	// Definitely need to create it
 	inf = dynamicinformer.NewFilteredDynamicInformer(
@@ -109,30 +273,65 @@ func (d *DynamicDiscoverySharedInformerFactory) informerForResourceLockHeld(gvr
 		gvr,
 		corev1.NamespaceAll,
 		resyncPeriod,
-		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
-		nil,
+		indexers,
+		d.listOptionsTweakerFor(gvr),
 	)
 
-	inf.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: d.filterFunc,
-		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				for _, h := range d.handlers.Load().([]GVREventHandler) {
-					h.OnAdd(gvr, obj)
-				}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				for _, h := range d.handlers.Load().([]GVREventHandler) {
-					h.OnUpdate(gvr, oldObj, newObj)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				for _, h := range d.handlers.Load().([]GVREventHandler) {
-					h.OnDelete(gvr, obj)
-				}
-			},
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			clusterName := clusterNameFrom(obj)
+			for _, h := range d.handlers.Load().([]ClusterAwareGVREventHandler) {
+				h := h
+				d.dispatchEvent(gvr, clusterName, "add", func() { h.OnAdd(gvr, clusterName, obj) })
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			clusterName := clusterNameFrom(newObj)
+			for _, h := range d.handlers.Load().([]ClusterAwareGVREventHandler) {
+				h := h
+				d.dispatchEvent(gvr, clusterName, "update", func() { h.OnUpdate(gvr, clusterName, oldObj, newObj) })
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			clusterName := clusterNameFrom(obj)
+			for _, h := range d.handlers.Load().([]ClusterAwareGVREventHandler) {
+				h := h
+				d.dispatchEvent(gvr, clusterName, "delete", func() { h.OnDelete(gvr, clusterName, obj) })
+			}
 		},
+	}
+	var tracker *initialListTracker
+	if d.initialListDone != nil {
+		initialListDone := d.initialListDone
+		tracker = newInitialListTracker(func() { initialListDone(gvr) })
+		addFunc := handler.AddFunc
+		handler.AddFunc = func(obj interface{}) {
+			addFunc(obj)
+			tracker.onAdd(obj)
+		}
+	}
+	if window := d.updateCoalescingWindows[gvr]; window > 0 {
+		coalescer := newUpdateCoalescer(d.clock, window, handler.UpdateFunc, handler.DeleteFunc)
+		handler.UpdateFunc = coalescer.OnUpdate
+		handler.DeleteFunc = coalescer.OnDelete
+		d.coalescers[gvr] = coalescer
+	}
+	inf.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: d.filter,
+		Handler:    handler,
 	})
+	if d.maxInformers > 0 {
+		lastActive := new(int64)
+		markActive := func() { atomic.StoreInt64(lastActive, d.clock.Now().UnixNano()) }
+		markActive()
+		inf.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { markActive() },
+			UpdateFunc: func(interface{}, interface{}) { markActive() },
+			DeleteFunc: func(interface{}) { markActive() },
+		})
+		d.lastActive[gvr] = lastActive
+		delete(d.evictedGVRs, gvr)
+	}
 
 	if err := inf.Informer().AddIndexers(d.indexers); err != nil {
 		return nil, err
@@ -140,15 +339,43 @@ func (d *DynamicDiscoverySharedInformerFactory) informerForResourceLockHeld(gvr
 
 	// Store in cache
 	d.informers[gvr] = inf
+	if tracker != nil {
+		go d.trackInitialList(gvr, inf, tracker)
+	}
 
 	return inf, nil
 }
 
+// canonicalGVR returns the canonical GVR gvr is an alias of, or gvr itself.
+func (d *DynamicDiscoverySharedInformerFactory) canonicalGVR(gvr schema.GroupVersionResource) schema.GroupVersionResource {
+	if canonical, ok := d.gvrAliases[gvr]; ok {
+		return canonical
+	}
+	return gvr
+}
+
+// dispatchEvent calls an event handler through fn. A panic of the handler is logged and
+// recovered from, such that it neither crashes the informer nor keeps the event from
+// being passed to the other handlers.
+func (d *DynamicDiscoverySharedInformerFactory) dispatchEvent(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, event string, fn func()) {
+	atomic.AddInt64(&d.inflightEvents, 1)
+	defer atomic.AddInt64(&d.inflightEvents, -1)
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error(fmt.Errorf("%v", r), "Recovered from panic in event handler", "gvr", gvr.String(), "logical-cluster", clusterName.String(), "event", event, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
+
 // Listers returns a map of per-resource-type listers for all types that are
 // known by this informer factory, and that are synced.
 //
 // If any informers aren't synced, their GVRs are returned so that they can be
 // checked and processed later.
+//
+// With WithoutNamespaceIndex, namespace-scoped queries of the listers scan all
+// objects of the type.
 func (d *DynamicDiscoverySharedInformerFactory) Listers() (listers map[schema.GroupVersionResource]cache.GenericLister, notSynced []schema.GroupVersionResource) {
 	listers = map[schema.GroupVersionResource]cache.GenericLister{}
 
@@ -173,6 +400,561 @@ func (d *DynamicDiscoverySharedInformerFactory) Listers() (listers map[schema.Gr
 	return listers, notSynced
 }
 
+// HasSyncedForResource returns whether the informer for gvr is synced, and whether it exists at all.
+// Unlike InformerForResource, it never creates an informer and only takes the read lock.
+func (d *DynamicDiscoverySharedInformerFactory) HasSyncedForResource(gvr schema.GroupVersionResource) (synced bool, exists bool) {
+	gvr = d.canonicalGVR(gvr)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return false, false
+	}
+
+	inf, ok := d.informers[gvr]
+	if !ok {
+		return false, false
+	}
+
+	return inf.Informer().HasSynced(), true
+}
+
+// notifySyncedPollInterval is the interval in which NotifyWhenSynced checks the informer.
+var notifySyncedPollInterval = 100 * time.Millisecond
+
+// NotifyWhenSynced calls fn once from a background goroutine as soon as the informer for gvr is
+// synced, or immediately if it is synced already. The informer does not have to exist yet. If the
+// factory is shut down before the informer has synced, fn is never called.
+func (d *DynamicDiscoverySharedInformerFactory) NotifyWhenSynced(gvr schema.GroupVersionResource, fn func()) {
+	gvr = d.canonicalGVR(gvr)
+
+	go func() {
+		for {
+			synced, terminating := d.syncedOrTerminating(gvr)
+			if synced {
+				fn()
+				return
+			}
+			if terminating {
+				return
+			}
+			<-d.clock.After(notifySyncedPollInterval)
+		}
+	}()
+}
+
+func (d *DynamicDiscoverySharedInformerFactory) syncedOrTerminating(gvr schema.GroupVersionResource) (synced, terminating bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return false, true
+	}
+	inf, ok := d.informers[gvr]
+	return ok && inf.Informer().HasSynced(), false
+}
+
+// WaitForGVRs blocks until the informers of all gvrs have been discovered and are synced, e.g. for
+// controllers that cannot do anything useful before a core set of types is available. The informers
+// are created by discovery, such that GVRs served only later are waited for, too. An error naming the
+// missing GVRs is returned when ctx is done first, and ErrFactoryTerminating when the factory is shut
+// down.
+func (d *DynamicDiscoverySharedInformerFactory) WaitForGVRs(ctx context.Context, gvrs []schema.GroupVersionResource) error {
+	var pending []schema.GroupVersionResource
+	var terminating bool
+	err := wait.PollImmediateUntil(notifySyncedPollInterval, func() (bool, error) {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+
+		if d.terminating {
+			terminating = true
+			return true, nil
+		}
+		pending = pending[:0]
+		for _, gvr := range gvrs {
+			if inf, ok := d.informers[d.canonicalGVR(gvr)]; !ok || !inf.Informer().HasSynced() {
+				pending = append(pending, gvr)
+			}
+		}
+		return len(pending) == 0, nil
+	}, ctx.Done())
+	if terminating {
+		return ErrFactoryTerminating
+	}
+	if err != nil {
+		return fmt.Errorf("failed waiting for informers of %v to be discovered and synced: %w", pending, ctx.Err())
+	}
+	return nil
+}
+
+// InformersForGroup returns a snapshot of the informers of the given API group, e.g. for
+// controllers only interested in one group. The informers are not necessarily synced.
+func (d *DynamicDiscoverySharedInformerFactory) InformersForGroup(group string) map[schema.GroupVersionResource]informers.GenericInformer {
+	ret := map[schema.GroupVersionResource]informers.GenericInformer{}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return ret
+	}
+
+	for gvr, informer := range d.informers {
+		if gvr.Group == group {
+			ret[gvr] = informer
+		}
+	}
+
+	return ret
+}
+
+// TypedGet retrieves the object with the given namespace and name from the
+// cache of the informer for gvr and converts it into the typed object into.
+// For cluster-scoped resources, namespace must be empty. The name may be a
+// cluster-aware key as built by clusters.ToClusterAwareKey.
+func (d *DynamicDiscoverySharedInformerFactory) TypedGet(gvr schema.GroupVersionResource, namespace, name string, into runtime.Object) error {
+	gvr = d.canonicalGVR(gvr)
+
+	d.mu.RLock()
+	inf, found := d.informers[gvr]
+	d.mu.RUnlock()
+
+	if !found {
+		return fmt.Errorf("no informer for %q", gvr)
+	}
+	if !inf.Informer().HasSynced() {
+		return fmt.Errorf("informer for %q is not synced", gvr)
+	}
+
+	var obj runtime.Object
+	var err error
+	if namespace != "" {
+		obj, err = inf.Lister().ByNamespace(namespace).Get(name)
+	} else {
+		obj, err = inf.Lister().Get(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("expected *unstructured.Unstructured for %q, got %T", gvr, obj)
+	}
+
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), into)
+}
+
+// ListPaged returns a page of at most limit objects from the cache of the
+// informer for gvr, across all namespaces, ordered by namespace and name. The
+// returned continue token is passed to the next call to retrieve the following
+// page, and is empty when there are no more objects. A limit of zero or less
+// returns all remaining objects.
+func (d *DynamicDiscoverySharedInformerFactory) ListPaged(gvr schema.GroupVersionResource, continueToken string, limit int64) ([]runtime.Object, string, error) {
+	gvr = d.canonicalGVR(gvr)
+
+	d.mu.RLock()
+	inf, found := d.informers[gvr]
+	d.mu.RUnlock()
+
+	if !found {
+		return nil, "", fmt.Errorf("no informer for %q", gvr)
+	}
+	if !inf.Informer().HasSynced() {
+		return nil, "", fmt.Errorf("informer for %q is not synced", gvr)
+	}
+
+	// store keys are of the form <namespace>/<name>, or <name> for cluster-scoped
+	// objects, and hence sort by namespace and name.
+	store := inf.Informer().GetStore()
+	keys := store.ListKeys()
+	sort.Strings(keys)
+
+	start := 0
+	if continueToken != "" {
+		// the token is the last key of the previous page. Resume after it, even if
+		// the object has been deleted in the meantime.
+		start = sort.SearchStrings(keys, continueToken)
+		if start < len(keys) && keys[start] == continueToken {
+			start++
+		}
+	}
+
+	var objs []runtime.Object
+	for i := start; i < len(keys); i++ {
+		if limit > 0 && int64(len(objs)) == limit {
+			return objs, keys[i-1], nil
+		}
+
+		obj, exists, err := store.GetByKey(keys[i])
+		if err != nil {
+			return nil, "", err
+		}
+		if !exists {
+			continue
+		}
+		runtimeObj, ok := obj.(runtime.Object)
+		if !ok {
+			return nil, "", fmt.Errorf("expected runtime.Object for %q, got %T", gvr, obj)
+		}
+		objs = append(objs, runtimeObj)
+	}
+
+	return objs, "", nil
+}
+
+// ForEach calls fn for every object in the cache of the informer for gvr, stopping at
+// and returning the first error of fn. Iteration works on a snapshot of the cache taken
+// when ForEach is called: no lock is held while fn runs, objects added afterwards are not
+// visited, and deleted ones still are. The objects are shared with the cache and must
+// not be mutated. ErrFactoryTerminating is returned once the factory is shut down.
+func (d *DynamicDiscoverySharedInformerFactory) ForEach(gvr schema.GroupVersionResource, fn func(obj interface{}) error) error {
+	gvr = d.canonicalGVR(gvr)
+
+	d.mu.RLock()
+	if d.terminating {
+		d.mu.RUnlock()
+		return ErrFactoryTerminating
+	}
+	inf, found := d.informers[gvr]
+	d.mu.RUnlock()
+
+	if !found {
+		return fmt.Errorf("no informer for %q", gvr)
+	}
+	if !inf.Informer().HasSynced() {
+		return fmt.Errorf("informer for %q is not synced", gvr)
+	}
+
+	for _, obj := range inf.Informer().GetStore().List() {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachAll calls fn for every object in the caches of all synced informers, with the
+// snapshot semantics of ForEach. Informers which are not synced are skipped.
+func (d *DynamicDiscoverySharedInformerFactory) ForEachAll(fn func(gvr schema.GroupVersionResource, obj interface{})) {
+	d.mu.RLock()
+	if d.terminating {
+		d.mu.RUnlock()
+		return
+	}
+	synced := make(map[schema.GroupVersionResource]informers.GenericInformer, len(d.informers))
+	for gvr, informer := range d.informers {
+		if informer.Informer().HasSynced() {
+			synced[gvr] = informer
+		}
+	}
+	d.mu.RUnlock()
+
+	for gvr, informer := range synced {
+		for _, obj := range informer.Informer().GetStore().List() {
+			fn(gvr, obj)
+		}
+	}
+}
+
+// FindByName returns the objects with the given namespace and name of all types
+// known by this informer factory, and that are synced. For cluster-scoped objects,
+// namespace must be empty. If objects with the given namespace and name exist in
+// multiple logical clusters, one of them is returned per type.
+//
+// The GVRs of informers that aren't synced are returned so that they can be checked
+// later.
+func (d *DynamicDiscoverySharedInformerFactory) FindByName(namespace, name string) (found map[schema.GroupVersionResource]runtime.Object, notSynced []schema.GroupVersionResource) {
+	found = map[schema.GroupVersionResource]runtime.Object{}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return
+	}
+
+	for gvr, informer := range d.informers {
+		if !informer.Informer().HasSynced() {
+			notSynced = append(notSynced, gvr)
+			continue
+		}
+
+		obj, err := findByName(informer.Informer().GetIndexer(), d.namespaceNameIndex, namespace, name)
+		if err != nil {
+			d.logger.Error(err, "Error finding object", "gvr", gvr.String(), "namespace", namespace, "name", name)
+			continue
+		}
+		if obj != nil {
+			found[gvr] = obj
+		}
+	}
+
+	return found, notSynced
+}
+
+// findByName returns an object with the given namespace and name from the indexer,
+// or nil if there is none. Without the ByNamespaceNameIndex, all objects are scanned.
+func findByName(indexer cache.Indexer, indexed bool, namespace, name string) (runtime.Object, error) {
+	var objs []interface{}
+	if indexed {
+		var err error
+		objs, err = indexer.ByIndex(ByNamespaceNameIndex, namespaceNameKey(namespace, name))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		objs = indexer.List()
+	}
+
+	for _, obj := range objs {
+		metaObj, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if metaObj.GetNamespace() != namespace || metaObj.GetName() != name {
+			continue
+		}
+		runtimeObj, ok := obj.(runtime.Object)
+		if !ok {
+			return nil, fmt.Errorf("expected runtime.Object, got %T", obj)
+		}
+		return runtimeObj, nil
+	}
+
+	return nil, nil
+}
+
+// ByNamespaceNameIndex is the name of the index of objects by namespace and name,
+// regardless of their logical cluster.
+const ByNamespaceNameIndex = "byNamespaceName"
+
+// IndexByNamespaceName is an index function indexing objects by namespace and name.
+func IndexByNamespaceName(obj interface{}) ([]string, error) {
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return []string{namespaceNameKey(metaObj.GetNamespace(), metaObj.GetName())}, nil
+}
+
+func namespaceNameKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// TweakListOptionsFunc is a function that transforms the list and watch
+// options of the dynamic informer for the given GroupVersionResource.
+type TweakListOptionsFunc func(gvr schema.GroupVersionResource, options *metav1.ListOptions)
+
+// listOptionsTweakerFor returns the function customizing the list and watch options of the
+// informer of gvr, or nil if they are not customized.
+func (d *DynamicDiscoverySharedInformerFactory) listOptionsTweakerFor(gvr schema.GroupVersionResource) dynamicinformer.TweakListOptionsFunc {
+	if d.tweakListOptions == nil && !d.watchBookmarks {
+		return nil
+	}
+	return func(options *metav1.ListOptions) {
+		if d.watchBookmarks {
+			options.AllowWatchBookmarks = true
+		}
+		if d.tweakListOptions != nil {
+			d.tweakListOptions(gvr, options)
+		}
+	}
+}
+
+// DynamicDiscoverySharedInformerOption defines the functional option type for
+// DynamicDiscoverySharedInformerFactory.
+type DynamicDiscoverySharedInformerOption func(*DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory
+
+// WithTweakListOptions sets a function that customizes the list and watch
+// options of every dynamic informer, e.g. to restrict it by field or label
+// selectors.
+func WithTweakListOptions(tweakListOptions TweakListOptionsFunc) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.tweakListOptions = tweakListOptions
+		return factory
+	}
+}
+
+// WithWatchBookmarks requests watch bookmarks by setting AllowWatchBookmarks on the
+// list and watch options of every dynamic informer, before WithTweakListOptions is
+// applied. With bookmarks, the server periodically sends the latest resource version
+// of a watch, such that the informer resumes from a recent resource version after the
+// watch is closed, instead of relisting with an expired one, which is expensive for
+// large resource types. Bookmarks are beta and enabled by default since Kubernetes 1.16
+// and GA since 1.17. Older servers ignore the option. Note that client-go reflectors
+// request bookmarks on their watches already.
+func WithWatchBookmarks() DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.watchBookmarks = true
+		return factory
+	}
+}
+
+// WithNamespaceNameIndex registers the ByNamespaceNameIndex on every dynamic
+// informer, e.g. to speed up FindByName.
+func WithNamespaceNameIndex() DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.namespaceNameIndex = true
+		return factory
+	}
+}
+
+// WithoutNamespaceIndex omits the cache.NamespaceIndex from every dynamic informer, in order
+// to save memory for types which are never queried by namespace, e.g. when only cluster-scoped
+// types are informed on. Namespace-scoped queries of the listers returned by Listers still
+// work, but scan all objects of the type instead of using the index.
+func WithoutNamespaceIndex() DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.disableNamespaceIndex = true
+		return factory
+	}
+}
+
+// WithGVRAliases maps alias GVRs to canonical GVRs, e.g. for the same underlying resource exposed
+// under multiple group names through APIBindings. A single informer for the canonical GVR serves
+// all its aliases, i.e. requesting the informer of an alias returns the informer of the canonical
+// GVR, and event handlers receive the events of aliases under the canonical GVR. Aliases found
+// by discovery are informed on via their canonical GVR as well.
+func WithGVRAliases(aliases map[schema.GroupVersionResource]schema.GroupVersionResource) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.gvrAliases = make(map[schema.GroupVersionResource]schema.GroupVersionResource, len(aliases))
+		for alias, canonical := range aliases {
+			factory.gvrAliases[alias] = canonical
+		}
+		return factory
+	}
+}
+
+// WithFallbackDiscovery sets a discovery client, e.g. of the root logical cluster,
+// which is consulted for a logical cluster whose discovery is not available (yet),
+// i.e. fails with a not found or service unavailable error, like for a freshly
+// created workspace. The discovery of the logical cluster always takes precedence;
+// the fallback is only used in its place when it fails, such that the baseline types
+// are informed on. Without a fallback, such a failure fails the whole discovery.
+func WithFallbackDiscovery(disco discovery.DiscoveryInterface) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.fallbackDisco = disco
+		return factory
+	}
+}
+
+// WithExcludeNamespaces drops the events of objects in the given namespaces before
+// they reach any event handler, e.g. for high-churn namespaces no controller is
+// interested in. The objects are still listed and watched, i.e. they are in the
+// informer caches.
+func WithExcludeNamespaces(namespaces ...string) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.excludeNamespaces = sets.NewString(namespaces...)
+		return factory
+	}
+}
+
+// WithWorkspaceSelector restricts discovery to the workspaces matching the given label
+// selector, i.e. only the types served in those workspaces are informed on. By default,
+// all workspaces are discovered.
+func WithWorkspaceSelector(selector labels.Selector) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.workspaceSelector = selector
+		return factory
+	}
+}
+
+// WithIncludeSubresources informs on the given subresources, e.g. "pods/status", which are
+// skipped by default. Caveats:
+//   - like for resources, only namespaced subresources that discovery reports as list- and
+//     watchable are informed on. Most subresources served by kube-apiserver, including
+//     pods/status, are not, i.e. this is meant for APIs explicitly serving list and watch
+//     on their subresources.
+//   - the informers list and watch the subresource path, i.e. the objects in their caches
+//     are whatever that path returns, usually the parent object, and they are keyed the
+//     same as the parent objects.
+//   - every included subresource adds another list and watch per logical cluster.
+func WithIncludeSubresources(subresources ...string) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.includeSubresources = sets.NewString(subresources...)
+		return factory
+	}
+}
+
+// WithInformerCreationRateLimit bounds the rate at which discovery adds informers to qps per
+// second, with bursts of up to burst informers. Every new informer lists its resource across all
+// logical clusters, hence many types discovered at once, e.g. when many workspaces are created,
+// spike the load on the apiserver. Types beyond the limit are informed on by later discovery runs.
+// Informers requested via InformerForResource are not limited.
+func WithInformerCreationRateLimit(qps float32, burst int) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.informerCreationLimiter = flowcontrol.NewTokenBucketPassiveRateLimiter(qps, burst)
+		return factory
+	}
+}
+
+// WithMaxInformers caps the number of informers added by discovery at maxInformers, in order to
+// bound the memory of the factory with very many types. At the cap, discovering another type evicts
+// the least recently active informer, i.e. the one whose last event, or creation, is the oldest,
+// except for the pinned GVRs. Evicted types are not informed on by discovery again until they are
+// requested via InformerForResource, which is not capped. Evictions are counted by the
+// kcp_dynamic_informer_evictions_total metric.
+func WithMaxInformers(maxInformers int, pinned ...schema.GroupVersionResource) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		registerMetrics()
+		factory.maxInformers = maxInformers
+		factory.pinnedGVRs = make(map[schema.GroupVersionResource]struct{}, len(pinned))
+		for _, gvr := range pinned {
+			factory.pinnedGVRs[factory.canonicalGVR(gvr)] = struct{}{}
+		}
+		return factory
+	}
+}
+
+// WithBaseGVRs makes discovery always inform on the given GVRs, in addition to the discovered
+// ones, such that their informers are present even if discovery transiently omits them, and are
+// never removed or evicted. The GVRs do not have to be namespaced.
+func WithBaseGVRs(baseGVRs ...schema.GroupVersionResource) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		for _, gvr := range baseGVRs {
+			factory.baseGVRs[gvr] = struct{}{}
+		}
+		return factory
+	}
+}
+
+// WithDrainTimeout makes the shutdown of the factory deliver the pending updates of
+// resources coalesced by WithUpdateCoalescing, and wait for the events being delivered to
+// the event handlers, before stopping the informers, such that the handlers see the final
+// state observed by the informers. Shutdown waits for at most the given timeout. Events
+// still buffered by the informers themselves are not waited for.
+func WithDrainTimeout(timeout time.Duration) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.drainTimeout = timeout
+		return factory
+	}
+}
+
+// WithLogger sets the logger of the factory and its informers, e.g. to route or filter their
+// logs. By default, logs are written via klog.
+func WithLogger(logger logr.Logger) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.logger = logger
+		return factory
+	}
+}
+
+// WithContext sets the base context of the factory. When it is done, all informers are
+// stopped and the factory is shut down, no matter how the informers were started, i.e. by
+// Start, or by the polling of StartPolling. The polling stops as well. By default, the
+// factory is only shut down when the context passed to StartPolling is done.
+func WithContext(ctx context.Context) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.ctx = ctx
+		return factory
+	}
+}
+
 // NewDynamicDiscoverySharedInformerFactory returns a factory for shared
 // informers that discovers new types and informs on updates to resources of
 // those types.
@@ -182,7 +964,12 @@ func NewDynamicDiscoverySharedInformerFactory(
 	dynClient dynamic.Interface,
 	filterFunc func(obj interface{}) bool,
 	pollInterval time.Duration,
+	opts ...DynamicDiscoverySharedInformerOption,
 ) *DynamicDiscoverySharedInformerFactory {
+	if filterFunc == nil {
+		filterFunc = func(obj interface{}) bool { return true }
+	}
+
 	f := &DynamicDiscoverySharedInformerFactory{
 		workspaceLister:  workspaceLister,
 		disco:            disco,
@@ -192,9 +979,36 @@ func NewDynamicDiscoverySharedInformerFactory(
 		informers:        make(map[schema.GroupVersionResource]informers.GenericInformer),
 		informerStops:    make(map[schema.GroupVersionResource]chan struct{}),
 		startedInformers: make(map[schema.GroupVersionResource]bool),
+		discoveryEvents:  make(chan DiscoveryEvent, discoveryEventsBufferSize),
+		lastActive:       make(map[schema.GroupVersionResource]*int64),
+		evictedGVRs:      make(map[schema.GroupVersionResource]struct{}),
+		coalescers:       make(map[schema.GroupVersionResource]*updateCoalescer),
+		baseGVRs:         make(map[schema.GroupVersionResource]struct{}),
+		clock:            clock.RealClock{},
+		logger:           klogr.NewWithOptions(klogr.WithFormat(klogr.FormatKlog)),
+		ctx:              context.Background(),
 	}
 
-	f.handlers.Store([]GVREventHandler{})
+	f.handlers.Store([]ClusterAwareGVREventHandler{})
+
+	for _, opt := range opts {
+		f = opt(f)
+	}
+	if f.workspaceSelector == nil {
+		f.workspaceSelector = labels.Everything()
+	}
+	// the aliases are only known after all options are applied
+	baseGVRs := make(map[schema.GroupVersionResource]struct{}, len(f.baseGVRs))
+	for gvr := range f.baseGVRs {
+		baseGVRs[f.canonicalGVR(gvr)] = struct{}{}
+	}
+	f.baseGVRs = baseGVRs
+	if f.ctx.Done() != nil {
+		go func() {
+			<-f.ctx.Done()
+			f.shutdown()
+		}()
+	}
 
 	return f
 }
@@ -229,14 +1043,92 @@ func (g GVREventHandlerFuncs) OnDelete(gvr schema.GroupVersionResource, obj inte
 	}
 }
 
+// ClusterAwareGVREventHandler is an event handler that includes the GroupVersionResource
+// and the logical cluster of the resource being handled.
+type ClusterAwareGVREventHandler interface {
+	OnAdd(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{})
+	OnUpdate(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, oldObj, newObj interface{})
+	OnDelete(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{})
+}
+
+type ClusterAwareGVREventHandlerFuncs struct {
+	AddFunc    func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{})
+	UpdateFunc func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, oldObj, newObj interface{})
+	DeleteFunc func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{})
+}
+
+func (g ClusterAwareGVREventHandlerFuncs) OnAdd(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{}) {
+	if g.AddFunc != nil {
+		g.AddFunc(gvr, clusterName, obj)
+	}
+}
+func (g ClusterAwareGVREventHandlerFuncs) OnUpdate(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, oldObj, newObj interface{}) {
+	if g.UpdateFunc != nil {
+		g.UpdateFunc(gvr, clusterName, oldObj, newObj)
+	}
+}
+func (g ClusterAwareGVREventHandlerFuncs) OnDelete(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{}) {
+	if g.DeleteFunc != nil {
+		g.DeleteFunc(gvr, clusterName, obj)
+	}
+}
+
+// clusterUnawareHandler adapts a GVREventHandler to a ClusterAwareGVREventHandler by
+// dropping the logical cluster.
+type clusterUnawareHandler struct {
+	handler GVREventHandler
+}
+
+func (h clusterUnawareHandler) OnAdd(gvr schema.GroupVersionResource, _ logicalcluster.Name, obj interface{}) {
+	h.handler.OnAdd(gvr, obj)
+}
+func (h clusterUnawareHandler) OnUpdate(gvr schema.GroupVersionResource, _ logicalcluster.Name, oldObj, newObj interface{}) {
+	h.handler.OnUpdate(gvr, oldObj, newObj)
+}
+func (h clusterUnawareHandler) OnDelete(gvr schema.GroupVersionResource, _ logicalcluster.Name, obj interface{}) {
+	h.handler.OnDelete(gvr, obj)
+}
+
+// filter returns whether the events of obj are passed to the event handlers.
+func (d *DynamicDiscoverySharedInformerFactory) filter(obj interface{}) bool {
+	if d.excludeNamespaces.Len() > 0 {
+		metaObj := obj
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			metaObj = tombstone.Obj
+		}
+		if m, err := meta.Accessor(metaObj); err == nil && d.excludeNamespaces.Has(m.GetNamespace()) {
+			return false
+		}
+	}
+	return d.filterFunc(obj)
+}
+
+// clusterNameFrom returns the logical cluster of an informed object, unwrapping
+// tombstones. It returns the empty name for objects without metadata.
+func clusterNameFrom(obj interface{}) logicalcluster.Name {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return logicalcluster.Name{}
+	}
+	return logicalcluster.From(metaObj)
+}
+
 func (d *DynamicDiscoverySharedInformerFactory) AddEventHandler(handler GVREventHandler) {
-	d.handlersLock.Lock()
+	d.AddClusterAwareEventHandler(clusterUnawareHandler{handler: handler})
+}
 
-	handlers := d.handlers.Load().([]GVREventHandler)
+// AddClusterAwareEventHandler adds an event handler that is passed the logical cluster
+// of the objects in addition to their GroupVersionResource.
+func (d *DynamicDiscoverySharedInformerFactory) AddClusterAwareEventHandler(handler ClusterAwareGVREventHandler) {
+	d.handlersLock.Lock()
 
-	var newHandlers []GVREventHandler
-	copy(newHandlers, handlers)
+	handlers := d.handlers.Load().([]ClusterAwareGVREventHandler)
 
+	newHandlers := make([]ClusterAwareGVREventHandler, 0, len(handlers)+1)
+	newHandlers = append(newHandlers, handlers...)
 	newHandlers = append(newHandlers, handler)
 
 	d.handlers.Store(newHandlers)
@@ -244,7 +1136,43 @@ func (d *DynamicDiscoverySharedInformerFactory) AddEventHandler(handler GVREvent
 	d.handlersLock.Unlock()
 }
 
+// NumEventHandlers returns the number of currently registered event handlers, e.g. for debugging.
+func (d *DynamicDiscoverySharedInformerFactory) NumEventHandlers() int {
+	return len(d.handlers.Load().([]ClusterAwareGVREventHandler))
+}
+
+// SetEventHandlers atomically replaces all event handlers, including those added with
+// AddClusterAwareEventHandler, by the given ones, e.g. for a controller re-initializing.
+// Events are delivered either to the old or to the new handlers, never to a mix of both.
+// Use SetClusterAwareEventHandlers to replace them by cluster-aware handlers.
+func (d *DynamicDiscoverySharedInformerFactory) SetEventHandlers(handlers []GVREventHandler) {
+	clusterAwareHandlers := make([]ClusterAwareGVREventHandler, 0, len(handlers))
+	for _, h := range handlers {
+		clusterAwareHandlers = append(clusterAwareHandlers, clusterUnawareHandler{handler: h})
+	}
+	d.SetClusterAwareEventHandlers(clusterAwareHandlers)
+}
+
+// SetClusterAwareEventHandlers atomically replaces all event handlers, including those added
+// with AddEventHandler, by the given cluster-aware ones, like SetEventHandlers.
+func (d *DynamicDiscoverySharedInformerFactory) SetClusterAwareEventHandlers(handlers []ClusterAwareGVREventHandler) {
+	newHandlers := make([]ClusterAwareGVREventHandler, 0, len(handlers))
+	newHandlers = append(newHandlers, handlers...)
+
+	d.handlersLock.Lock()
+	d.handlers.Store(newHandlers)
+	d.handlersLock.Unlock()
+}
+
+// AddIndexers adds indexers to every informer created by the factory. Indexers can only be
+// added before the first informer is created.
 func (d *DynamicDiscoverySharedInformerFactory) AddIndexers(indexers cache.Indexers) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.informers) > 0 {
+		return fmt.Errorf("cannot add indexers after informers have been created")
+	}
 	if d.indexers == nil {
 		d.indexers = map[string]cache.IndexFunc{}
 	}
@@ -258,36 +1186,103 @@ func (d *DynamicDiscoverySharedInformerFactory) AddIndexers(indexers cache.Index
 	return nil
 }
 
+// AddCrossIndex adds an index with the given name computing the keys of the objects of every
+// type, e.g. from a spec field, to be queried across all informers with GetByCrossIndex. Like
+// AddIndexers, cross indexes can only be added before the first informer is created.
+func (d *DynamicDiscoverySharedInformerFactory) AddCrossIndex(name string, indexFunc cache.IndexFunc) error {
+	return d.AddIndexers(cache.Indexers{name: indexFunc})
+}
+
+// GetByCrossIndex returns the objects of all synced informers with the given key in the index
+// with the given name, added by AddCrossIndex or AddIndexers, ordered by GVR. The objects are
+// shared with the informers and must not be mutated. If the index does not exist, nil is
+// returned.
+func (d *DynamicDiscoverySharedInformerFactory) GetByCrossIndex(name, key string) []runtime.Object {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return nil
+	}
+	if _, found := d.indexers[name]; !found {
+		d.logger.Error(fmt.Errorf("index %q does not exist", name), "Error getting objects by cross index")
+		return nil
+	}
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(d.informers))
+	for gvr := range d.informers {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+
+	var found []runtime.Object
+	for _, gvr := range gvrs {
+		informer := d.informers[gvr].Informer()
+		if !informer.HasSynced() {
+			continue
+		}
+		objs, err := informer.GetIndexer().ByIndex(name, key)
+		if err != nil {
+			d.logger.Error(err, "Error getting objects by cross index", "gvr", gvr.String(), "index", name, "key", key)
+			continue
+		}
+		for _, obj := range objs {
+			if runtimeObj, ok := obj.(runtime.Object); ok {
+				found = append(found, runtimeObj)
+			}
+		}
+	}
+	return found
+}
+
+// RemoveIndexer removes the indexer with the given name added by AddIndexers. Like adding,
+// indexers can only be removed before the first informer is created.
+func (d *DynamicDiscoverySharedInformerFactory) RemoveIndexer(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.informers) > 0 {
+		return fmt.Errorf("cannot remove indexer %q after informers have been created", name)
+	}
+	if _, found := d.indexers[name]; !found {
+		return fmt.Errorf("indexer %q does not exist", name)
+	}
+	delete(d.indexers, name)
+
+	return nil
+}
+
 // StartPolling starts the polling process that periodically discovers new resources and starts informers for them.
-// This call is non-blocking.
+// This call is non-blocking. The polling stops when ctx or the base context of the factory is done, and all informers
+// are stopped.
 func (d *DynamicDiscoverySharedInformerFactory) StartPolling(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-d.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	// Immediately discover types and start informing.
 	if err := wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
 		if err := d.discoverTypes(ctx); err != nil {
-			klog.Errorf("Error discovering initial types: %v", err)
+			d.logger.Error(err, "Error discovering initial types")
 			return false, nil
 		}
 		return true, nil
 	}); err != nil {
-		klog.Errorf("Error discovering initial types: %v", err)
+		d.logger.Error(err, "Error discovering initial types")
+		cancel()
+		d.shutdown()
 		return
 	}
 
 	// Poll for new types in the background.
 	ticker := time.NewTicker(d.pollInterval)
 	go func() {
-		defer func() {
-			d.mu.Lock()
-			defer d.mu.Unlock()
-
-			// tear down all informers when done.
-			for _, stopCh := range d.informerStops {
-				close(stopCh)
-			}
-
-			// Note: it does not matter if after this another informer is added. It won't be started without
-			// calling discoverTypes.
-		}()
+		defer d.shutdown()
 
 		for {
 			select {
@@ -296,14 +1291,90 @@ func (d *DynamicDiscoverySharedInformerFactory) StartPolling(ctx context.Context
 				return
 			case <-ticker.C:
 				if err := d.discoverTypes(ctx); err != nil {
-					klog.Errorf("Error discovering types: %v", err)
+					d.logger.Error(err, "Error discovering types")
 				}
 			}
 		}
 	}()
 }
 
+// shutdown stops all informers. No new informers are created or started afterwards, as
+// they would never be stopped. With a drain timeout, the pending updates of coalesced
+// resources are delivered, and the in-flight events are waited for, before.
+func (d *DynamicDiscoverySharedInformerFactory) shutdown() {
+	d.mu.Lock()
+	if d.terminating || d.draining {
+		d.mu.Unlock()
+		return
+	}
+	if d.drainTimeout > 0 {
+		d.draining = true
+		coalescers := make([]*updateCoalescer, 0, len(d.coalescers))
+		for _, coalescer := range d.coalescers {
+			coalescers = append(coalescers, coalescer)
+		}
+		d.mu.Unlock()
+
+		// the event handlers may call into the factory, hence drain without the lock
+		d.drain(coalescers)
+
+		d.mu.Lock()
+	}
+	defer d.mu.Unlock()
+
+	d.terminating = true
+
+	for gvr, stopCh := range d.informerStops {
+		close(stopCh)
+		delete(d.informerStops, gvr)
+	}
+	close(d.discoveryEvents)
+}
+
+// drain delivers the pending updates of the given coalescers, and waits for the in-flight
+// events to be delivered, up to the drain timeout.
+func (d *DynamicDiscoverySharedInformerFactory) drain(coalescers []*updateCoalescer) {
+	d.logger.V(2).Info("Draining events of dynamic informers", "timeout", d.drainTimeout)
+	for _, coalescer := range coalescers {
+		coalescer.flushAll()
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, d.drainTimeout, func() (bool, error) {
+		return atomic.LoadInt64(&d.inflightEvents) == 0, nil
+	}); err != nil {
+		d.logger.Info("Stopping dynamic informers with events in flight after the drain timeout", "timeout", d.drainTimeout, "events", atomic.LoadInt64(&d.inflightEvents))
+	}
+}
+
+// PauseDiscovery stops the factory from starting or stopping informers for newly
+// discovered or removed types until ResumeDiscovery is called. Existing informers
+// keep running.
+func (d *DynamicDiscoverySharedInformerFactory) PauseDiscovery() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logger.Info("Pausing discovery of dynamic informers")
+	d.discoveryPaused = true
+}
+
+// ResumeDiscovery resumes discovery paused by PauseDiscovery. Changes of types in
+// the meantime are picked up by the next discovery.
+func (d *DynamicDiscoverySharedInformerFactory) ResumeDiscovery() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logger.Info("Resuming discovery of dynamic informers")
+	d.discoveryPaused = false
+}
+
 func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Context) error {
+	d.mu.RLock()
+	paused := d.discoveryPaused
+	d.mu.RUnlock()
+	if paused {
+		d.logger.V(4).Info("Discovery of dynamic informers is paused")
+		return nil
+	}
+
 	latest := map[schema.GroupVersionResource]struct{}{}
 
 	// Get a list of all the logical cluster names. We'll get discovery from all of them, union all the GVRs, and use
@@ -311,15 +1382,29 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 
 	// TODO(ncdc): this may not scale well. Watchable discovery or something like that
 	// is a better long term solution.
-	workspaces, err := d.workspaceLister.List(labels.Everything())
+	workspaces, err := d.workspaceLister.List(d.workspaceSelector)
 	if err != nil {
 		return err
 	}
+
+	// the fallback discovery is consulted at most once per run
+	var fallbackResources []*metav1.APIResourceList
+	var fallbackErr error
+	fallbackDiscovered := false
+
 	for i := range workspaces {
 		logicalClusterName := logicalcluster.From(workspaces[i]).Join(workspaces[i].Name).String()
 
-		klog.Infof("Discovering types for logical cluster %q", logicalClusterName)
+		d.logger.Info("Discovering types", "logical-cluster", logicalClusterName)
 		rs, err := d.disco.WithCluster(logicalcluster.New(logicalClusterName)).ServerPreferredResources()
+		if err != nil && d.fallbackDisco != nil && (apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err)) {
+			d.logger.V(2).Info("Discovery of logical cluster is not available, using fallback discovery", "logical-cluster", logicalClusterName, "err", err)
+			if !fallbackDiscovered {
+				fallbackResources, fallbackErr = d.fallbackDisco.ServerPreferredResources()
+				fallbackDiscovered = true
+			}
+			rs, err = fallbackResources, fallbackErr
+		}
 		if err != nil {
 			return err
 		}
@@ -331,7 +1416,7 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 			for _, ai := range r.APIResources {
 				gvr := gv.WithResource(ai.Name)
 
-				if strings.Contains(ai.Name, "/") {
+				if strings.Contains(ai.Name, "/") && !d.includeSubresources.Has(ai.Name) {
 					// foo/status, pods/exec, namespace/finalize, etc.
 					continue
 				}
@@ -340,14 +1425,17 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 					continue
 				}
 				if !sets.NewString([]string(ai.Verbs)...).HasAll("list", "watch") {
-					klog.V(4).InfoS("resource is not list+watchable", "logical-cluster", logicalClusterName, "group", gv.Group, "version", gv.Version, "resource", ai.Name, "verbs", ai.Verbs)
+					d.logger.V(4).Info("Resource is not list+watchable", "logical-cluster", logicalClusterName, "gvr", gvr.String(), "verbs", ai.Verbs)
 					continue
 				}
 
-				latest[gvr] = struct{}{}
+				latest[d.canonicalGVR(gvr)] = struct{}{}
 			}
 		}
 	}
+	for gvr := range d.baseGVRs {
+		latest[gvr] = struct{}{}
+	}
 
 	// Grab a read lock to compare against d.informers to see if we need to start or stop any informers
 	d.mu.RLock()
@@ -362,6 +1450,13 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// Forget evictions of types which are gone, such that they are informed on when they come back
+	for gvr := range d.evictedGVRs {
+		if _, found := latest[gvr]; !found {
+			delete(d.evictedGVRs, gvr)
+		}
+	}
+
 	// Recalculate in case another goroutine did this work in between when we had the read lock and when we acquired
 	// the write lock
 	informersToAdd, informersToRemove = d.calculateInformersLockHeld(latest)
@@ -369,10 +1464,34 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 		return nil
 	}
 
-	// Now we definitely need to do this work
+	// Now we definitely need to do this work. With a rate limit, add informers in a stable
+	// order, and leave the others to the next discovery run.
+	if d.informerCreationLimiter != nil {
+		sort.Slice(informersToAdd, func(i, j int) bool {
+			return informersToAdd[i].String() < informersToAdd[j].String()
+		})
+	}
+	for i := range informersToRemove {
+		gvr := informersToRemove[i]
+
+		d.logger.Info("Removing dynamic informer", "gvr", gvr.String())
+		d.removeInformerLockHeld(gvr)
+		d.emitDiscoveryEventLockHeld(DiscoveryEvent{Type: DiscoveryEventRemoved, GVR: gvr})
+	}
+
+	added := make(map[schema.GroupVersionResource]struct{}, len(informersToAdd))
 	for i := range informersToAdd {
 		gvr := informersToAdd[i]
 
+		if d.informerCreationLimiter != nil && !d.informerCreationLimiter.TryAccept() {
+			d.logger.V(2).Info("Deferring dynamic informers due to the informer creation rate limit", "count", len(informersToAdd)-i)
+			break
+		}
+		if d.maxInformers > 0 && len(d.informers) >= d.maxInformers && !d.evictInformerLockHeld(added) {
+			d.logger.Info("Skipping dynamic informers, the maximum number of informers is reached and all are pinned or new", "count", len(informersToAdd)-i, "max", d.maxInformers)
+			break
+		}
+
 		// We have the write lock, so call the LH variant
 		inf, err := d.informerForResourceLockHeld(gvr)
 		if err != nil {
@@ -386,35 +1505,133 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 		// And store it
 		d.informerStops[gvr] = stop
 		d.startedInformers[gvr] = true
+
+		added[gvr] = struct{}{}
+
+		d.emitDiscoveryEventLockHeld(DiscoveryEvent{Type: DiscoveryEventAdded, GVR: gvr})
 	}
 
-	for i := range informersToRemove {
-		gvr := informersToRemove[i]
+	return nil
+}
+
+// removeInformerLockHeld stops the informer of gvr and removes it. The caller must have the write
+// lock before calling this method.
+func (d *DynamicDiscoverySharedInformerFactory) removeInformerLockHeld(gvr schema.GroupVersionResource) {
+	stop, ok := d.informerStops[gvr]
+	if ok {
+		d.logger.V(4).Info("Closing stop channel for dynamic informer", "gvr", gvr.String())
+		close(stop)
+	}
 
-		klog.Infof("Removing dynamic informer for %q", gvr)
+	d.logger.V(4).Info("Removing dynamic informer from maps", "gvr", gvr.String())
+	delete(d.informers, gvr)
+	delete(d.informerStops, gvr)
+	delete(d.startedInformers, gvr)
+	delete(d.lastActive, gvr)
+	if coalescer, ok := d.coalescers[gvr]; ok {
+		coalescer.stop()
+		delete(d.coalescers, gvr)
+	}
+}
 
-		stop, ok := d.informerStops[gvr]
-		if ok {
-			klog.V(4).Infof("Closing stop channel for dynamic informer for %q", gvr)
-			close(stop)
+// evictInformerLockHeld removes the least recently active informer which is neither pinned nor
+// in skip, and returns false if there is none. The caller must have the write lock before calling
+// this method.
+func (d *DynamicDiscoverySharedInformerFactory) evictInformerLockHeld(skip map[schema.GroupVersionResource]struct{}) bool {
+	var coldest schema.GroupVersionResource
+	var coldestActive int64
+	found := false
+	for gvr := range d.informers {
+		if _, pinned := d.pinnedGVRs[gvr]; pinned {
+			continue
+		}
+		if _, base := d.baseGVRs[gvr]; base {
+			continue
+		}
+		if _, skipped := skip[gvr]; skipped {
+			continue
+		}
+		var active int64
+		if lastActive := d.lastActive[gvr]; lastActive != nil {
+			active = atomic.LoadInt64(lastActive)
+		}
+		if !found || active < coldestActive || (active == coldestActive && gvr.String() < coldest.String()) {
+			coldest, coldestActive, found = gvr, active, true
 		}
+	}
+	if !found {
+		return false
+	}
 
-		klog.V(4).Infof("Removing dynamic informer from maps for %q", gvr)
-		delete(d.informers, gvr)
-		delete(d.informerStops, gvr)
-		delete(d.startedInformers, gvr)
+	d.logger.Info("Evicting least recently active dynamic informer", "gvr", coldest.String(), "lastActive", time.Unix(0, coldestActive), "max", d.maxInformers)
+	d.removeInformerLockHeld(coldest)
+	d.evictedGVRs[coldest] = struct{}{}
+	informerEvictionsTotal.WithLabelValues(coldest.Group, coldest.Resource).Inc()
+	d.emitDiscoveryEventLockHeld(DiscoveryEvent{Type: DiscoveryEventRemoved, GVR: coldest})
+	return true
+}
+
+// DiscoveryEventType is the kind of change of a DiscoveryEvent.
+type DiscoveryEventType string
+
+const (
+	// DiscoveryEventAdded means discovery added an informer for the GVR.
+	DiscoveryEventAdded DiscoveryEventType = "Added"
+	// DiscoveryEventRemoved means discovery removed the informer of the GVR.
+	DiscoveryEventRemoved DiscoveryEventType = "Removed"
+)
+
+// DiscoveryEvent is a change of the set of GVRs informed on by discovery.
+type DiscoveryEvent struct {
+	Type DiscoveryEventType
+	GVR  schema.GroupVersionResource
+}
+
+// discoveryEventsBufferSize is the number of discovery events buffered for a slow consumer.
+const discoveryEventsBufferSize = 100
+
+// DiscoveryEvents returns a channel receiving an event for every GVR discovery adds an informer
+// for or removes the informer of. Informers created by InformerForResource are not reported. In
+// order to never block discovery, the oldest events are dropped when the consumer falls behind by
+// more than a buffer of events. The channel is shared by all callers, and closed when the factory
+// is shut down.
+func (d *DynamicDiscoverySharedInformerFactory) DiscoveryEvents() <-chan DiscoveryEvent {
+	return d.discoveryEvents
+}
+
+// emitDiscoveryEventLockHeld sends event to the discovery events channel, dropping the oldest
+// events if it is full. The caller must have the write lock before calling this method.
+func (d *DynamicDiscoverySharedInformerFactory) emitDiscoveryEventLockHeld(event DiscoveryEvent) {
+	if d.terminating {
+		return
 	}
+	for {
+		select {
+		case d.discoveryEvents <- event:
+			return
+		default:
+		}
 
-	return nil
+		select {
+		case dropped := <-d.discoveryEvents:
+			d.logger.V(2).Info("Dropping discovery event not consumed in time", "type", dropped.Type, "gvr", dropped.GVR.String())
+		default:
+		}
+	}
 }
 
 // Start starts any informers that have been created but not yet started. The passed in stop channel is ignored;
 // instead, a new stop channel is created, so the factory can properly stop the informer if/when the API is removed.
-// Like other shared informer factories, this call is non-blocking.
+// The informers are stopped when the base context of the factory is done. Like other shared informer factories, this
+// call is non-blocking.
 func (d *DynamicDiscoverySharedInformerFactory) Start(_ <-chan struct{}) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.terminating {
+		return
+	}
+
 	for gvr, informer := range d.informers {
 		if !d.startedInformers[gvr] {
 			// Set up a stop channel for this specific informer
@@ -428,24 +1645,17 @@ func (d *DynamicDiscoverySharedInformerFactory) Start(_ <-chan struct{}) {
 	}
 }
 
-var (
-	crdGVR         = apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")
-	apibindingsGVR = apisv1alpha1.SchemeGroupVersion.WithResource("apibindings")
-)
-
 func (d *DynamicDiscoverySharedInformerFactory) calculateInformersLockHeld(latest map[schema.GroupVersionResource]struct{}) (toAdd, toRemove []schema.GroupVersionResource) {
 	for gvr := range latest {
+		if _, evicted := d.evictedGVRs[gvr]; evicted {
+			continue
+		}
 		if _, found := d.informers[gvr]; !found {
 			toAdd = append(toAdd, gvr)
 		}
 	}
 
 	for gvr := range d.informers {
-		// HACK(ncdc): these are needed by our kubeQuota controller - don't delete them
-		if gvr == crdGVR || gvr == apibindingsGVR {
-			continue
-		}
-
 		if _, found := latest[gvr]; !found {
 			toRemove = append(toRemove, gvr)
 		}
@@ -0,0 +1,1769 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"context"
+	"fmt"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+var (
+	servicesGVR      = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	widgetsGVR       = schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}
+	widgetsStatusGVR = schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets/status"}
+)
+
+func newFakeDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			servicesGVR:      "ServiceList",
+			widgetsGVR:       "WidgetList",
+			widgetsStatusGVR: "WidgetList",
+		},
+		objects...,
+	)
+}
+
+func TestTweakListOptions(t *testing.T) {
+	client := newFakeDynamicClient()
+
+	var lock sync.Mutex
+	var tweaked []schema.GroupVersionResource
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client,
+		func(obj interface{}) bool { return true }, time.Minute,
+		WithTweakListOptions(func(gvr schema.GroupVersionResource, options *metav1.ListOptions) {
+			lock.Lock()
+			defer lock.Unlock()
+			tweaked = append(tweaked, gvr)
+			options.LabelSelector = "app=foo"
+			options.FieldSelector = "metadata.name=bar"
+		}),
+	)
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+
+	lock.Lock()
+	require.Contains(t, tweaked, servicesGVR)
+	lock.Unlock()
+
+	var listAction clienttesting.ListActionImpl
+	for _, action := range client.Actions() {
+		if a, ok := action.(clienttesting.ListActionImpl); ok {
+			listAction = a
+			break
+		}
+	}
+	require.Equal(t, servicesGVR, listAction.GetResource())
+	require.Equal(t, "app=foo", listAction.GetListRestrictions().Labels.String())
+	require.Equal(t, "metadata.name=bar", listAction.GetListRestrictions().Fields.String())
+}
+
+func TestWatchBookmarks(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(),
+		func(obj interface{}) bool { return true }, time.Minute)
+	require.Nil(t, f.listOptionsTweakerFor(servicesGVR), "expected list options not to be customized by default")
+
+	var lock sync.Mutex
+	var tweaked []metav1.ListOptions
+	f = NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(),
+		func(obj interface{}) bool { return true }, time.Minute,
+		WithWatchBookmarks(),
+		WithTweakListOptions(func(gvr schema.GroupVersionResource, options *metav1.ListOptions) {
+			lock.Lock()
+			defer lock.Unlock()
+			tweaked = append(tweaked, *options)
+		}),
+	)
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.NotEmpty(t, tweaked)
+	require.True(t, tweaked[0].AllowWatchBookmarks, "expected the list options to allow watch bookmarks")
+}
+
+func TestTypedGet(t *testing.T) {
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "foo",
+		},
+		"spec": map[string]interface{}{
+			"clusterIP": "10.0.0.1",
+		},
+	}}
+	client := newFakeDynamicClient(service)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, func(obj interface{}) bool { return true }, time.Minute)
+
+	var into corev1.Service
+	require.Error(t, f.TypedGet(servicesGVR, "default", "foo", &into), "expected an error for an unknown informer")
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+
+	require.NoError(t, f.TypedGet(servicesGVR, "default", "foo", &into))
+	require.Equal(t, "foo", into.Name)
+	require.Equal(t, "default", into.Namespace)
+	require.Equal(t, "10.0.0.1", into.Spec.ClusterIP)
+
+	err = f.TypedGet(servicesGVR, "default", "bar", &into)
+	require.True(t, errors.IsNotFound(err), "expected a NotFound error, got %v", err)
+}
+
+func newService(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+		},
+	}}
+}
+
+func TestListPaged(t *testing.T) {
+	client := newFakeDynamicClient(
+		newService("ns-b", "a"),
+		newService("ns-a", "c"),
+		newService("ns-b", "b"),
+		newService("ns-a", "a"),
+		newService("ns-a", "b"),
+	)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, func(obj interface{}) bool { return true }, time.Minute)
+
+	_, _, err := f.ListPaged(servicesGVR, "", 2)
+	require.Error(t, err, "expected an error for an unknown informer")
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	_, _, err = f.ListPaged(servicesGVR, "", 2)
+	require.Error(t, err, "expected an error for an unsynced informer")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+
+	keys := func(objs []runtime.Object) []string {
+		var ret []string
+		for _, obj := range objs {
+			u := obj.(*unstructured.Unstructured)
+			ret = append(ret, u.GetNamespace()+"/"+u.GetName())
+		}
+		return ret
+	}
+
+	var pages [][]string
+	token := ""
+	for {
+		objs, next, err := f.ListPaged(servicesGVR, token, 2)
+		require.NoError(t, err)
+		pages = append(pages, keys(objs))
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	require.Equal(t, [][]string{
+		{"ns-a/a", "ns-a/b"},
+		{"ns-a/c", "ns-b/a"},
+		{"ns-b/b"},
+	}, pages)
+
+	objs, next, err := f.ListPaged(servicesGVR, "", 0)
+	require.NoError(t, err)
+	require.Empty(t, next)
+	require.Equal(t, []string{"ns-a/a", "ns-a/b", "ns-a/c", "ns-b/a", "ns-b/b"}, keys(objs))
+}
+
+func TestForEach(t *testing.T) {
+	client := newFakeDynamicClient(
+		newService("ns-a", "a"),
+		newService("ns-b", "b"),
+		newObject(schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}, "ns-a", "w", 0),
+	)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, func(obj interface{}) bool { return true }, time.Minute)
+
+	err := f.ForEach(servicesGVR, func(obj interface{}) error { return nil })
+	require.Error(t, err, "expected an error for an unknown informer")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	for _, gvr := range []schema.GroupVersionResource{servicesGVR, widgetsGVR} {
+		inf, err := f.InformerForResource(gvr)
+		require.NoError(t, err)
+		go inf.Informer().Run(stopCh)
+		require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+	}
+
+	key := func(obj interface{}) string {
+		u := obj.(*unstructured.Unstructured)
+		return u.GetNamespace() + "/" + u.GetName()
+	}
+
+	var visited []string
+	require.NoError(t, f.ForEach(servicesGVR, func(obj interface{}) error {
+		visited = append(visited, key(obj))
+		return nil
+	}))
+	require.ElementsMatch(t, []string{"ns-a/a", "ns-b/b"}, visited)
+
+	t.Log("Iteration stops at the first error")
+	visited = nil
+	stopErr := fmt.Errorf("stop")
+	err = f.ForEach(servicesGVR, func(obj interface{}) error {
+		visited = append(visited, key(obj))
+		return stopErr
+	})
+	require.Equal(t, stopErr, err)
+	require.Len(t, visited, 1)
+
+	t.Log("All informers are visited")
+	all := map[schema.GroupVersionResource][]string{}
+	f.ForEachAll(func(gvr schema.GroupVersionResource, obj interface{}) {
+		all[gvr] = append(all[gvr], key(obj))
+	})
+	require.Len(t, all, 2)
+	require.ElementsMatch(t, []string{"ns-a/a", "ns-b/b"}, all[servicesGVR])
+	require.Equal(t, []string{"ns-a/w"}, all[widgetsGVR])
+
+	t.Log("Nothing is visited after shutdown")
+	f.shutdown()
+	visited = nil
+	err = f.ForEach(servicesGVR, func(obj interface{}) error {
+		visited = append(visited, key(obj))
+		return nil
+	})
+	require.ErrorIs(t, err, ErrFactoryTerminating)
+	require.Empty(t, visited)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute,
+		WithUpdateCoalescing(time.Hour, servicesGVR),
+		WithDrainTimeout(wait.ForeverTestTimeout),
+	)
+
+	var lock sync.Mutex
+	var events []string
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	release := make(chan struct{})
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			name := obj.(*unstructured.Unstructured).GetName()
+			if name == "bar" {
+				<-release
+			}
+			record("add " + name)
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			record("update " + newObj.(*unstructured.Unstructured).GetName())
+		},
+	})
+
+	_, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	f.Start(nil)
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("An update of foo is pending in the coalescer")
+	foo := newService("default", "foo")
+	foo.SetLabels(map[string]string{"updated": "true"})
+	_, err = client.Resource(servicesGVR).Namespace("default").Update(context.Background(), foo, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	f.mu.RLock()
+	coalescer := f.coalescers[servicesGVR]
+	f.mu.RUnlock()
+	require.Eventually(t, func() bool {
+		coalescer.lock.Lock()
+		defer coalescer.lock.Unlock()
+		return len(coalescer.pending) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("The add of bar is in flight")
+	_, err = client.Resource(servicesGVR).Namespace("default").Create(context.Background(), newService("default", "bar"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&f.inflightEvents) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		f.shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected shutdown to wait for the in-flight event")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for shutdown")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.ElementsMatch(t, []string{"add foo", "update foo", "add bar"}, events)
+}
+
+func TestRemoveInformerStopsUpdateCoalescing(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute,
+		WithUpdateCoalescing(time.Minute, servicesGVR),
+	)
+	defer f.shutdown()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f.clock = fakeClock
+
+	var lock sync.Mutex
+	var events []string
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	recorded := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), events...)
+	}
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			record("add " + obj.(*unstructured.Unstructured).GetName())
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			record("update " + newObj.(*unstructured.Unstructured).GetName())
+		},
+	})
+
+	_, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	f.Start(nil)
+	require.Eventually(t, func() bool {
+		return len(recorded()) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("An update of foo is pending in the coalescer")
+	foo := newService("default", "foo")
+	foo.SetLabels(map[string]string{"updated": "true"})
+	_, err = client.Resource(servicesGVR).Namespace("default").Update(context.Background(), foo, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	f.mu.RLock()
+	coalescer := f.coalescers[servicesGVR]
+	f.mu.RUnlock()
+	require.Eventually(t, func() bool {
+		coalescer.lock.Lock()
+		defer coalescer.lock.Unlock()
+		return len(coalescer.pending) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("The informer is removed before the window passed")
+	f.mu.Lock()
+	f.removeInformerLockHeld(servicesGVR)
+	f.mu.Unlock()
+	fakeClock.Step(time.Minute)
+	require.Equal(t, []string{"add foo"}, recorded(), "the update of a removed informer must not be delivered")
+}
+
+func TestNilFilterFunc(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+
+	added := make(chan string, 1)
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			added <- obj.(*unstructured.Unstructured).GetName()
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+
+	select {
+	case name := <-added:
+		require.Equal(t, "foo", name)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the add event")
+	}
+}
+
+func TestClusterAwareEventHandler(t *testing.T) {
+	inOrg := newService("default", "foo")
+	inOrg.SetClusterName("root:org")
+	inTeam := newService("default", "bar")
+	inTeam.SetClusterName("root:org:team")
+	client := newFakeDynamicClient(inOrg, inTeam)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+
+	added := make(chan string, 2)
+	f.AddClusterAwareEventHandler(ClusterAwareGVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{}) {
+			added <- clusterName.String() + "|" + obj.(*unstructured.Unstructured).GetName()
+		},
+	})
+	plainAdded := make(chan string, 2)
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			plainAdded <- obj.(*unstructured.Unstructured).GetName()
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+
+	receive := func(ch chan string) []string {
+		var got []string
+		for len(got) < 2 {
+			select {
+			case s := <-ch:
+				got = append(got, s)
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timed out waiting for add events")
+			}
+		}
+		sort.Strings(got)
+		return got
+	}
+	require.Equal(t, []string{"root:org:team|bar", "root:org|foo"}, receive(added))
+	require.Equal(t, []string{"bar", "foo"}, receive(plainAdded), "handlers without cluster must be called too")
+}
+
+func TestClusterNameFrom(t *testing.T) {
+	obj := newService("default", "foo")
+	obj.SetClusterName("root:org")
+
+	require.Equal(t, logicalcluster.New("root:org"), clusterNameFrom(obj))
+	require.Equal(t, logicalcluster.New("root:org"), clusterNameFrom(cache.DeletedFinalStateUnknown{Key: "default/foo", Obj: obj}))
+	require.True(t, clusterNameFrom("not an object").Empty())
+}
+
+func TestSetEventHandlers(t *testing.T) {
+	client := newFakeDynamicClient()
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+
+	events := make(chan string, 10)
+	handler := func(name string) GVREventHandler {
+		return GVREventHandlerFuncs{
+			AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+				events <- name + " " + obj.(*unstructured.Unstructured).GetName()
+			},
+		}
+	}
+	f.AddEventHandler(handler("old"))
+	f.AddClusterAwareEventHandler(ClusterAwareGVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{}) {
+			events <- "old-cluster-aware " + obj.(*unstructured.Unstructured).GetName()
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, inf.Informer().HasSynced))
+
+	f.SetEventHandlers([]GVREventHandler{handler("new-1"), handler("new-2")})
+
+	_, err = client.Resource(servicesGVR).Namespace("default").Create(context.Background(), newService("default", "foo"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	require.Equal(t, []string{"new-1 foo", "new-2 foo"}, got)
+
+	t.Log("Cluster-aware handlers replace all handlers as well")
+	f.SetClusterAwareEventHandlers([]ClusterAwareGVREventHandler{ClusterAwareGVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj interface{}) {
+			events <- "new-cluster-aware " + obj.(*unstructured.Unstructured).GetName()
+		},
+	}})
+
+	_, err = client.Resource(servicesGVR).Namespace("default").Create(context.Background(), newService("default", "bar"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	select {
+	case e := <-events:
+		require.Equal(t, "new-cluster-aware bar", e)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the event of bar")
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %q", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNumEventHandlers(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+	require.Equal(t, 0, f.NumEventHandlers())
+
+	f.AddEventHandler(GVREventHandlerFuncs{})
+	f.AddClusterAwareEventHandler(ClusterAwareGVREventHandlerFuncs{})
+	require.Equal(t, 2, f.NumEventHandlers())
+
+	f.SetEventHandlers([]GVREventHandler{GVREventHandlerFuncs{}})
+	require.Equal(t, 1, f.NumEventHandlers())
+
+	f.SetEventHandlers(nil)
+	require.Equal(t, 0, f.NumEventHandlers())
+}
+
+func TestPanickingEventHandler(t *testing.T) {
+	client := newFakeDynamicClient()
+
+	var logsLock sync.Mutex
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logsLock.Lock()
+		defer logsLock.Unlock()
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute, WithLogger(logger))
+
+	events := make(chan string, 10)
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			var m map[string]string
+			m[obj.(*unstructured.Unstructured).GetName()] = "boom"
+		},
+	})
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			events <- obj.(*unstructured.Unstructured).GetName()
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, inf.Informer().HasSynced))
+
+	// the informer keeps delivering events after the first handler panicked.
+	for _, name := range []string{"foo", "bar"} {
+		_, err = client.Resource(servicesGVR).Namespace("default").Create(context.Background(), newService("default", name), metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		select {
+		case e := <-events:
+			require.Equal(t, name, e)
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for the event of %s", name)
+		}
+	}
+
+	// the panics are logged through the injected logger.
+	logsLock.Lock()
+	defer logsLock.Unlock()
+	var panics int
+	for _, l := range logs {
+		if strings.Contains(l, `"msg"="Recovered from panic in event handler"`) {
+			require.Contains(t, l, `"gvr"="/v1, Resource=services"`)
+			require.Contains(t, l, `"event"="add"`)
+			panics++
+		}
+	}
+	require.Equal(t, 2, panics)
+}
+
+func TestExcludeNamespaces(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"), newService("kube-system", "bar"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute, WithExcludeNamespaces("kube-system"))
+
+	events := make(chan string, 10)
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			u := obj.(*unstructured.Unstructured)
+			events <- "add " + u.GetNamespace() + "/" + u.GetName()
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			u := obj.(*unstructured.Unstructured)
+			events <- "delete " + u.GetNamespace() + "/" + u.GetName()
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, inf.Informer().HasSynced))
+
+	_, err = client.Resource(servicesGVR).Namespace("kube-system").Create(context.Background(), newService("kube-system", "baz"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, client.Resource(servicesGVR).Namespace("kube-system").Delete(context.Background(), "bar", metav1.DeleteOptions{}))
+	require.NoError(t, client.Resource(servicesGVR).Namespace("default").Delete(context.Background(), "foo", metav1.DeleteOptions{}))
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	require.Equal(t, []string{"add default/foo", "delete default/foo"}, got)
+
+	// excluded objects are still in the cache
+	_, exists, err := inf.Informer().GetIndexer().GetByKey("kube-system/baz")
+	require.NoError(t, err)
+	require.True(t, exists)
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %q", e)
+	default:
+	}
+}
+
+func TestCrossIndex(t *testing.T) {
+	withOwnerTeam := func(obj *unstructured.Unstructured, team string) *unstructured.Unstructured {
+		require.NoError(t, unstructured.SetNestedField(obj.Object, team, "spec", "ownerTeam"))
+		return obj
+	}
+	client := newFakeDynamicClient(
+		withOwnerTeam(newService("ns-a", "a"), "blue"),
+		withOwnerTeam(newService("ns-b", "b"), "red"),
+		withOwnerTeam(newObject(schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}, "ns-a", "w", 0), "blue"),
+		newService("ns-c", "c"),
+	)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+	require.NoError(t, f.AddCrossIndex("byOwnerTeam", func(obj interface{}) ([]string, error) {
+		team, found, err := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "ownerTeam")
+		if err != nil || !found {
+			return nil, err
+		}
+		return []string{team}, nil
+	}))
+	require.Error(t, f.AddCrossIndex("byOwnerTeam", nil), "expected duplicate indexes to be rejected")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	for _, gvr := range []schema.GroupVersionResource{servicesGVR, widgetsGVR} {
+		inf, err := f.InformerForResource(gvr)
+		require.NoError(t, err)
+		go inf.Informer().Run(stopCh)
+		require.True(t, cache.WaitForCacheSync(stopCh, inf.Informer().HasSynced))
+	}
+	require.Error(t, f.AddCrossIndex("late", nil), "expected indexes to be rejected after informers were created")
+
+	names := func(objs []runtime.Object) []string {
+		var names []string
+		for _, obj := range objs {
+			u := obj.(*unstructured.Unstructured)
+			names = append(names, u.GetKind()+" "+u.GetNamespace()+"/"+u.GetName())
+		}
+		return names
+	}
+	require.Equal(t, []string{"Service ns-a/a", "Widget ns-a/w"}, names(f.GetByCrossIndex("byOwnerTeam", "blue")))
+	require.Equal(t, []string{"Service ns-b/b"}, names(f.GetByCrossIndex("byOwnerTeam", "red")))
+	require.Empty(t, f.GetByCrossIndex("byOwnerTeam", "green"))
+	require.Nil(t, f.GetByCrossIndex("unknown", "blue"))
+}
+
+func TestRemoveIndexer(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+
+	indexByName := func(obj interface{}) ([]string, error) {
+		return []string{obj.(*unstructured.Unstructured).GetName()}, nil
+	}
+	require.NoError(t, f.AddIndexers(cache.Indexers{"byName": indexByName, "other": indexByName}))
+
+	require.NoError(t, f.RemoveIndexer("byName"))
+	require.Error(t, f.RemoveIndexer("byName"), "removing an unknown indexer must fail")
+	require.NoError(t, f.AddIndexers(cache.Indexers{"byName": indexByName}), "a removed indexer can be added again")
+	require.NoError(t, f.RemoveIndexer("byName"))
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	require.NotContains(t, inf.Informer().GetIndexer().GetIndexers(), "byName")
+	require.Contains(t, inf.Informer().GetIndexer().GetIndexers(), "other")
+
+	require.Error(t, f.RemoveIndexer("other"), "indexers cannot be removed after informers have been created")
+	require.Error(t, f.AddIndexers(cache.Indexers{"byName": indexByName}), "indexers cannot be added after informers have been created")
+}
+
+func TestWithoutNamespaceIndex(t *testing.T) {
+	client := newFakeDynamicClient(
+		newObject(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "default", "foo", 0),
+		newObject(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "other", "bar", 0),
+	)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+	defer f.shutdown()
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	require.Contains(t, inf.Informer().GetIndexer().GetIndexers(), cache.NamespaceIndex)
+
+	f = NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute, WithoutNamespaceIndex(), WithNamespaceNameIndex())
+	defer f.shutdown()
+	require.NoError(t, startAndSync(f, servicesGVR))
+	inf, err = f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	require.NotContains(t, inf.Informer().GetIndexer().GetIndexers(), cache.NamespaceIndex)
+	require.Contains(t, inf.Informer().GetIndexer().GetIndexers(), ByNamespaceNameIndex)
+
+	t.Log("Namespace-scoped queries still work without the index")
+	listers, notSynced := f.Listers()
+	require.Empty(t, notSynced)
+	objs, err := listers[servicesGVR].ByNamespace("default").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+}
+
+func TestRecreateInformer(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"), newService("default", "bar"))
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+	defer f.shutdown()
+
+	var adds int32
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			atomic.AddInt32(&adds, 1)
+		},
+	})
+	indexByName := func(obj interface{}) ([]string, error) {
+		return []string{obj.(*unstructured.Unstructured).GetName()}, nil
+	}
+	require.NoError(t, f.AddIndexers(cache.Indexers{"byName": indexByName}))
+
+	_, err := f.RecreateInformer(context.Background(), servicesGVR)
+	require.Error(t, err, "informers which do not exist cannot be recreated")
+	_, err = f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	_, err = f.RecreateInformer(context.Background(), servicesGVR)
+	require.Error(t, err, "informers which have not been started cannot be recreated")
+
+	require.NoError(t, startAndSync(f, servicesGVR))
+	old, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&adds) == 2 }, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	lists := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" && action.GetResource() == servicesGVR {
+				count++
+			}
+		}
+		return count
+	}
+	listsBefore := lists()
+
+	inf, err := f.RecreateInformer(context.Background(), servicesGVR)
+	require.NoError(t, err)
+	require.NotSame(t, old, inf)
+	current, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	require.Same(t, inf, current)
+
+	t.Log("The new informer lists from scratch and notifies the handlers again")
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+	require.Greater(t, lists(), listsBefore)
+	require.Len(t, inf.Informer().GetStore().List(), 2)
+	require.Contains(t, inf.Informer().GetIndexer().GetIndexers(), "byName")
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&adds) == 4 }, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	f.shutdown()
+	_, err = f.RecreateInformer(context.Background(), servicesGVR)
+	require.ErrorIs(t, err, ErrFactoryTerminating)
+}
+
+func TestRecreateInformerStopsUpdateCoalescing(t *testing.T) {
+	client := newFakeDynamicClient(newService("default", "foo"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute,
+		WithUpdateCoalescing(time.Minute, servicesGVR),
+	)
+	defer f.shutdown()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f.clock = fakeClock
+
+	var lock sync.Mutex
+	var events []string
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	recorded := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), events...)
+	}
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			record("add " + obj.(*unstructured.Unstructured).GetName())
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			record("update " + newObj.(*unstructured.Unstructured).GetName())
+		},
+	})
+
+	require.NoError(t, startAndSync(f, servicesGVR))
+	require.Eventually(t, func() bool {
+		return len(recorded()) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("An update of foo is pending in the coalescer")
+	foo := newService("default", "foo")
+	foo.SetLabels(map[string]string{"updated": "true"})
+	_, err := client.Resource(servicesGVR).Namespace("default").Update(context.Background(), foo, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	f.mu.RLock()
+	old := f.coalescers[servicesGVR]
+	f.mu.RUnlock()
+	require.Eventually(t, func() bool {
+		old.lock.Lock()
+		defer old.lock.Unlock()
+		return len(old.pending) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("The informer is recreated before the window passed")
+	inf, err := f.RecreateInformer(context.Background(), servicesGVR)
+	require.NoError(t, err)
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+	require.Eventually(t, func() bool {
+		return len(recorded()) == 2
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	f.mu.RLock()
+	current := f.coalescers[servicesGVR]
+	f.mu.RUnlock()
+	require.NotSame(t, old, current, "expected a new coalescer for the new informer")
+	old.lock.Lock()
+	require.True(t, old.stopped)
+	require.Empty(t, old.pending)
+	old.lock.Unlock()
+
+	t.Log("The pending update of the old informer is not delivered after the new initial list")
+	fakeClock.Step(time.Minute)
+	require.Equal(t, []string{"add foo", "add foo"}, recorded())
+}
+
+func TestGVRAliases(t *testing.T) {
+	widgetsAliasGVR := schema.GroupVersionResource{Group: "alias.example.io", Version: "v1", Resource: "widgets"}
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{
+		{
+			GroupVersion: "example.io/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+		},
+		{
+			GroupVersion: "alias.example.io/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+		},
+	}}
+	client := newFakeDynamicClient(newObject(schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}, "default", "foo", 0))
+
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, client, nil, time.Minute,
+		WithGVRAliases(map[schema.GroupVersionResource]schema.GroupVersionResource{widgetsAliasGVR: widgetsGVR}))
+	defer f.shutdown()
+
+	var lock sync.Mutex
+	var handled []schema.GroupVersionResource
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			handled = append(handled, gvr)
+		},
+	})
+
+	require.NoError(t, f.discoverTypes(context.Background()))
+	require.Len(t, f.informers, 1, "expected a single informer for the aliased GVRs")
+	require.Contains(t, f.informers, widgetsGVR)
+
+	canonical, err := f.InformerForResource(widgetsGVR)
+	require.NoError(t, err)
+	alias, err := f.InformerForResource(widgetsAliasGVR)
+	require.NoError(t, err)
+	require.Same(t, canonical, alias)
+
+	infs, err := f.InformerForResources([]schema.GroupVersionResource{widgetsGVR, widgetsAliasGVR})
+	require.NoError(t, err)
+	require.Same(t, canonical, infs[widgetsAliasGVR])
+	require.Len(t, f.informers, 1)
+
+	require.True(t, cache.WaitForCacheSync(wait.NeverStop, canonical.Informer().HasSynced))
+	synced, exists := f.HasSyncedForResource(widgetsAliasGVR)
+	require.True(t, exists)
+	require.True(t, synced)
+
+	t.Log("Handlers receive the events under the canonical GVR")
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(handled) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []schema.GroupVersionResource{widgetsGVR}, handled)
+}
+
+// fakeClusterDiscovery serves the same preferred resources, or error, for every logical cluster
+// unless clusterResources has resources for the logical cluster.
+type fakeClusterDiscovery struct {
+	resources        []*metav1.APIResourceList
+	clusterResources map[logicalcluster.Name][]*metav1.APIResourceList
+	err              error
+}
+
+func (f *fakeClusterDiscovery) WithCluster(clusterName logicalcluster.Name) discovery.DiscoveryInterface {
+	resources := f.resources
+	if rs, ok := f.clusterResources[clusterName]; ok {
+		resources = rs
+	}
+	return &fakeDiscovery{FakeDiscovery: &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{}}, resources: resources, err: f.err}
+}
+
+type fakeDiscovery struct {
+	*discoveryfake.FakeDiscovery
+	resources []*metav1.APIResourceList
+	err       error
+}
+
+func (f *fakeDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.resources, nil
+}
+
+func newWorkspaceLister(t *testing.T) tenancylisters.ClusterWorkspaceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			ClusterName: "root",
+		},
+	}))
+	return tenancylisters.NewClusterWorkspaceLister(indexer)
+}
+
+func TestPauseDiscovery(t *testing.T) {
+	disco := &fakeClusterDiscovery{}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute)
+	defer func() {
+		for _, stop := range f.informerStops {
+			close(stop)
+		}
+	}()
+
+	ctx := context.Background()
+	serviceResources := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	widgetResources := &metav1.APIResourceList{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+
+	disco.resources = []*metav1.APIResourceList{serviceResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Contains(t, f.informers, servicesGVR)
+
+	f.PauseDiscovery()
+	disco.resources = []*metav1.APIResourceList{serviceResources, widgetResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	require.NotContains(t, f.informers, widgetsGVR, "expected the new type not to be picked up while paused")
+	require.Contains(t, f.informers, servicesGVR, "expected existing informers to keep running while paused")
+
+	f.ResumeDiscovery()
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Contains(t, f.informers, widgetsGVR, "expected the new type to be picked up after resume")
+}
+
+func TestWorkspaceSelector(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ws := range []*tenancyv1alpha1.ClusterWorkspace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant", ClusterName: "root", Labels: map[string]string{"tenant": "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", ClusterName: "root"}},
+	} {
+		require.NoError(t, indexer.Add(ws))
+	}
+	disco := &fakeClusterDiscovery{clusterResources: map[logicalcluster.Name][]*metav1.APIResourceList{
+		logicalcluster.New("root:tenant"): {{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+		}},
+		logicalcluster.New("root:other"): {{
+			GroupVersion: "example.io/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+		}},
+	}}
+
+	f := NewDynamicDiscoverySharedInformerFactory(tenancylisters.NewClusterWorkspaceLister(indexer), disco, newFakeDynamicClient(), nil, time.Minute,
+		WithWorkspaceSelector(labels.SelectorFromSet(labels.Set{"tenant": "true"})))
+	defer func() {
+		for _, stop := range f.informerStops {
+			close(stop)
+		}
+	}()
+
+	require.NoError(t, f.discoverTypes(context.Background()))
+	require.Contains(t, f.informers, servicesGVR, "expected the types of the matching workspace to be informed on")
+	require.NotContains(t, f.informers, widgetsGVR, "expected the types of the other workspace not to be informed on")
+}
+
+func TestIncludeSubresources(t *testing.T) {
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}},
+			{Name: "widgets/status", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+			{Name: "widgets/scale", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+		},
+	}}}
+
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute,
+		WithIncludeSubresources("widgets/status"))
+	defer func() {
+		for _, stop := range f.informerStops {
+			close(stop)
+		}
+	}()
+
+	require.NoError(t, f.discoverTypes(context.Background()))
+	require.Contains(t, f.informers, widgetsGVR)
+	require.Contains(t, f.informers, widgetsStatusGVR, "expected the included subresource to be informed on")
+	require.NotContains(t, f.informers, widgetsGVR.GroupVersion().WithResource("widgets/scale"), "expected other subresources to be skipped")
+}
+
+func TestInformerCreationRateLimit(t *testing.T) {
+	var resources []metav1.APIResource
+	for _, gvr := range benchmarkGVRs(10) {
+		resources = append(resources, metav1.APIResource{Name: gvr.Resource, Namespaced: true, Verbs: []string{"list", "watch"}})
+	}
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{{GroupVersion: "example.io/v1", APIResources: resources}}}
+
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute,
+		WithInformerCreationRateLimit(1, 3))
+	defer func() {
+		for _, stop := range f.informerStops {
+			close(stop)
+		}
+	}()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	f.informerCreationLimiter = flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(1, 3, fakeClock)
+
+	ctx := context.Background()
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 3, "expected no more informers than the burst")
+	require.Contains(t, f.informers, schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets0"}, "expected informers to be added in a stable order")
+
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 3, "expected no informers without new tokens")
+
+	fakeClock.SetTime(fakeClock.Now().Add(2 * time.Second))
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 5, "expected the deferred informers to be added at the rate of the limit")
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 8, "expected no more informers than the burst")
+	require.NoError(t, f.discoverTypes(ctx))
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 10)
+}
+
+func TestFallbackDiscovery(t *testing.T) {
+	serviceResources := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	widgetResources := &metav1.APIResourceList{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	notFound := errors.NewNotFound(schema.GroupResource{}, "")
+
+	ctx := context.Background()
+
+	// the workspace does not serve discovery yet
+	disco := &fakeClusterDiscovery{err: notFound}
+
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute)
+	require.Error(t, f.discoverTypes(ctx), "discovery must fail without fallback")
+
+	fallback := &fakeDiscovery{FakeDiscovery: &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{}}, resources: []*metav1.APIResourceList{serviceResources}}
+	f = NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute, WithFallbackDiscovery(fallback))
+	defer func() {
+		for _, stop := range f.informerStops {
+			close(stop)
+		}
+	}()
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Contains(t, f.informers, servicesGVR, "expected the fallback types to be informed on")
+
+	// the workspace serves discovery, which takes precedence
+	disco.err = nil
+	disco.resources = []*metav1.APIResourceList{serviceResources, widgetResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Contains(t, f.informers, servicesGVR)
+	require.Contains(t, f.informers, widgetsGVR, "expected the workspace types to be informed on")
+
+	// other errors are not masked by the fallback
+	disco.err = errors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("denied"))
+	require.Error(t, f.discoverTypes(ctx))
+}
+
+func TestWaitForGVRs(t *testing.T) {
+	serviceResources := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	widgetResources := &metav1.APIResourceList{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait.ForeverTestTimeout)
+	defer cancel()
+
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{serviceResources}}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute)
+	defer f.shutdown()
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- f.WaitForGVRs(ctx, []schema.GroupVersionResource{servicesGVR, widgetsGVR})
+	}()
+
+	t.Log("Only services are discovered at first")
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Never(t, func() bool { return len(waitErr) > 0 }, 3*notifySyncedPollInterval, 10*time.Millisecond,
+		"expected to wait for widgets to be discovered")
+
+	t.Log("Widgets are discovered in a later cycle")
+	disco.resources = []*metav1.APIResourceList{serviceResources, widgetResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	select {
+	case err := <-waitErr:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for WaitForGVRs to return")
+	}
+
+	t.Log("A GVR never discovered times out")
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 3*notifySyncedPollInterval)
+	defer timeoutCancel()
+	gizmosGVR := schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "gizmos"}
+	err := f.WaitForGVRs(timeoutCtx, []schema.GroupVersionResource{servicesGVR, gizmosGVR})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "gizmos")
+}
+
+func TestDiscoveryEvents(t *testing.T) {
+	serviceResources := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	widgetResources := &metav1.APIResourceList{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	ctx := context.Background()
+
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{serviceResources}}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute)
+	events := f.DiscoveryEvents()
+
+	receive := func() DiscoveryEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		default:
+			t.Fatal("expected a discovery event")
+			return DiscoveryEvent{}
+		}
+	}
+
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Equal(t, DiscoveryEvent{Type: DiscoveryEventAdded, GVR: servicesGVR}, receive())
+	require.Empty(t, events)
+
+	t.Log("Unchanged discovery emits no events")
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Empty(t, events)
+
+	t.Log("Widgets are added and services removed")
+	disco.resources = []*metav1.APIResourceList{widgetResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Equal(t, DiscoveryEvent{Type: DiscoveryEventRemoved, GVR: servicesGVR}, receive())
+	require.Equal(t, DiscoveryEvent{Type: DiscoveryEventAdded, GVR: widgetsGVR}, receive())
+	require.Empty(t, events)
+
+	t.Log("The oldest events are dropped when the buffer is full")
+	f.mu.Lock()
+	for i := 0; i <= discoveryEventsBufferSize; i++ {
+		f.emitDiscoveryEventLockHeld(DiscoveryEvent{Type: DiscoveryEventAdded, GVR: schema.GroupVersionResource{Resource: fmt.Sprintf("r%d", i)}})
+	}
+	f.mu.Unlock()
+	require.Len(t, events, discoveryEventsBufferSize)
+	require.Equal(t, "r1", receive().GVR.Resource)
+
+	t.Log("The channel is closed on shutdown")
+	f.shutdown()
+	for range events {
+	}
+}
+
+func TestBaseGVRs(t *testing.T) {
+	serviceResources := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "services", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	widgetResources := &metav1.APIResourceList{
+		GroupVersion: "example.io/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: []string{"list", "watch"}}},
+	}
+	ctx := context.Background()
+
+	disco := &fakeClusterDiscovery{resources: []*metav1.APIResourceList{serviceResources, widgetResources}}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute, WithBaseGVRs(widgetsGVR))
+	defer f.shutdown()
+
+	informed := func() []schema.GroupVersionResource {
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+		var gvrs []schema.GroupVersionResource
+		for gvr := range f.informers {
+			gvrs = append(gvrs, gvr)
+		}
+		return gvrs
+	}
+
+	require.NoError(t, f.discoverTypes(ctx))
+	require.ElementsMatch(t, []schema.GroupVersionResource{servicesGVR, widgetsGVR}, informed())
+
+	t.Log("Base GVRs persist while discovery omits them")
+	disco.resources = []*metav1.APIResourceList{serviceResources}
+	require.NoError(t, f.discoverTypes(ctx))
+	require.ElementsMatch(t, []schema.GroupVersionResource{servicesGVR, widgetsGVR}, informed())
+
+	t.Log("Other GVRs are removed when discovery omits them")
+	disco.resources = nil
+	require.NoError(t, f.discoverTypes(ctx))
+	require.ElementsMatch(t, []schema.GroupVersionResource{widgetsGVR}, informed())
+}
+
+func TestFindByName(t *testing.T) {
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "foo",
+		},
+	}}
+	configMapsGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	tests := map[string][]DynamicDiscoverySharedInformerOption{
+		"with index":    {WithNamespaceNameIndex()},
+		"without index": nil,
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newFakeDynamicClient(newService("default", "foo"), newService("default", "bar"), newService("other", "foo"), widget)
+			f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute, opts...)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			for _, gvr := range []schema.GroupVersionResource{servicesGVR, widgetsGVR} {
+				inf, err := f.InformerForResource(gvr)
+				require.NoError(t, err)
+				go inf.Informer().Run(stopCh)
+				require.True(t, cache.WaitForCacheSync(wait.NeverStop, inf.Informer().HasSynced))
+			}
+
+			// not started, hence never synced
+			_, err := f.InformerForResource(configMapsGVR)
+			require.NoError(t, err)
+
+			found, notSynced := f.FindByName("default", "foo")
+			require.Equal(t, []schema.GroupVersionResource{configMapsGVR}, notSynced)
+			require.Len(t, found, 2)
+			for _, gvr := range []schema.GroupVersionResource{servicesGVR, widgetsGVR} {
+				require.Contains(t, found, gvr)
+				u := found[gvr].(*unstructured.Unstructured)
+				require.Equal(t, "default", u.GetNamespace())
+				require.Equal(t, "foo", u.GetName())
+			}
+			require.Equal(t, "Widget", found[widgetsGVR].GetObjectKind().GroupVersionKind().Kind)
+
+			found, _ = f.FindByName("default", "bar")
+			require.Len(t, found, 1)
+			require.Contains(t, found, servicesGVR)
+
+			found, _ = f.FindByName("default", "baz")
+			require.Empty(t, found)
+		})
+	}
+}
+
+type fakeGenericInformer struct {
+	informers.GenericInformer
+	informer *fakeSharedIndexInformer
+}
+
+func (f *fakeGenericInformer) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+type fakeSharedIndexInformer struct {
+	cache.SharedIndexInformer
+	synced bool
+}
+
+func (f *fakeSharedIndexInformer) HasSynced() bool {
+	return f.synced
+}
+
+func TestHasSyncedForResource(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+
+	synced, exists := f.HasSyncedForResource(servicesGVR)
+	require.False(t, exists)
+	require.False(t, synced)
+
+	informer := &fakeSharedIndexInformer{}
+	f.informers[servicesGVR] = &fakeGenericInformer{informer: informer}
+
+	synced, exists = f.HasSyncedForResource(servicesGVR)
+	require.True(t, exists)
+	require.False(t, synced)
+
+	informer.synced = true
+	synced, exists = f.HasSyncedForResource(servicesGVR)
+	require.True(t, exists)
+	require.True(t, synced)
+
+	synced, exists = f.HasSyncedForResource(widgetsGVR)
+	require.False(t, exists)
+	require.False(t, synced)
+	require.NotContains(t, f.informers, widgetsGVR, "no informer must be created")
+}
+
+func TestNotifyWhenSynced(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f.clock = fakeClock
+
+	// waitForPoll waits until the callback goroutine checked the informer, and waits for the next poll.
+	waitForPoll := func() {
+		require.Eventually(t, fakeClock.HasWaiters, wait.ForeverTestTimeout, time.Millisecond, "timed out waiting for a poll")
+	}
+
+	var calls int32
+	notified := make(chan struct{}, 10)
+	f.NotifyWhenSynced(servicesGVR, func() {
+		atomic.AddInt32(&calls, 1)
+		notified <- struct{}{}
+	})
+
+	// the informer does not exist yet
+	waitForPoll()
+	require.Zero(t, atomic.LoadInt32(&calls))
+
+	informer := &fakeSharedIndexInformer{}
+	f.mu.Lock()
+	f.informers[servicesGVR] = &fakeGenericInformer{informer: informer}
+	f.mu.Unlock()
+
+	fakeClock.Step(notifySyncedPollInterval)
+	waitForPoll()
+	require.Zero(t, atomic.LoadInt32(&calls))
+
+	f.mu.Lock()
+	informer.synced = true
+	f.mu.Unlock()
+
+	fakeClock.Step(notifySyncedPollInterval)
+	select {
+	case <-notified:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the callback")
+	}
+	require.False(t, fakeClock.HasWaiters(), "no poll after the callback")
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// already synced informers notify without polling
+	f.NotifyWhenSynced(servicesGVR, func() { notified <- struct{}{} })
+	select {
+	case <-notified:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the callback of an already synced informer")
+	}
+
+	// no callback after shutdown
+	f.NotifyWhenSynced(widgetsGVR, func() { notified <- struct{}{} })
+	waitForPoll()
+	f.mu.Lock()
+	f.terminating = true
+	f.informers[widgetsGVR] = &fakeGenericInformer{informer: &fakeSharedIndexInformer{synced: true}}
+	f.mu.Unlock()
+	fakeClock.Step(notifySyncedPollInterval)
+	require.Eventually(t, func() bool { return !fakeClock.HasWaiters() }, wait.ForeverTestTimeout, time.Millisecond, "polling did not stop on shutdown")
+	require.Empty(t, notified)
+}
+
+func TestInformersForGroup(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+	_, err := f.InformerForResources([]schema.GroupVersionResource{servicesGVR, widgetsGVR, widgetsStatusGVR})
+	require.NoError(t, err)
+
+	got := f.InformersForGroup("example.io")
+	require.Len(t, got, 2)
+	require.Contains(t, got, widgetsGVR)
+	require.Contains(t, got, widgetsStatusGVR)
+
+	got = f.InformersForGroup("")
+	require.Len(t, got, 1)
+	require.Contains(t, got, servicesGVR)
+
+	require.Empty(t, f.InformersForGroup("apps"))
+
+	// the snapshot is not affected by later changes
+	got[widgetsGVR] = nil
+	require.Len(t, f.InformersForGroup(""), 1)
+}
+
+func TestInformerForResourceAfterShutdown(t *testing.T) {
+	disco := &fakeClusterDiscovery{}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, newFakeDynamicClient(), nil, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.StartPolling(ctx)
+
+	_, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	cancel()
+
+	// the informers are torn down asynchronously
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		_, err := f.InformerForResource(widgetsGVR)
+		return err != nil, nil
+	}))
+
+	for _, gvr := range []schema.GroupVersionResource{servicesGVR, widgetsGVR} {
+		_, err := f.InformerForResource(gvr)
+		require.ErrorIs(t, err, ErrFactoryTerminating)
+	}
+}
+
+func TestBaseContext(t *testing.T) {
+	for _, polling := range []bool{false, true} {
+		t.Run(fmt.Sprintf("polling=%t", polling), func(t *testing.T) {
+			before := informerGoroutines()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), &fakeClusterDiscovery{}, newFakeDynamicClient(), nil, time.Minute, WithContext(ctx))
+
+			if polling {
+				// the polling is stopped by the base context, although its own context is not done.
+				f.StartPolling(context.Background())
+			} else {
+				// informers started by Start, i.e. outside of polling
+				infs, err := f.InformerForResources([]schema.GroupVersionResource{servicesGVR, widgetsGVR})
+				require.NoError(t, err)
+				f.Start(nil)
+				for gvr, inf := range infs {
+					require.True(t, cache.WaitForCacheSync(ctx.Done(), inf.Informer().HasSynced), "informer for %s not synced", gvr)
+				}
+			}
+
+			cancel()
+
+			require.NoError(t, wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+				_, err := f.InformerForResource(servicesGVR)
+				return err != nil, nil
+			}))
+			_, err := f.InformerForResource(servicesGVR)
+			require.ErrorIs(t, err, ErrFactoryTerminating)
+
+			// all goroutines of the factory and its informers end.
+			err = wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+				return informerGoroutines() <= before, nil
+			})
+			require.NoError(t, err, "goroutines leaked: %d before, %d after", before, informerGoroutines())
+		})
+	}
+}
+
+// informerGoroutines returns the number of goroutines running code of the factory or of
+// informers. Other goroutines, e.g. of pollers of other tests, are ignored.
+func informerGoroutines() int {
+	buf := make([]byte, 1<<20)
+	for {
+		n := goruntime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var count int
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(stack, "k8s.io/client-go/tools/cache.") || strings.Contains(stack, "pkg/informer.(*DynamicDiscoverySharedInformerFactory)") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestInformerForResources(t *testing.T) {
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, newFakeDynamicClient(), nil, time.Minute)
+
+	services, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+
+	infs, err := f.InformerForResources([]schema.GroupVersionResource{servicesGVR, widgetsGVR})
+	require.NoError(t, err)
+	require.Len(t, infs, 2)
+	require.Same(t, services, infs[servicesGVR], "existing informers must be reused")
+
+	widgets, err := f.InformerForResource(widgetsGVR)
+	require.NoError(t, err)
+	require.Same(t, widgets, infs[widgetsGVR])
+
+	f.mu.Lock()
+	f.terminating = true
+	f.mu.Unlock()
+	infs, err = f.InformerForResources([]schema.GroupVersionResource{servicesGVR})
+	require.ErrorIs(t, err, ErrFactoryTerminating)
+	require.Empty(t, infs)
+}
+
+func benchmarkGVRs(n int) []schema.GroupVersionResource {
+	gvrs := make([]schema.GroupVersionResource, 0, n)
+	for i := 0; i < n; i++ {
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: fmt.Sprintf("widgets%d", i)})
+	}
+	return gvrs
+}
+
+func BenchmarkInformerForResource(b *testing.B) {
+	gvrs := benchmarkGVRs(100)
+	client := newFakeDynamicClient()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+		for _, gvr := range gvrs {
+			if _, err := f.InformerForResource(gvr); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkInformerForResources(b *testing.B) {
+	gvrs := benchmarkGVRs(100)
+	client := newFakeDynamicClient()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+		if _, err := f.InformerForResources(gvrs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMaxInformers(t *testing.T) {
+	gizmosGVR := schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "gizmos"}
+	thingsGVR := schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "things"}
+	resources := func(names ...string) []*metav1.APIResourceList {
+		var core, example []metav1.APIResource
+		for _, name := range names {
+			resource := metav1.APIResource{Name: name, Namespaced: true, Verbs: []string{"list", "watch"}}
+			if name == "services" {
+				core = append(core, resource)
+			} else {
+				example = append(example, resource)
+			}
+		}
+		return []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: core},
+			{GroupVersion: "example.io/v1", APIResources: example},
+		}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			servicesGVR: "ServiceList",
+			widgetsGVR:  "WidgetList",
+			gizmosGVR:   "GizmoList",
+			thingsGVR:   "ThingList",
+		},
+	)
+	ctx := context.Background()
+
+	disco := &fakeClusterDiscovery{resources: resources("services", "gizmos")}
+	f := NewDynamicDiscoverySharedInformerFactory(newWorkspaceLister(t), disco, client, nil, time.Minute,
+		WithMaxInformers(3, servicesGVR),
+	)
+	defer f.shutdown()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f.clock = fakeClock
+
+	evictions := func() float64 {
+		t.Helper()
+		value, err := testutil.GetCounterMetricValue(informerEvictionsTotal.WithLabelValues(gizmosGVR.Group, gizmosGVR.Resource))
+		require.NoError(t, err)
+		return value
+	}
+	evictionsBefore := evictions()
+
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 2)
+
+	t.Log("Widgets are added below the cap")
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	disco.resources = resources("services", "gizmos", "widgets")
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 3)
+
+	t.Log("Things evict gizmos, the least recently active, but not the pinned services")
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	disco.resources = resources("services", "gizmos", "widgets", "things")
+	require.NoError(t, f.discoverTypes(ctx))
+	require.Len(t, f.informers, 3)
+	require.Contains(t, f.informers, servicesGVR)
+	require.Contains(t, f.informers, widgetsGVR)
+	require.Contains(t, f.informers, thingsGVR)
+	require.NotContains(t, f.informers, gizmosGVR)
+	require.Equal(t, evictionsBefore+1, evictions())
+
+	t.Log("Evicted gizmos are not added back by discovery")
+	require.NoError(t, f.discoverTypes(ctx))
+	require.NotContains(t, f.informers, gizmosGVR)
+
+	t.Log("Only pinned informers cannot be evicted")
+	f.mu.Lock()
+	require.False(t, f.evictInformerLockHeld(map[schema.GroupVersionResource]struct{}{widgetsGVR: {}, thingsGVR: {}}))
+	f.mu.Unlock()
+	require.Contains(t, f.informers, servicesGVR)
+
+	t.Log("Evicted gizmos are recreated on request")
+	_, err := f.InformerForResource(gizmosGVR)
+	require.NoError(t, err)
+	require.Contains(t, f.informers, gizmosGVR)
+}
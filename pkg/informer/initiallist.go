@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WithInitialListDoneHandler calls fn once per informer, as soon as all objects of its initial list
+// have been delivered to the event handlers as add events. This is later than the informer being
+// synced, which only means that the initial list is in its store, e.g. for handler-side bookkeeping
+// that must know that it has seen every object. fn is called from a background goroutine or from the
+// event delivery of the informer, and must not block. Informers recreated by RecreateInformer or
+// after eviction call fn again.
+func WithInitialListDoneHandler(fn func(gvr schema.GroupVersionResource)) DynamicDiscoverySharedInformerOption {
+	return func(factory *DynamicDiscoverySharedInformerFactory) *DynamicDiscoverySharedInformerFactory {
+		factory.initialListDone = fn
+		return factory
+	}
+}
+
+// initialListTracker calls done once all objects of the initial list of an informer have been
+// delivered to the event handlers.
+type initialListTracker struct {
+	lock sync.Mutex
+	// delivered are the keys of the objects delivered before the informer synced.
+	delivered map[string]struct{}
+	// pending are the keys of the objects of the initial list not delivered yet. It is nil
+	// until the informer has synced.
+	pending map[string]struct{}
+	// done is called once, and set to nil afterwards.
+	done func()
+}
+
+func newInitialListTracker(done func()) *initialListTracker {
+	return &initialListTracker{
+		delivered: map[string]struct{}{},
+		done:      done,
+	}
+}
+
+// onAdd records the delivery of obj to the event handlers.
+func (t *initialListTracker) onAdd(obj interface{}) {
+	t.lock.Lock()
+	var done func()
+	if t.done != nil {
+		key := initialListKey(obj)
+		if t.pending == nil {
+			t.delivered[key] = struct{}{}
+		} else {
+			delete(t.pending, key)
+			done = t.finishLockHeld()
+		}
+	}
+	t.lock.Unlock()
+
+	if done != nil {
+		done()
+	}
+}
+
+// synced records the keys of the objects of the initial list, i.e. in the store when the
+// informer synced.
+func (t *initialListTracker) synced(keys []string) {
+	t.lock.Lock()
+	var done func()
+	if t.done != nil {
+		t.pending = make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			if _, ok := t.delivered[key]; !ok {
+				t.pending[key] = struct{}{}
+			}
+		}
+		t.delivered = nil
+		done = t.finishLockHeld()
+	}
+	t.lock.Unlock()
+
+	if done != nil {
+		done()
+	}
+}
+
+// finishLockHeld returns the done func to call if nothing is pending anymore, and nil otherwise.
+func (t *initialListTracker) finishLockHeld() func() {
+	if len(t.pending) > 0 {
+		return nil
+	}
+	done := t.done
+	t.done = nil
+	return done
+}
+
+// initialListKey returns the key of obj across logical clusters.
+func initialListKey(obj interface{}) string {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return ""
+	}
+	return clusterNameFrom(obj).String() + "|" + key
+}
+
+// trackInitialList waits for inf to sync, and passes the keys of the objects of its initial list
+// that pass the filter of the factory to tracker. It gives up when the informer is replaced or
+// the factory shuts down.
+func (d *DynamicDiscoverySharedInformerFactory) trackInitialList(gvr schema.GroupVersionResource, inf informers.GenericInformer, tracker *initialListTracker) {
+	var keys []string
+	var synced bool
+	_ = wait.PollImmediateInfinite(notifySyncedPollInterval, func() (bool, error) {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+
+		if d.terminating || d.informers[gvr] != inf {
+			return true, nil
+		}
+		if !inf.Informer().HasSynced() {
+			return false, nil
+		}
+		for _, obj := range inf.Informer().GetStore().List() {
+			if d.filter(obj) {
+				keys = append(keys, initialListKey(obj))
+			}
+		}
+		synced = true
+		return true, nil
+	})
+	if synced {
+		tracker.synced(keys)
+	}
+}
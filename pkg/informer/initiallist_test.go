@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestInitialListTracker(t *testing.T) {
+	foo, bar := newService("default", "foo"), newService("default", "bar")
+
+	t.Run("objects delivered before the sync count", func(t *testing.T) {
+		var calls int
+		tracker := newInitialListTracker(func() { calls++ })
+		tracker.onAdd(foo)
+		tracker.onAdd(bar)
+		require.Zero(t, calls)
+		tracker.synced([]string{initialListKey(foo), initialListKey(bar)})
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("objects delivered after the sync", func(t *testing.T) {
+		var calls int
+		tracker := newInitialListTracker(func() { calls++ })
+		tracker.onAdd(foo)
+		tracker.synced([]string{initialListKey(foo), initialListKey(bar)})
+		require.Zero(t, calls)
+		tracker.onAdd(bar)
+		require.Equal(t, 1, calls)
+		tracker.onAdd(newService("default", "baz"))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("empty initial list", func(t *testing.T) {
+		var calls int
+		tracker := newInitialListTracker(func() { calls++ })
+		tracker.synced(nil)
+		require.Equal(t, 1, calls)
+		tracker.onAdd(foo)
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestInitialListDoneHandler(t *testing.T) {
+	client := newFakeDynamicClient(
+		newService("default", "a"),
+		newService("default", "b"),
+		newService("default", "c"),
+	)
+
+	// adds are held back until the informer has synced, such that it syncs before the initial
+	// list is delivered.
+	release := make(chan struct{})
+
+	var lock sync.Mutex
+	var done []schema.GroupVersionResource
+	var addsWhenDone int64
+	var adds int64
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute,
+		WithInitialListDoneHandler(func(gvr schema.GroupVersionResource) {
+			lock.Lock()
+			defer lock.Unlock()
+			done = append(done, gvr)
+			addsWhenDone = atomic.LoadInt64(&adds)
+		}),
+	)
+	f.AddEventHandler(GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			<-release
+			atomic.AddInt64(&adds, 1)
+		},
+	})
+
+	inf, err := f.InformerForResource(servicesGVR)
+	require.NoError(t, err)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Informer().Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, inf.Informer().HasSynced))
+	lock.Lock()
+	require.Empty(t, done, "expected no notification before the initial list is delivered")
+	lock.Unlock()
+	close(release)
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(done) > 0
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	t.Log("Objects added later do not fire again")
+	_, err = client.Resource(servicesGVR).Namespace("default").Create(context.Background(), newService("default", "d"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&adds) == 4
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []schema.GroupVersionResource{servicesGVR}, done)
+	require.Equal(t, int64(3), addsWhenDone, "expected all initial objects to be delivered first")
+}
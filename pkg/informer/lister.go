@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+)
+
+// ClientBackedLister returns a lister for gvr which serves from the cache of the informer for gvr
+// when it is synced, and falls back to listing from the API server with the dynamic client of the
+// factory otherwise, e.g. while the informer warms up or before it is created. It never creates
+// an informer.
+//
+// Consistency trade-off: reads served by the fallback are live, i.e. they may observe newer
+// objects than the cache later serves, and successive reads may switch between the two
+// sources once the informer syncs. Every fallback read is a request to the API server, and Get
+// lists by a metadata.name field selector across all logical clusters to match cluster-aware
+// keys. Hence the fallback is meant for read paths which must work during warmup, not for hot
+// loops.
+//
+// Both sources apply the list options tweaks, the filter and the excluded namespaces of the
+// factory, such that the fallback never returns objects which the informer would not serve.
+func (d *DynamicDiscoverySharedInformerFactory) ClientBackedLister(gvr schema.GroupVersionResource) cache.GenericLister {
+	return &clientBackedLister{factory: d, gvr: d.canonicalGVR(gvr)}
+}
+
+// clientBackedLister implements cache.GenericLister with a fallback to the dynamic client.
+type clientBackedLister struct {
+	factory *DynamicDiscoverySharedInformerFactory
+	gvr     schema.GroupVersionResource
+}
+
+var _ cache.GenericLister = &clientBackedLister{}
+
+func (l *clientBackedLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	if lister, synced := l.syncedLister(); synced {
+		objs, err := lister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		return l.filterList(objs), nil
+	}
+	return l.listLive("", selector)
+}
+
+func (l *clientBackedLister) Get(name string) (runtime.Object, error) {
+	if lister, synced := l.syncedLister(); synced {
+		obj, err := lister.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return l.filterGet(name, obj)
+	}
+	return l.getLive("", name)
+}
+
+func (l *clientBackedLister) ByNamespace(namespace string) cache.GenericNamespaceLister {
+	return &clientBackedNamespaceLister{lister: l, namespace: namespace}
+}
+
+// syncedLister returns the lister of the informer for the resource if it is synced.
+func (l *clientBackedLister) syncedLister() (cache.GenericLister, bool) {
+	d := l.factory
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.terminating {
+		return nil, false
+	}
+	inf, ok := d.informers[l.gvr]
+	if !ok || !inf.Informer().HasSynced() {
+		return nil, false
+	}
+	return inf.Lister(), true
+}
+
+func (l *clientBackedLister) resource(namespace string) dynamic.ResourceInterface {
+	if namespace != "" {
+		return l.factory.dynamicClient.Resource(l.gvr).Namespace(namespace)
+	}
+	return l.factory.dynamicClient.Resource(l.gvr)
+}
+
+// filterList drops the objects rejected by the filter of the factory.
+func (l *clientBackedLister) filterList(objs []runtime.Object) []runtime.Object {
+	ret := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		if l.factory.filter(obj) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+// filterGet returns a not found error for key if obj is rejected by the filter of the factory.
+func (l *clientBackedLister) filterGet(key string, obj runtime.Object) (runtime.Object, error) {
+	if !l.factory.filter(obj) {
+		return nil, apierrors.NewNotFound(l.gvr.GroupResource(), key)
+	}
+	return obj, nil
+}
+
+// listOptions returns the list options of the informer for the resource, with the given
+// label and field selectors added.
+func (l *clientBackedLister) listOptions(labelSelector, fieldSelector string) metav1.ListOptions {
+	var options metav1.ListOptions
+	if tweak := l.factory.listOptionsTweakerFor(l.gvr); tweak != nil {
+		tweak(&options)
+	}
+	options.LabelSelector = joinSelectors(options.LabelSelector, labelSelector)
+	options.FieldSelector = joinSelectors(options.FieldSelector, fieldSelector)
+	return options
+}
+
+// joinSelectors returns the conjunction of the given label or field selectors.
+func joinSelectors(selectors ...string) string {
+	var nonEmpty []string
+	for _, selector := range selectors {
+		if selector != "" {
+			nonEmpty = append(nonEmpty, selector)
+		}
+	}
+	return strings.Join(nonEmpty, ",")
+}
+
+func (l *clientBackedLister) listLive(namespace string, selector labels.Selector) ([]runtime.Object, error) {
+	list, err := l.resource(namespace).List(l.factory.ctx, l.listOptions(selector.String(), ""))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]runtime.Object, 0, len(list.Items))
+	for i := range list.Items {
+		if selector.Matches(labels.Set(list.Items[i].GetLabels())) && l.factory.filter(&list.Items[i]) {
+			ret = append(ret, &list.Items[i])
+		}
+	}
+	return ret, nil
+}
+
+// getLive returns the object with the given name, which may be a cluster-aware key as built by
+// clusters.ToClusterAwareKey. Without a logical cluster in the key, the object of any logical
+// cluster is returned.
+func (l *clientBackedLister) getLive(namespace, key string) (runtime.Object, error) {
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	list, err := l.resource(namespace).List(l.factory.ctx, l.listOptions("", fields.OneTermEqualSelector("metadata.name", name).String()))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if obj.GetName() != name || (namespace != "" && obj.GetNamespace() != namespace) {
+			continue
+		}
+		if !clusterName.Empty() && logicalcluster.From(obj) != clusterName {
+			continue
+		}
+		if !l.factory.filter(obj) {
+			continue
+		}
+		return obj, nil
+	}
+	return nil, apierrors.NewNotFound(l.gvr.GroupResource(), key)
+}
+
+// clientBackedNamespaceLister implements cache.GenericNamespaceLister with a fallback to the
+// dynamic client.
+type clientBackedNamespaceLister struct {
+	lister    *clientBackedLister
+	namespace string
+}
+
+var _ cache.GenericNamespaceLister = &clientBackedNamespaceLister{}
+
+func (l *clientBackedNamespaceLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	if lister, synced := l.lister.syncedLister(); synced {
+		objs, err := lister.ByNamespace(l.namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		return l.lister.filterList(objs), nil
+	}
+	return l.lister.listLive(l.namespace, selector)
+}
+
+func (l *clientBackedNamespaceLister) Get(name string) (runtime.Object, error) {
+	if lister, synced := l.lister.syncedLister(); synced {
+		obj, err := lister.ByNamespace(l.namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return l.lister.filterGet(name, obj)
+	}
+	return l.lister.getLive(l.namespace, name)
+}
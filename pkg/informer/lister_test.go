@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+)
+
+func TestClientBackedLister(t *testing.T) {
+	foo := newService("default", "foo")
+	foo.SetClusterName("root:org:ws")
+	client := newFakeDynamicClient(foo, newService("default", "bar"), newService("other", "baz"))
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+	defer f.shutdown()
+
+	lister := f.ClientBackedLister(servicesGVR)
+	liveLists := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" && action.GetResource() == servicesGVR {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Log("Without a synced informer, reads fall back to the client")
+	objs, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+	objs, err = lister.ByNamespace("default").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	obj, err := lister.ByNamespace("default").Get(clusters.ToClusterAwareKey(logicalcluster.New("root:org:ws"), "foo"))
+	require.NoError(t, err)
+	require.Equal(t, "foo", obj.(*unstructured.Unstructured).GetName())
+	_, err = lister.ByNamespace("default").Get(clusters.ToClusterAwareKey(logicalcluster.New("root:org:other"), "foo"))
+	require.True(t, apierrors.IsNotFound(err), "expected a not found error, got %v", err)
+	_, err = lister.ByNamespace("other").Get("foo")
+	require.True(t, apierrors.IsNotFound(err), "expected a not found error, got %v", err)
+	require.Equal(t, 5, liveLists())
+
+	t.Log("With a synced informer, reads are served from the cache")
+	require.NoError(t, startAndSync(f, servicesGVR))
+	listsAfterSync := liveLists()
+	objs, err = lister.List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+	objs, err = lister.ByNamespace("other").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	_, err = lister.ByNamespace("other").Get("baz")
+	require.NoError(t, err)
+	require.Equal(t, listsAfterSync, liveLists(), "expected no requests to the API server")
+}
+
+func TestClientBackedListerFilters(t *testing.T) {
+	withLabels := func(obj *unstructured.Unstructured, l map[string]string) *unstructured.Unstructured {
+		obj.SetLabels(l)
+		return obj
+	}
+	client := newFakeDynamicClient(
+		withLabels(newService("default", "foo"), map[string]string{"app": "kcp", "tier": "web"}),
+		withLabels(newService("default", "filtered"), map[string]string{"app": "kcp"}),
+		newService("default", "untweaked"),
+		withLabels(newService("excluded", "bar"), map[string]string{"app": "kcp"}),
+	)
+
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client,
+		func(obj interface{}) bool {
+			return obj.(*unstructured.Unstructured).GetName() != "filtered"
+		},
+		time.Minute,
+		WithExcludeNamespaces("excluded"),
+		WithTweakListOptions(func(gvr schema.GroupVersionResource, options *metav1.ListOptions) {
+			options.LabelSelector = "app=kcp"
+		}),
+	)
+	defer f.shutdown()
+	lister := f.ClientBackedLister(servicesGVR)
+
+	type reads struct {
+		all, defaultNamespace, web []string
+		got                        map[string]bool
+	}
+	read := func() reads {
+		names := func(objs []runtime.Object, err error) []string {
+			require.NoError(t, err)
+			var ret []string
+			for _, obj := range objs {
+				ret = append(ret, obj.(*unstructured.Unstructured).GetName())
+			}
+			sort.Strings(ret)
+			return ret
+		}
+		r := reads{
+			all:              names(lister.List(labels.Everything())),
+			defaultNamespace: names(lister.ByNamespace("default").List(labels.Everything())),
+			web:              names(lister.List(labels.SelectorFromSet(labels.Set{"tier": "web"}))),
+			got:              map[string]bool{},
+		}
+		for _, key := range []string{"default/foo", "default/filtered", "default/untweaked", "excluded/bar"} {
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			require.NoError(t, err)
+			_, err = lister.ByNamespace(namespace).Get(name)
+			require.True(t, err == nil || apierrors.IsNotFound(err), "unexpected error: %v", err)
+			r.got[key] = err == nil
+		}
+		return r
+	}
+
+	t.Log("Without a synced informer, the fallback applies the tweaks and filters")
+	live := read()
+	require.Equal(t, []string{"foo"}, live.all)
+	require.Equal(t, []string{"foo"}, live.defaultNamespace)
+	require.Equal(t, []string{"foo"}, live.web)
+	require.Equal(t, map[string]bool{"default/foo": true, "default/filtered": false, "default/untweaked": false, "excluded/bar": false}, live.got)
+
+	t.Log("The cache serves the same objects")
+	require.NoError(t, startAndSync(f, servicesGVR))
+	require.Equal(t, live, read())
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// ResourceStats describes the objects cached by one or more informers.
+type ResourceStats struct {
+	// Objects is the number of objects in the informer stores.
+	Objects int
+	// EstimatedBytes estimates the memory held by the objects in the informer stores by
+	// the size of their JSON serialization. The in-memory representation of unstructured
+	// objects is usually larger, but grows roughly proportionally.
+	EstimatedBytes int64
+}
+
+// FactoryStats describes the objects cached by the informers of a DynamicDiscoverySharedInformerFactory.
+type FactoryStats struct {
+	// Resources holds the stats of the informer of each resource.
+	Resources map[schema.GroupVersionResource]ResourceStats
+	// Total aggregates the stats of all informers.
+	Total ResourceStats
+}
+
+// Stats returns the number of objects and an estimate of the bytes cached by each informer of the
+// factory, and their aggregate. It serializes every cached object, hence it is meant for capacity
+// planning and debugging, not to be called in a hot path.
+func (d *DynamicDiscoverySharedInformerFactory) Stats() FactoryStats {
+	d.mu.RLock()
+	infs := make(map[schema.GroupVersionResource]informers.GenericInformer, len(d.informers))
+	for gvr, inf := range d.informers {
+		infs[gvr] = inf
+	}
+	d.mu.RUnlock()
+
+	stats := FactoryStats{
+		Resources: make(map[schema.GroupVersionResource]ResourceStats, len(infs)),
+	}
+	for gvr, inf := range infs {
+		var resourceStats ResourceStats
+		for _, obj := range inf.Informer().GetStore().List() {
+			resourceStats.Objects++
+			if bs, err := json.Marshal(obj); err == nil {
+				resourceStats.EstimatedBytes += int64(len(bs))
+			}
+		}
+		stats.Resources[gvr] = resourceStats
+		stats.Total.Objects += resourceStats.Objects
+		stats.Total.EstimatedBytes += resourceStats.EstimatedBytes
+	}
+	return stats
+}
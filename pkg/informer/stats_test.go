@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newObject(gvk schema.GroupVersionKind, namespace, name string, dataSize int) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+		},
+		"spec": map[string]interface{}{
+			"data": strings.Repeat("x", dataSize),
+		},
+	}}
+}
+
+// startAndSync creates and starts the informers for gvrs and waits for them to sync.
+func startAndSync(f *DynamicDiscoverySharedInformerFactory, gvrs ...schema.GroupVersionResource) error {
+	infs, err := f.InformerForResources(gvrs)
+	if err != nil {
+		return err
+	}
+	f.Start(nil)
+	var synced []cache.InformerSynced
+	for _, inf := range infs {
+		synced = append(synced, inf.Informer().HasSynced)
+	}
+	if !cache.WaitForCacheSync(wait.NeverStop, synced...) {
+		return fmt.Errorf("informers did not sync")
+	}
+	return nil
+}
+
+func TestStats(t *testing.T) {
+	client := newFakeDynamicClient(
+		newObject(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "default", "foo", 10),
+		newObject(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "default", "bar", 10),
+		newObject(schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}, "default", "baz", 1000),
+	)
+	f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute)
+	defer f.shutdown()
+
+	require.Empty(t, f.Stats().Resources)
+
+	require.NoError(t, startAndSync(f, servicesGVR, widgetsGVR))
+
+	stats := f.Stats()
+	require.Len(t, stats.Resources, 2)
+	require.Equal(t, 2, stats.Resources[servicesGVR].Objects)
+	require.Equal(t, 1, stats.Resources[widgetsGVR].Objects)
+	require.Greater(t, stats.Resources[servicesGVR].EstimatedBytes, int64(2*10))
+	require.Greater(t, stats.Resources[widgetsGVR].EstimatedBytes, int64(1000))
+	require.Greater(t, stats.Resources[widgetsGVR].EstimatedBytes, stats.Resources[servicesGVR].EstimatedBytes)
+	require.Equal(t, ResourceStats{
+		Objects:        3,
+		EstimatedBytes: stats.Resources[servicesGVR].EstimatedBytes + stats.Resources[widgetsGVR].EstimatedBytes,
+	}, stats.Total)
+}
+
+// BenchmarkInformerMemory creates informers for many resources with synthetic objects, and reports
+// the heap growth per factory next to the estimate of Stats.
+func BenchmarkInformerMemory(b *testing.B) {
+	const resources = 20
+	for _, objects := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("resources=%d,objects=%d", resources, objects), func(b *testing.B) {
+			listKinds := map[schema.GroupVersionResource]string{}
+			var gvrs []schema.GroupVersionResource
+			var objs []runtime.Object
+			for i := 0; i < resources; i++ {
+				gvk := schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: fmt.Sprintf("Gadget%d", i)}
+				gvr := schema.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: fmt.Sprintf("gadget%ds", i)}
+				listKinds[gvr] = gvk.Kind + "List"
+				gvrs = append(gvrs, gvr)
+				for j := 0; j < objects; j++ {
+					objs = append(objs, newObject(gvk, fmt.Sprintf("ns-%d", j%10), fmt.Sprintf("gadget-%d", j), 256))
+				}
+			}
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+
+			var heapBytes, estimatedBytes int64
+			var before, after goruntime.MemStats
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				goruntime.GC()
+				goruntime.ReadMemStats(&before)
+
+				f := NewDynamicDiscoverySharedInformerFactory(nil, nil, client, nil, time.Minute, WithLogger(logr.Discard()))
+				if err := startAndSync(f, gvrs...); err != nil {
+					b.Fatal(err)
+				}
+
+				goruntime.GC()
+				goruntime.ReadMemStats(&after)
+				heapBytes += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+
+				b.StopTimer()
+				stats := f.Stats()
+				if stats.Total.Objects != resources*objects {
+					b.Fatalf("expected %d objects, got %d", resources*objects, stats.Total.Objects)
+				}
+				estimatedBytes += stats.Total.EstimatedBytes
+				f.shutdown()
+				b.StartTimer()
+			}
+
+			b.ReportMetric(float64(heapBytes)/float64(b.N), "heap-bytes/op")
+			b.ReportMetric(float64(estimatedBytes)/float64(b.N), "estimated-bytes/op")
+		})
+	}
+}
@@ -75,9 +75,11 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationSpec":                          schema_pkg_apis_scheduling_v1alpha1_LocationSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationStatus":                        schema_pkg_apis_scheduling_v1alpha1_LocationStatus(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.Placement":                             schema_pkg_apis_scheduling_v1alpha1_Placement(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementAffinity":                     schema_pkg_apis_scheduling_v1alpha1_PlacementAffinity(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementList":                         schema_pkg_apis_scheduling_v1alpha1_PlacementList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementSpec":                         schema_pkg_apis_scheduling_v1alpha1_PlacementSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementStatus":                       schema_pkg_apis_scheduling_v1alpha1_PlacementStatus(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.RebalancePolicy":                       schema_pkg_apis_scheduling_v1alpha1_RebalancePolicy(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspace":                         schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspace(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceList":                     schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceLocation":                 schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceLocation(ref),
@@ -101,6 +103,11 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1.WorkspaceSpec":                             schema_pkg_apis_tenancy_v1beta1_WorkspaceSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1.WorkspaceStatus":                           schema_pkg_apis_tenancy_v1beta1_WorkspaceStatus(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition": schema_conditions_apis_conditions_v1alpha1_Condition(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ConditionTransition":                     schema_pkg_apis_workload_v1alpha1_ConditionTransition(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.Endpoint":                                schema_pkg_apis_workload_v1alpha1_Endpoint(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.MaintenanceWindow":                       schema_pkg_apis_workload_v1alpha1_MaintenanceWindow(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.NamespaceCollision":                      schema_pkg_apis_workload_v1alpha1_NamespaceCollision(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceSyncStatus":                      schema_pkg_apis_workload_v1alpha1_ResourceSyncStatus(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTarget":                              schema_pkg_apis_workload_v1alpha1_SyncTarget(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTargetList":                          schema_pkg_apis_workload_v1alpha1_SyncTargetList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTargetSpec":                          schema_pkg_apis_workload_v1alpha1_SyncTargetSpec(ref),
@@ -2348,6 +2355,35 @@ func schema_pkg_apis_scheduling_v1alpha1_Placement(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_scheduling_v1alpha1_PlacementAffinity(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PlacementAffinity describes the co-location of the namespaces of a placement with those of another placement.\n\nNamespaces are preferably scheduled to the sync targets the namespaces of the referenced placement are scheduled to. The location selected by the referenced placement is preferred when selecting a location. Namespaces already scheduled to a sync target are not moved.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"placement": {
+						SchemaProps: spec.SchemaProps{
+							Description: "placement is the name of the referenced Placement in the same workspace.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"required": {
+						SchemaProps: spec.SchemaProps{
+							Description: "required makes namespaces only be scheduled to the sync targets of the referenced placement. If none of them is available, namespaces are not scheduled. By default, namespaces are scheduled to other sync targets in that case.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"placement"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_scheduling_v1alpha1_PlacementList(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2437,12 +2473,39 @@ func schema_pkg_apis_scheduling_v1alpha1_PlacementSpec(ref common.ReferenceCallb
 							Format:      "",
 						},
 					},
+					"rebalance": {
+						SchemaProps: spec.SchemaProps{
+							Description: "rebalance enables the periodic rebalancing of the namespaces bound to this placement over the sync targets of the selected location, e.g. after sync targets have been added. If unset, namespaces stay on the sync target they are scheduled to.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.RebalancePolicy"),
+						},
+					},
+					"placementAffinity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "placementAffinity co-locates the namespaces of this placement with the namespaces of another placement in the same workspace, e.g. for data locality. If unset, the sync targets of this placement are chosen independently of other placements.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementAffinity"),
+						},
+					},
+					"requiredCapabilities": {
+						SchemaProps: spec.SchemaProps{
+							Description: "requiredCapabilities are the capabilities a sync target must report in its status.capabilities to get the namespaces of this placement, e.g. storageclass/<name> or gpu. Namespaces are removed from sync targets which stop reporting any of them.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"locationResource"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementAffinity", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.RebalancePolicy", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -2465,6 +2528,34 @@ func schema_pkg_apis_scheduling_v1alpha1_PlacementStatus(ref common.ReferenceCal
 							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationReference"),
 						},
 					},
+					"lastRebalanceTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "lastRebalanceTime is the time namespaces were last moved to another sync target by rebalancing according to spec.rebalance.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"boundNamespaces": {
+						SchemaProps: spec.SchemaProps{
+							Description: "boundNamespaces lists the names of the namespaces bound to this placement in alphabetical order. For large numbers of namespaces, only the first MaxBoundNamespaces are listed, while boundNamespaceCount holds the total.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"boundNamespaceCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "boundNamespaceCount is the total number of namespaces bound to this placement.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"conditions": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Current processing state of the Placement.",
@@ -2483,7 +2574,37 @@ func schema_pkg_apis_scheduling_v1alpha1_PlacementStatus(ref common.ReferenceCal
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationReference", "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition"},
+			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationReference", "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_scheduling_v1alpha1_RebalancePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RebalancePolicy describes how namespaces are moved between the sync targets of a location.\n\nEvery interval, the namespaces of the most loaded sync target, relative to its allocatable cpu if all sync targets report it, are moved to the least loaded one, as long as this improves the balance. Sync targets which are not ready, unschedulable, evicting or in their reschedule cooldown do not get namespaces moved to them.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"interval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "interval is the minimum time between two rebalancings moving namespaces.",
+							Default:     0,
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"maxNamespacesPerInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "maxNamespacesPerInterval is the maximum number of namespaces moved to another sync target per interval.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"interval"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -3518,6 +3639,212 @@ func schema_conditions_apis_conditions_v1alpha1_Condition(ref common.ReferenceCa
 	}
 }
 
+func schema_pkg_apis_workload_v1alpha1_ConditionTransition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ConditionTransition is a change of the status of a condition of a SyncTarget.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type is the type of the condition.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"from": {
+						SchemaProps: spec.SchemaProps{
+							Description: "From is the status of the condition before the transition. It is empty for the first recorded status of the condition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"to": {
+						SchemaProps: spec.SchemaProps{
+							Description: "To is the status of the condition after the transition.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"timestamp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timestamp is the time of the transition.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is the reason of the condition after the transition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "to", "timestamp"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_Endpoint(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the URL of the API endpoint of the downstream cluster.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_MaintenanceWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "MaintenanceWindow is a recurring period of time.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule is the start of the window in cron format, i.e. five fields for the minute, hour, day of month, month and day of week, evaluated in UTC. For example, \"0 2 * * 6\" starts the window every Saturday at 02:00 UTC.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration is the length of the window.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+				Required: []string{"schedule", "duration"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_NamespaceCollision(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NamespaceCollision is an upstream namespace which is not synced because its downstream namespace belongs to another upstream namespace.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"downstreamNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DownstreamNamespace is the namespace in the downstream cluster.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"workspace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Workspace is the workspace of the upstream namespace which is not synced.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace is the upstream namespace which is not synced.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message describes the collision, e.g. the upstream namespace the downstream namespace belongs to.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"downstreamNamespace", "namespace"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_ResourceSyncStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ResourceSyncStatus describes the sync health of a resource.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"group": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Group is the API group of the resource, empty for the core group.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Version is the API version of the resource.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Resource is the name of the resource.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastSyncTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastSyncTime is the time the syncer last synced an object of the resource.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"errorCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ErrorCount is the number of failed syncs of objects of the resource since the last successful sync. It is zero for a healthy resource.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastError": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastError is the error of the last failed sync of an object of the resource. It is cleared by a successful sync.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"version", "resource"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_workload_v1alpha1_SyncTarget(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3637,11 +3964,98 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetSpec(ref common.ReferenceCallba
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"evictionGracePeriod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EvictionGracePeriod spreads the eviction of workloads after EvictAfter over the given duration instead of evicting all of them at once, in order to reduce the churn on the cluster. No new workloads are scheduled to the cluster after EvictAfter. By default, all workloads are evicted at once.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"namespaceSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceSelector restricts the namespaces whose workloads can be scheduled to this SyncTarget. Only namespaces whose labels match the selector are scheduled to it. A nil or empty selector accepts all namespaces.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"minSyncedResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinSyncedResources is the minimum number of resources in status.syncedResources for the SyncTarget to become Ready. By default, no resources are required.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rescheduleCooldown": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RescheduleCooldown is the duration after the SyncTarget returns to Ready, after having been not Ready, during which no new workloads are scheduled to it. This prevents workloads from flapping between clusters when the SyncTarget oscillates between ready and unreachable. By default, workloads are scheduled to the SyncTarget as soon as it is Ready.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"maxImportedResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxImportedResources caps the number of resources the syncer imports from the cluster, in order to bound memory and import time. When more resources are available, the resources imported already are kept, the others are imported in alphabetical order up to the cap, and the APIImportComplete condition lists the skipped resources. By default, all resources are imported.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maintenanceWindows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceWindows are recurring periods during which the SyncTarget is cordoned automatically, i.e. no new workloads are scheduled to it as if spec.unschedulable was true. Workloads already scheduled to the SyncTarget are kept. During a window, the OutsideMaintenanceWindow condition is false.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.MaintenanceWindow"),
+									},
+								},
+							},
+						},
+					},
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Mode selects the direction of the SyncTarget. In Import mode, the syncer imports the APIs of the cluster into kcp for discovery, but no workloads are scheduled to the SyncTarget. In Sync mode, workloads are synced to the cluster, but its APIs are not imported as APIResourceImports. In Both mode, the default, APIs are imported and workloads are synced.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"syncerQPS": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerQPS limits the queries per second of the syncer to the cluster, in order to not overwhelm clusters with a small API server. It overrides the --qps flag of the syncer and is read when the syncer starts. By default, or if zero, the flag applies.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"syncerBurst": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerBurst limits the burst of queries of the syncer to the cluster. It overrides the --burst flag of the syncer and is read when the syncer starts. By default, or if zero, the flag applies.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"syncerImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerImage pins the container image of the syncer deployed for this SyncTarget, e.g. ghcr.io/kcp-dev/kcp/syncer:v0.8.0. The workload sync plugin uses it in the syncer deployment and rejects a different --syncer-image. By default, the image is taken from --syncer-image.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"supportedAPIExportSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SupportedAPIExportSelector selects the APIExports in the workspace of the SyncTarget whose APIs are synced to the cluster. Only the APIs of the matching APIExports are served to the syncer. An empty selector matches all APIExports. By default, only the APIs of the kubernetes APIExport are synced.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"namespaceMappingStrategy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceMappingStrategy selects how the syncer names the downstream namespaces of upstream namespaces. With Prefixed, the default, downstream namespaces are named kcp-<hash> of the workspace and name of the upstream namespace, such that namespaces of different workspaces do not collide. With Mirror, downstream namespaces have the name of the upstream namespace, which is meant for clusters synced from a single workspace. The strategy is read when the syncer starts, and status.namespaceMappingStrategy shows the one in effect.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.MaintenanceWindow", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -3696,6 +4110,20 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetStatus(ref common.ReferenceCall
 							},
 						},
 					},
+					"transitionHistory": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TransitionHistory lists the latest status changes of the conditions, oldest first. It is capped at 20 entries, dropping the oldest.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ConditionTransition"),
+									},
+								},
+							},
+						},
+					},
 					"syncedResources": {
 						SchemaProps: spec.SchemaProps{
 							Type: []string{"array"},
@@ -3710,12 +4138,81 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetStatus(ref common.ReferenceCall
 							},
 						},
 					},
+					"importedVersions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImportedVersions maps the synced resources, in the format <resource>.<group>, to the API version imported from the downstream cluster.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"kubernetesVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KubernetesVersion is the Kubernetes version of the downstream cluster as reported by the syncer, e.g. v1.24.3.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"lastSyncerHeartbeatTime": {
 						SchemaProps: spec.SchemaProps{
 							Description: "A timestamp indicating when the syncer last reported status.",
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"lastSyncTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastSyncTime is the time the syncer last synced an object between kcp and the downstream cluster. Together with lastSyncerHeartbeatTime, it tells a syncer that is connected but idle or stuck apart from one that is actively syncing.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"syncedObjectCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncedObjectCount is the number of objects the syncer synced between kcp and the downstream cluster since it was started.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"resourceSyncStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceSyncStatus reports the sync health of each resource the syncer has synced objects of, or failed to, since it was started, sorted by group, resource and version.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceSyncStatus"),
+									},
+								},
+							},
+						},
+					},
+					"evictionProgress": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EvictionProgress is the percentage of the eviction grace period passed since spec.evictAfter, i.e. approximately the percentage of workloads evicted from the cluster. It is only set after spec.evictAfter.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rescheduleCooldownUntil": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RescheduleCooldownUntil is the time until which no new workloads are scheduled to the SyncTarget after it returned to Ready, according to spec.rescheduleCooldown. It is unset when no cooldown is in effect.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"nextMaintenanceWindow": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NextMaintenanceWindow is the start of the next maintenance window of spec.maintenanceWindows that has not started yet.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
 					"virtualWorkspaces": {
 						SchemaProps: spec.SchemaProps{
 							Description: "VirtualWorkspaces contains all syncer virtual workspace URLs.",
@@ -3730,11 +4227,96 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetStatus(ref common.ReferenceCall
 							},
 						},
 					},
+					"endpoints": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Endpoints contains the API endpoints of the downstream cluster as reported by the syncer. Workloads can use them to reach the downstream cluster directly, not through kcp. In contrast, VirtualWorkspaces are served by kcp for the syncer to reach kcp.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.Endpoint"),
+									},
+								},
+							},
+						},
+					},
+					"supportedAPIExports": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SupportedAPIExports are the names of the APIExports whose APIs are synced to the cluster, resolved from spec.supportedAPIExportSelector, sorted by name.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"namespaceCollisions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceCollisions lists the upstream namespaces the syncer cannot sync because their downstream namespace belongs to another upstream namespace, as reported by the syncer, sorted by downstream namespace. Collisions are removed when the upstream namespace can be synced again.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.NamespaceCollision"),
+									},
+								},
+							},
+						},
+					},
+					"capabilities": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Capabilities are the capabilities of the cluster as reported by the syncer, sorted, i.e. storageclass/<name> for every storage class, and gpu if any node has allocatable GPUs. Placements only schedule namespaces to sync targets with all of their spec.requiredCapabilities.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"namespaceMappingStrategy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceMappingStrategy is the spec.namespaceMappingStrategy in effect, as reported by the syncer.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"syncGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncGeneration is the generation of the running syncer, advanced every time a syncer starts. The syncer labels the objects it syncs downstream with the generation in the workload.kcp.dev/sync-generation label, i.e. objects with another generation have not been synced by the running syncer and might be stale.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"syncerCertificateNotAfter": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerCertificateNotAfter is the expiry time of the client certificate the syncer uses to connect to kcp, as reported by the syncer. It is not set if the syncer does not authenticate with a client certificate.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"syncerCertificateDaysToExpiry": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerCertificateDaysToExpiry is the number of full days until the client certificate of the syncer expires, or 0 if it has expired.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.VirtualWorkspace", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ConditionTransition", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.Endpoint", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.NamespaceCollision", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceSyncStatus", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.VirtualWorkspace", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// clusterBlocklist rejects requests for the logical clusters listed in a file, one per line,
+// ignoring empty lines and lines starting with #. The file is re-read when it changed, checked
+// at most every reloadInterval, such that clusters can be quarantined without restarting the
+// proxy. A removed file unblocks all clusters.
+type clusterBlocklist struct {
+	filename       string
+	statusCode     int
+	message        string
+	reloadInterval time.Duration
+	clock          clock.PassiveClock
+
+	// lastCheck is the time of the last check of the file as Unix nanoseconds.
+	lastCheck int64
+	// clusters holds the blocked logical clusters as map[logicalcluster.Name]struct{}.
+	clusters atomic.Value
+
+	// lock serializes the reloads and protects the fields below.
+	lock    sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// newClusterBlocklist loads the blocklist in filename. Requests for blocked clusters are
+// rejected with the given status code and message.
+func newClusterBlocklist(filename string, statusCode int, message string, reloadInterval time.Duration, clock clock.PassiveClock) (*clusterBlocklist, error) {
+	b := &clusterBlocklist{
+		filename:       filename,
+		statusCode:     statusCode,
+		message:        message,
+		reloadInterval: reloadInterval,
+		clock:          clock,
+		lastCheck:      clock.Now().UnixNano(),
+	}
+	b.clusters.Store(map[logicalcluster.Name]struct{}{})
+	if err := b.reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// check returns an error to respond with if the logical cluster is blocked. A nil blocklist
+// blocks nothing.
+func (b *clusterBlocklist) check(req *http.Request, clusterName logicalcluster.Name) error {
+	if b == nil {
+		return nil
+	}
+
+	now := b.clock.Now().UnixNano()
+	if last := atomic.LoadInt64(&b.lastCheck); now-last >= int64(b.reloadInterval) && atomic.CompareAndSwapInt64(&b.lastCheck, last, now) {
+		if err := b.reload(); err != nil {
+			klog.Errorf("Failed to reload the cluster blocklist, keeping the previous one: %v", err)
+		}
+	}
+
+	if _, blocked := b.clusters.Load().(map[logicalcluster.Name]struct{})[clusterName]; !blocked {
+		return nil
+	}
+	// the generic response has the reason of the status code, but a canned message for some
+	err := apierrors.NewGenericServerResponse(b.statusCode, req.Method, schema.GroupResource{}, "", b.message, 0, false)
+	err.ErrStatus.Message = b.message
+	return err
+}
+
+// reload re-reads the file if it changed since the last reload.
+func (b *clusterBlocklist) reload() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	fi, err := os.Stat(b.filename)
+	if os.IsNotExist(err) {
+		if len(b.clusters.Load().(map[logicalcluster.Name]struct{})) > 0 {
+			klog.Infof("Cluster blocklist %s was removed, unblocking all clusters", b.filename)
+		}
+		b.clusters.Store(map[logicalcluster.Name]struct{}{})
+		b.modTime, b.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cluster blocklist %q: %w", b.filename, err)
+	}
+	if fi.ModTime().Equal(b.modTime) && fi.Size() == b.size {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(b.filename)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster blocklist %q: %w", b.filename, err)
+	}
+	clusters := map[logicalcluster.Name]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		clusters[logicalcluster.New(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to parse cluster blocklist %q: %w", b.filename, err)
+	}
+
+	klog.Infof("Loaded %d blocked clusters from %s", len(clusters), b.filename)
+	b.clusters.Store(clusters)
+	b.modTime, b.size = fi.ModTime(), fi.Size()
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestShardHandlerClusterBlocklist(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "blocklist")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("# abusive during incident 42\nroot:abusive\n\n"), 0600))
+
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	blocklist, err := newClusterBlocklist(filename, http.StatusServiceUnavailable, "the workspace is quarantined", 10*time.Second, clock)
+	require.NoError(t, err)
+
+	var proxied []string
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req.Header.Get(ClusterHeader))
+	})
+	handler := shardHandler(fakeIndex{
+		logicalcluster.New("root:abusive"): "https://shard-1.example.com:6443",
+		logicalcluster.New("root:org"):     "https://shard-1.example.com:6443",
+	}, &replicaSelector{}, nil, pathLimits{}, blocklist, proxy)
+
+	serve := func(clusterName string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/"+clusterName+"/api/v1/namespaces", nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("root:abusive")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Contains(t, w.Body.String(), "the workspace is quarantined")
+	w = serve("root:org")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"root:org"}, proxied)
+
+	t.Log("Changes are picked up after the reload interval")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("root:org\n"), 0600))
+	require.Equal(t, http.StatusServiceUnavailable, serve("root:abusive").Code, "expected no reload within the interval")
+	clock.SetTime(clock.Now().Add(11 * time.Second))
+	require.Equal(t, http.StatusServiceUnavailable, serve("root:org").Code)
+	require.Equal(t, http.StatusOK, serve("root:abusive").Code)
+
+	t.Log("Removing the file unblocks all clusters")
+	require.NoError(t, os.Remove(filename))
+	clock.SetTime(clock.Now().Add(11 * time.Second))
+	require.Equal(t, http.StatusOK, serve("root:org").Code)
+	require.Equal(t, []string{"root:org", "root:abusive", "root:org"}, proxied)
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+type circuitBreakerState int
+
+const (
+	circuitBreakerClosed circuitBreakerState = iota
+	circuitBreakerOpen
+	circuitBreakerHalfOpen
+)
+
+func (s circuitBreakerState) String() string {
+	switch s {
+	case circuitBreakerClosed:
+		return "closed"
+	case circuitBreakerOpen:
+		return "open"
+	case circuitBreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+var (
+	shardCircuitBreakerState = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kcp",
+			Subsystem:      "front_proxy",
+			Name:           "shard_circuit_breaker_state",
+			Help:           "State of the circuit breaker of a shard: 0 is closed, 1 is open and 2 is half-open.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard"},
+	)
+)
+
+// CircuitBreakerConfig configures the circuit breakers protecting the shards.
+type CircuitBreakerConfig struct {
+	// ErrorRatio is the ratio of failed requests within Window at which a circuit
+	// breaker opens.
+	ErrorRatio float64
+	// MinRequests is the number of requests within Window required before the
+	// ErrorRatio is evaluated.
+	MinRequests int
+	// Window is the interval over which requests are counted.
+	Window time.Duration
+	// OpenDuration is the time a circuit breaker stays open before a single
+	// probe request is let through.
+	OpenDuration time.Duration
+}
+
+// circuitBreaker sheds requests to a shard whose responses fail too often. It opens
+// when the ratio of 5xx responses within a window exceeds the configured threshold
+// and rejects requests while open. After the open duration, it half-opens and lets a
+// single probe request through: the breaker closes if the probe succeeds and opens
+// again otherwise.
+type circuitBreaker struct {
+	shard  string
+	config CircuitBreakerConfig
+	clock  clock.PassiveClock
+
+	lock        sync.Mutex
+	state       circuitBreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+func newCircuitBreaker(shard string, config CircuitBreakerConfig, clock clock.PassiveClock) *circuitBreaker {
+	b := &circuitBreaker{
+		shard:       shard,
+		config:      config,
+		clock:       clock,
+		windowStart: clock.Now(),
+	}
+	shardCircuitBreakerState.WithLabelValues(shard).Set(float64(circuitBreakerClosed))
+	return b
+}
+
+// allow returns whether a request may be sent to the shard, and whether it is the
+// probe request of a half-open breaker.
+func (b *circuitBreaker) allow() (allowed, probe bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case circuitBreakerOpen:
+		if b.clock.Since(b.openedAt) < b.config.OpenDuration {
+			return false, false
+		}
+		b.setStateLocked(circuitBreakerHalfOpen)
+		b.probing = true
+		return true, true
+	case circuitBreakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+// observe records the outcome of a request admitted by allow.
+func (b *circuitBreaker) observe(probe, failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if probe {
+		b.probing = false
+		if failed {
+			b.openLocked()
+		} else {
+			b.setStateLocked(circuitBreakerClosed)
+			b.resetWindowLocked(b.clock.Now())
+		}
+		return
+	}
+
+	if b.state != circuitBreakerClosed {
+		// a late response of a request admitted before the breaker opened
+		return
+	}
+
+	now := b.clock.Now()
+	if now.Sub(b.windowStart) > b.config.Window {
+		b.resetWindowLocked(now)
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.config.MinRequests && float64(b.failures)/float64(b.requests) >= b.config.ErrorRatio {
+		b.openLocked()
+	}
+}
+
+// isOpen returns whether the breaker rejects requests, i.e. it is open and the open
+// duration has not passed yet.
+func (b *circuitBreaker) isOpen() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.state == circuitBreakerOpen && b.clock.Since(b.openedAt) < b.config.OpenDuration
+}
+
+// retryAfter returns the time until the breaker half-opens.
+func (b *circuitBreaker) retryAfter() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if d := b.config.OpenDuration - b.clock.Since(b.openedAt); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (b *circuitBreaker) openLocked() {
+	klog.Warningf("Opening circuit breaker for shard %s after %d failures out of %d requests", b.shard, b.failures, b.requests)
+	b.openedAt = b.clock.Now()
+	b.setStateLocked(circuitBreakerOpen)
+}
+
+func (b *circuitBreaker) resetWindowLocked(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *circuitBreaker) setStateLocked(state circuitBreakerState) {
+	if b.state != state {
+		klog.V(2).Infof("Circuit breaker for shard %s transitions from %s to %s", b.shard, b.state, state)
+	}
+	b.state = state
+	shardCircuitBreakerState.WithLabelValues(b.shard).Set(float64(state))
+}
+
+// shardCircuitBreakers holds a circuit breaker per shard URL.
+type shardCircuitBreakers struct {
+	config CircuitBreakerConfig
+	clock  clock.PassiveClock
+
+	lock     sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newShardCircuitBreakers(config CircuitBreakerConfig, clock clock.PassiveClock) *shardCircuitBreakers {
+	registerMetrics()
+	return &shardCircuitBreakers{
+		config:   config,
+		clock:    clock,
+		breakers: map[string]*circuitBreaker{},
+	}
+}
+
+func (s *shardCircuitBreakers) forShard(shard string) *circuitBreaker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	b, found := s.breakers[shard]
+	if !found {
+		b = newCircuitBreaker(shard, s.config, s.clock)
+		s.breakers[shard] = b
+	}
+	return b
+}
+
+// healthy returns false if the circuit breaker of the shard is open, and true
+// otherwise.
+func (s *shardCircuitBreakers) healthy(shard string) bool {
+	s.lock.Lock()
+	b, found := s.breakers[shard]
+	s.lock.Unlock()
+
+	if !found {
+		return true
+	}
+	return !b.isOpen()
+}
+
+// withShardCircuitBreakers rejects requests with 503 Service Unavailable while the
+// circuit breaker of the shard in the request context is open, and otherwise feeds
+// the response status of the delegate into the circuit breaker.
+func withShardCircuitBreakers(delegate http.Handler, breakers *shardCircuitBreakers) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		shardURL := ShardURLFrom(req.Context())
+		if shardURL == nil {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		breaker := breakers.forShard(shardURL.String())
+		allowed, probe := breaker.allow()
+		if !allowed {
+			klog.V(4).Infof("Rejecting %q as the circuit breaker for shard %s is open%s", req.URL.Path, shardURL, logRequestID(req.Context()))
+			retryAfter := int(breaker.retryAfter().Round(time.Second) / time.Second)
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			err := apierrors.NewServiceUnavailable(fmt.Sprintf("shard %s is temporarily unavailable", shardURL.Host))
+			responsewriters.ErrorNegotiated(err, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+			return
+		}
+
+		rw := &statusObservingResponseWriter{
+			ResponseWriter: w,
+			observe: func(status int) {
+				breaker.observe(probe, status >= http.StatusInternalServerError)
+			},
+		}
+		defer rw.observeStatus(http.StatusOK)
+		delegate.ServeHTTP(rw, req)
+	}
+}
+
+// statusObservingResponseWriter calls observe with the status code of the response
+// as soon as it is known, i.e. without waiting for long-running requests to finish.
+type statusObservingResponseWriter struct {
+	http.ResponseWriter
+	observe  func(status int)
+	observed bool
+}
+
+func (w *statusObservingResponseWriter) observeStatus(status int) {
+	if w.observed {
+		return
+	}
+	w.observed = true
+	w.observe(status)
+}
+
+func (w *statusObservingResponseWriter) WriteHeader(status int) {
+	if status >= http.StatusOK {
+		w.observeStatus(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusObservingResponseWriter) Write(b []byte) (int, error) {
+	w.observeStatus(http.StatusOK)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusObservingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusObservingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer of type %T does not support hijacking", w.ResponseWriter)
+	}
+	// the upgrade succeeded
+	w.observeStatus(http.StatusSwitchingProtocols)
+	return hijacker.Hijack()
+}
+
+func (w *statusObservingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	breakers := newShardCircuitBreakers(CircuitBreakerConfig{
+		ErrorRatio:   0.5,
+		MinRequests:  4,
+		Window:       time.Minute,
+		OpenDuration: 30 * time.Second,
+	}, clock)
+
+	shardURL, err := url.Parse("https://shard-1.example.com:6443")
+	require.NoError(t, err)
+
+	backendStatus := http.StatusOK
+	backendRequests := 0
+	handler := withShardCircuitBreakers(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		backendRequests++
+		w.WriteHeader(backendStatus)
+	}), breakers)
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root/api/v1/namespaces", nil)
+		req = req.WithContext(WithShardURL(req.Context(), shardURL))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	requireState := func(want circuitBreakerState) {
+		t.Helper()
+		b := breakers.forShard(shardURL.String())
+		b.lock.Lock()
+		state := b.state
+		b.lock.Unlock()
+		require.Equal(t, want, state)
+
+		value, err := testutil.GetGaugeMetricValue(shardCircuitBreakerState.WithLabelValues(shardURL.String()))
+		require.NoError(t, err)
+		require.Equal(t, float64(want), value)
+	}
+
+	t.Log("The breaker stays closed below the minimum number of requests")
+	backendStatus = http.StatusInternalServerError
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusInternalServerError, request())
+	}
+	requireState(circuitBreakerClosed)
+
+	t.Log("The breaker opens when the error ratio is reached")
+	require.Equal(t, http.StatusInternalServerError, request())
+	requireState(circuitBreakerOpen)
+
+	t.Log("Requests are rejected while open, without reaching the backend")
+	backendStatus = http.StatusOK
+	backendRequests = 0
+	require.Equal(t, http.StatusServiceUnavailable, request())
+	require.Equal(t, 0, backendRequests)
+
+	t.Log("A failing probe after the open duration opens the breaker again")
+	clock.SetTime(clock.Now().Add(31 * time.Second))
+	backendStatus = http.StatusServiceUnavailable
+	require.Equal(t, http.StatusServiceUnavailable, request())
+	require.Equal(t, 1, backendRequests)
+	requireState(circuitBreakerOpen)
+	require.Equal(t, http.StatusServiceUnavailable, request())
+	require.Equal(t, 1, backendRequests)
+
+	t.Log("Only a single probe is let through while half-open")
+	clock.SetTime(clock.Now().Add(31 * time.Second))
+	b := breakers.forShard(shardURL.String())
+	allowed, probe := b.allow()
+	require.True(t, allowed)
+	require.True(t, probe)
+	requireState(circuitBreakerHalfOpen)
+	allowed, _ = b.allow()
+	require.False(t, allowed)
+
+	t.Log("A successful probe closes the breaker")
+	b.observe(true, false)
+	requireState(circuitBreakerClosed)
+	backendStatus = http.StatusOK
+	require.Equal(t, http.StatusOK, request())
+
+	t.Log("Failures outside of the window are not counted")
+	backendStatus = http.StatusInternalServerError
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusInternalServerError, request())
+	}
+	clock.SetTime(clock.Now().Add(2 * time.Minute))
+	require.Equal(t, http.StatusInternalServerError, request())
+	requireState(circuitBreakerClosed)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// withGzipCompression compresses the responses of delegate with gzip if the client
+// accepts it, the response is not encoded already and its body has at least minSize
+// bytes. Watches, upgraded connections and other streaming responses are passed through
+// as they are.
+func withGzipCompression(delegate http.Handler, minSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !acceptsGzip(req.Header) || isStreamingRequest(req) {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK}
+		defer gw.close()
+		delegate.ServeHTTP(gw, req)
+	}
+}
+
+// acceptsGzip returns whether the Accept-Encoding header accepts gzip with a non-zero quality.
+func acceptsGzip(header http.Header) bool {
+	for _, value := range header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, err := mime.ParseMediaType(strings.TrimSpace(coding))
+			if err != nil || name != "gzip" {
+				continue
+			}
+			if q, found := params["q"]; found {
+				if quality, err := strconv.ParseFloat(q, 64); err != nil || quality == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// isStreamingRequest returns whether the response to the request is streamed, i.e. for
+// watches, followed logs and upgraded connections like exec and port-forward.
+func isStreamingRequest(req *http.Request) bool {
+	if req.Header.Get("Upgrade") != "" || req.Header.Get("Range") != "" {
+		return true
+	}
+	if strings.Contains(req.URL.Path, "/watch/") {
+		return true
+	}
+	query := req.URL.Query()
+	for _, param := range []string{"watch", "follow"} {
+		if value := query.Get(param); value == "true" || value == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// isStreamingContentType returns whether the content type is one of a streamed response.
+func isStreamingContentType(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/event-stream" || params["stream"] != ""
+}
+
+// gzipResponseWriter buffers the beginning of a response until it either reaches minSize
+// bytes and is compressed, or ends or is flushed before and is passed through.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status      int
+	buf         []byte
+	decided     bool
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || isStreamingContentType(header.Get("Content-Type")) ||
+		status == http.StatusNoContent || status == http.StatusNotModified {
+		w.passThrough()
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.minSize {
+		return len(p), nil
+	}
+
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.decided = true
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	if _, err := w.gz.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush passes a response through uncompressed if it is still buffered, because
+// flushing before minSize bytes are written hints at a streaming response.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.passThrough()
+	}
+	if w.gz != nil {
+		w.gz.Flush() // nolint: errcheck
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// passThrough writes the header and the buffered body uncompressed, and passes the
+// rest of the response through.
+func (w *gzipResponseWriter) passThrough() {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf) // nolint: errcheck
+		w.buf = nil
+	}
+}
+
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		w.passThrough()
+	}
+	if w.gz != nil {
+		w.gz.Close() // nolint: errcheck
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipCompression(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	small := "small"
+
+	tests := map[string]struct {
+		path            string
+		acceptEncoding  string
+		contentType     string
+		contentEncoding string
+		body            string
+		flush           bool
+
+		expectCompressed bool
+	}{
+		"large body is compressed": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip, deflate", body: large,
+			expectCompressed: true,
+		},
+		"small body is passed through": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip", body: small,
+		},
+		"client not accepting gzip": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip;q=0, deflate", body: large,
+		},
+		"already encoded body is passed through": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip", contentEncoding: "br", body: large,
+		},
+		"watch is passed through": {
+			path: "/api/v1/configmaps?watch=true", acceptEncoding: "gzip", body: large,
+		},
+		"legacy watch path is passed through": {
+			path: "/api/v1/watch/configmaps", acceptEncoding: "gzip", body: large,
+		},
+		"streaming content type is passed through": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip", contentType: "application/vnd.kubernetes.protobuf;stream=watch", body: large,
+		},
+		"flushed small body is passed through": {
+			path: "/api/v1/configmaps", acceptEncoding: "gzip", body: small, flush: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := withGzipCompression(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				if tc.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tc.contentEncoding)
+				}
+				w.WriteHeader(http.StatusOK)
+				if tc.flush {
+					w.Write([]byte(tc.body[:1])) // nolint: errcheck
+					w.(http.Flusher).Flush()
+					w.Write([]byte(tc.body[1:])) // nolint: errcheck
+					return
+				}
+				w.Write([]byte(tc.body)) // nolint: errcheck
+			}), 1024)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			if !tc.expectCompressed {
+				require.Equal(t, tc.contentEncoding, w.Header().Get("Content-Encoding"))
+				require.Equal(t, tc.body, w.Body.String())
+				return
+			}
+
+			require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			require.Less(t, w.Body.Len(), len(tc.body))
+			r, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, tc.body, string(body))
+		})
+	}
+}
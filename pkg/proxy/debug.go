@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/index"
+)
+
+// indexDebugPath is the path the index debug handler is served under.
+const indexDebugPath = "/debug/index"
+
+// metricsPath is the path the metrics handler is served under.
+const metricsPath = "/metrics"
+
+// indexDebugHandler dumps the logical clusters known to the index and the shard URLs
+// they resolve to as JSON, e.g. to find out why a workspace cannot be found. Only
+// users in the system:masters group are permitted, as the dump reveals all workspaces.
+func indexDebugHandler(idx index.Index) http.HandlerFunc {
+	return withSystemMasters("access to the index is not permitted", func(w http.ResponseWriter, req *http.Request) {
+		dumpable, ok := idx.(index.DumpableIndex)
+		if !ok {
+			http.Error(w, "the index cannot be dumped", http.StatusNotImplemented)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(dumpable.Dump()); err != nil {
+			klog.Errorf("Failed to write the index dump: %v", err)
+		}
+	})
+}
+
+// metricsHandler serves the metrics of the proxy. Only users in the system:masters group
+// are permitted, as the metrics reveal the shards and the traffic they serve.
+func metricsHandler() http.HandlerFunc {
+	return withSystemMasters("access to the metrics is not permitted", legacyregistry.Handler().ServeHTTP)
+}
+
+// withSystemMasters rejects requests of users outside the system:masters group with
+// the given message.
+func withSystemMasters(message string, delegate http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok || !sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			http.Error(w, message, http.StatusForbidden)
+			return
+		}
+		delegate(w, req)
+	}
+}
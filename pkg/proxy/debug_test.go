@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/index"
+)
+
+type fakeDumpableIndex struct {
+	fakeIndex
+}
+
+func (i fakeDumpableIndex) Dump() []index.Entry {
+	var entries []index.Entry
+	for clusterName, url := range i.fakeIndex {
+		entries = append(entries, index.Entry{Cluster: clusterName, URLs: []string{url}})
+	}
+	return entries
+}
+
+func TestIndexDebugHandler(t *testing.T) {
+	idx := fakeDumpableIndex{fakeIndex{
+		logicalcluster.New("root:org"): "https://shard-1.example.com:6443",
+	}}
+
+	serve := func(idx index.Index, u user.Info) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, indexDebugPath, nil)
+		if u != nil {
+			req = req.WithContext(request.WithUser(req.Context(), u))
+		}
+		w := httptest.NewRecorder()
+		indexDebugHandler(idx).ServeHTTP(w, req)
+		return w
+	}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+
+	t.Log("Anonymous requests are forbidden")
+	require.Equal(t, http.StatusForbidden, serve(idx, nil).Code)
+
+	t.Log("Users outside system:masters are forbidden")
+	require.Equal(t, http.StatusForbidden, serve(idx, &user.DefaultInfo{Name: "user", Groups: []string{"system:authenticated"}}).Code)
+
+	t.Log("The dump reflects the mappings of the index")
+	w := serve(idx, admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var entries []index.Entry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Equal(t, []index.Entry{{Cluster: logicalcluster.New("root:org"), URLs: []string{"https://shard-1.example.com:6443"}}}, entries)
+
+	t.Log("Indexes which cannot be dumped are reported")
+	require.Equal(t, http.StatusNotImplemented, serve(idx.fakeIndex, admin).Code)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	serve := func(u user.Info) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, metricsPath, nil)
+		if u != nil {
+			req = req.WithContext(request.WithUser(req.Context(), u))
+		}
+		w := httptest.NewRecorder()
+		metricsHandler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Log("Anonymous requests are forbidden")
+	require.Equal(t, http.StatusForbidden, serve(nil).Code)
+
+	t.Log("Users outside system:masters are forbidden")
+	require.Equal(t, http.StatusForbidden, serve(&user.DefaultInfo{Name: "user", Groups: []string{"system:authenticated"}}).Code)
+
+	t.Log("Users in system:masters get the metrics")
+	w := serve(&user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
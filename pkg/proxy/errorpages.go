@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+// requestIDHeader is the header carrying the ID of a request. It is taken from the
+// request if set by a client or load balancer, and generated otherwise.
+const requestIDHeader = "X-Request-Id"
+
+// ErrorPageData is the data error page templates are rendered with.
+type ErrorPageData struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Reason is a machine readable description of the error, e.g. "NotFound".
+	Reason string
+	// Message is a human readable description of the error.
+	Message string
+	// ClusterName is the logical cluster of the request. It is empty if the request
+	// path does not name a logical cluster.
+	ClusterName string
+	// Path is the path of the request.
+	Path string
+	// RequestID identifies the request, e.g. to correlate it with logs.
+	RequestID string
+}
+
+// errorPages renders the bodies of the errors the proxy itself responds with from
+// operator supplied templates. A nil template means the default response is used.
+type errorPages struct {
+	forbidden   *template.Template
+	notFound    *template.Template
+	contentType string
+}
+
+var errorPageFuncs = template.FuncMap{
+	// json renders a value as JSON, e.g. to safely quote strings in JSON bodies.
+	"json": func(v interface{}) (string, error) {
+		bs, err := json.Marshal(v)
+		return string(bs), err
+	},
+}
+
+// newErrorPages loads the error page templates configured in the given options.
+func newErrorPages(o *proxyoptions.Options) (*errorPages, error) {
+	pages := &errorPages{contentType: o.ErrorTemplateContentType}
+
+	var err error
+	if pages.forbidden, err = parseErrorPageTemplate(o.ForbiddenTemplateFile); err != nil {
+		return nil, err
+	}
+	if pages.notFound, err = parseErrorPageTemplate(o.NotFoundTemplateFile); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func parseErrorPageTemplate(filename string) (*template.Template, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error template %q: %w", filename, err)
+	}
+	tmpl, err := template.New(filepath.Base(filename)).Funcs(errorPageFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse error template %q: %w", filename, err)
+	}
+	return tmpl, nil
+}
+
+// writeForbidden renders the forbidden template. It returns false if no template is
+// configured, i.e. the caller has to fall back to the default response.
+func (p *errorPages) writeForbidden(w http.ResponseWriter, req *http.Request, clusterName logicalcluster.Name, message string) bool {
+	if p == nil || p.forbidden == nil {
+		return false
+	}
+	p.write(w, req, p.forbidden, ErrorPageData{
+		StatusCode:  http.StatusForbidden,
+		Reason:      "Forbidden",
+		Message:     message,
+		ClusterName: clusterName.String(),
+	})
+	return true
+}
+
+// writeNotFound renders the not found template. It returns false if no template is
+// configured, i.e. the caller has to fall back to the default response.
+func (p *errorPages) writeNotFound(w http.ResponseWriter, req *http.Request) bool {
+	if p == nil || p.notFound == nil {
+		return false
+	}
+	p.write(w, req, p.notFound, ErrorPageData{
+		StatusCode: http.StatusNotFound,
+		Reason:     "NotFound",
+		Message:    "the requested path was not found",
+	})
+	return true
+}
+
+func (p *errorPages) write(w http.ResponseWriter, req *http.Request, tmpl *template.Template, data ErrorPageData) {
+	data.Path = req.URL.Path
+	data.RequestID = req.Header.Get(requestIDHeader)
+	if data.RequestID == "" {
+		data.RequestID = string(uuid.NewUUID())
+	}
+
+	// render into a buffer first to not send a partial body on template errors
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		klog.Errorf("Failed to render error template %q: %v", tmpl.Name(), err)
+		http.Error(w, http.StatusText(data.StatusCode), data.StatusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", p.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set(requestIDHeader, data.RequestID)
+	w.WriteHeader(data.StatusCode)
+	w.Write(buf.Bytes()) // nolint: errcheck
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package proxy
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/kcp-dev/logicalcluster"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/endpoints/filters"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
@@ -33,11 +39,105 @@ import (
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 )
 
-func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
+// ClusterHeader is the header conveying the logical cluster of a request proxied to a
+// shard, independently of the request path. Client supplied values are overwritten by
+// the proxy.
+const ClusterHeader = "X-Kcp-Cluster"
+
+const (
+	// shardURLAuditAnnotation is the audit annotation recording the URL of the shard
+	// a request is proxied to.
+	shardURLAuditAnnotation = "proxy.kcp.dev/shard-url"
+	// clusterNameAuditAnnotation is the audit annotation recording the logical cluster
+	// a request is proxied for.
+	clusterNameAuditAnnotation = "proxy.kcp.dev/cluster-name"
+)
+
+// replicaSelector selects one of the equivalent replicas of a shard in a round-robin
+// fashion, skipping replicas that are not healthy.
+type replicaSelector struct {
+	next uint64
+	// healthy returns whether requests should be sent to the given shard URL. If nil,
+	// all replicas are considered healthy.
+	healthy func(shardURL string) bool
+}
+
+// selectReplica returns the next healthy replica. If none is healthy, the next
+// replica is returned regardless.
+func (s *replicaSelector) selectReplica(replicas []string) string {
+	if len(replicas) == 1 {
+		return replicas[0]
+	}
+
+	start := atomic.AddUint64(&s.next, 1) - 1
+	for i := range replicas {
+		replica := replicas[(start+uint64(i))%uint64(len(replicas))]
+		if s.healthy == nil || s.healthy(replica) {
+			return replica
+		}
+	}
+	return replicas[start%uint64(len(replicas))]
+}
+
+// lookupShardURL returns the URL of the shard of the given logical cluster. If the
+// index maps logical clusters to multiple replicas, one of them is selected.
+func lookupShardURL(idx index.Index, selector *replicaSelector, clusterName logicalcluster.Name) (string, bool) {
+	replicaIndex, ok := idx.(index.ReplicaIndex)
+	if !ok {
+		return idx.Lookup(clusterName)
+	}
+
+	replicas, found := replicaIndex.LookupReplicas(clusterName)
+	if !found || len(replicas) == 0 {
+		return "", false
+	}
+	return selector.selectReplica(replicas), true
+}
+
+// pathLimits bounds the request paths accepted by the shardHandler. Zero values mean
+// unlimited.
+type pathLimits struct {
+	// maxLength is the maximum length in bytes of the escaped request path.
+	maxLength int
+	// maxSegments is the maximum number of /-separated segments of the request path.
+	maxSegments int
+}
+
+// check returns an error to respond with if the request path exceeds the limits.
+func (l pathLimits) check(req *http.Request) error {
+	if l.maxLength > 0 {
+		if length := len(req.URL.EscapedPath()); length > l.maxLength {
+			return apierrors.NewGenericServerResponse(http.StatusRequestURITooLong, req.Method, schema.GroupResource{}, "",
+				fmt.Sprintf("request path of %d bytes exceeds the maximum of %d bytes", length, l.maxLength), 0, false)
+		}
+	}
+	if l.maxSegments > 0 {
+		if segments := strings.Count(req.URL.Path, "/"); segments > l.maxSegments {
+			return apierrors.NewBadRequest(fmt.Sprintf("request path of %d segments exceeds the maximum of %d segments", segments, l.maxSegments))
+		}
+	}
+	return nil
+}
+
+// shardHandler proxies requests for logical clusters to their shard. Unknown and
+// invalid logical clusters are rendered with the given error pages, which may be nil.
+// If the shard URL has a path, it is the base path of the shard, and /clusters/<name>
+// of the request path is replaced with it. Requests with paths exceeding the given
+// limits, and requests for logical clusters in the blocklist, which may be nil, are
+// rejected before the logical cluster is looked up.
+func shardHandler(index index.Index, selector *replicaSelector, errorPages *errorPages, limits pathLimits, blocklist *clusterBlocklist, proxy http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		if err := limits.check(req); err != nil {
+			klog.V(4).Infof("Rejecting request: %v%s", err, logRequestID(req.Context()))
+			responsewriters.ErrorNegotiated(err, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+			return
+		}
+
 		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 3)
 		if len(cs) != 3 || cs[0] != "clusters" {
-			http.NotFound(w, req)
+			if !errorPages.writeNotFound(w, req) {
+				http.NotFound(w, req)
+			}
 			return
 		}
 
@@ -49,16 +149,27 @@ func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
 		}
 
 		clusterName := logicalcluster.New(cs[1])
+		if err := blocklist.check(req, clusterName); err != nil {
+			klog.V(4).Infof("Rejecting request for blocked cluster %q%s", clusterName, logRequestID(ctx))
+			responsewriters.ErrorNegotiated(err, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+			return
+		}
 		if !tenancyhelper.IsValidCluster(clusterName) {
 			// this includes wildcards
-			klog.V(4).Infof("Invalid cluster name %q", req.URL.Path)
+			klog.V(4).Infof("Invalid cluster name %q%s", req.URL.Path, logRequestID(ctx))
+			if errorPages.writeForbidden(w, req, clusterName, fmt.Sprintf("access to cluster %q is not permitted", clusterName)) {
+				return
+			}
 			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
 			return
 		}
 
-		shardURLString, found := index.Lookup(clusterName)
+		shardURLString, found := lookupShardURL(index, selector, clusterName)
 		if !found {
-			klog.V(4).Infof("Unknown cluster %q", clusterName)
+			klog.V(4).Infof("Unknown cluster %q%s", clusterName, logRequestID(ctx))
+			if errorPages.writeForbidden(w, req, clusterName, fmt.Sprintf("access to cluster %q is not permitted", clusterName)) {
+				return
+			}
 			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
 			return
 		}
@@ -68,10 +179,83 @@ func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
 			return
 		}
 
-		klog.V(4).Infof("Redirecting %q to %s", req.URL.Path, shardURL)
+		if basePath := strings.TrimRight(shardURL.Path, "/"); basePath != "" {
+			// the shard serves the logical cluster under its base path. Without
+			// /clusters/<name> in the path, it reads the logical cluster from
+			// logicalcluster.ClusterHeader, which is set below.
+			req.URL.Path = basePath + "/" + cs[2]
+			req.URL.RawPath = ""
+		}
+
+		klog.V(4).Infof("Redirecting %q to %s%s", req.URL.Path, shardURL, logRequestID(ctx))
+
+		audit.AddAuditAnnotation(ctx, shardURLAuditAnnotation, shardURL.String())
+		audit.AddAuditAnnotation(ctx, clusterNameAuditAnnotation, clusterName.String())
 
 		ctx = WithShardURL(ctx, shardURL)
 		req = req.WithContext(ctx)
+		req.Header.Set(ClusterHeader, clusterName.String())
+		// overwrite any client supplied value, the shard trusts it for paths without a cluster.
+		req.Header.Set(logicalcluster.ClusterHeader, clusterName.String())
 		proxy.ServeHTTP(w, req)
 	}
 }
+
+// withWorkspaceHeader resolves the logical cluster of API requests, i.e. under /api or /apis,
+// from the given header, by prefixing the path with /clusters/<name>. Requests with
+// /clusters/<name> in the path and requests for other paths, e.g. virtual workspaces under
+// /services, are not rerouted. Header values which are no valid logical cluster are rejected
+// like invalid logical clusters in the path. The header is not forwarded.
+func withWorkspaceHeader(delegate http.Handler, header string, errorPages *errorPages) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		value := strings.TrimSpace(req.Header.Get(header))
+		req.Header.Del(header)
+		if value == "" || !isAPIPath(req.URL.Path) {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		clusterName := logicalcluster.New(value)
+		if !tenancyhelper.IsValidCluster(clusterName) {
+			ctx := req.Context()
+			klog.V(4).Infof("Invalid cluster name %q in header %s%s", value, header, logRequestID(ctx))
+			if errorPages.writeForbidden(w, req, clusterName, fmt.Sprintf("access to cluster %q is not permitted", clusterName)) {
+				return
+			}
+			attributes, err := filters.GetAuthorizerAttributes(ctx)
+			if err != nil {
+				responsewriters.InternalError(w, req, err)
+				return
+			}
+			responsewriters.Forbidden(ctx, attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+			return
+		}
+
+		req.URL.Path = "/clusters/" + clusterName.String() + req.URL.Path
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = "/clusters/" + clusterName.String() + req.URL.RawPath
+		}
+		delegate.ServeHTTP(w, req)
+	}
+}
+
+// isAPIPath returns whether path is a Kubernetes API path without /clusters/<name> prefix.
+func isAPIPath(path string) bool {
+	return path == "/api" || path == "/apis" || strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/apis/")
+}
+
+// withRequestID makes sure requests carry a request ID header, taking the one of the client
+// if set and generating one otherwise. The ID is forwarded to the shard, echoed back in the
+// response, and added to the log lines of the request, in order to correlate proxy and shard logs.
+func withRequestID(delegate http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = string(uuid.NewUUID())
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		delegate.ServeHTTP(w, req.WithContext(WithRequestID(req.Context(), requestID)))
+	}
+}
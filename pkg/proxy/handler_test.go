@@ -0,0 +1,503 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/audit/policy"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	fakeaudit "k8s.io/apiserver/plugin/pkg/audit/fake"
+
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+type fakeIndex map[logicalcluster.Name]string
+
+func (i fakeIndex) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
+	url, found := i[logicalCluster]
+	return url, found
+}
+
+type fakeReplicaIndex map[logicalcluster.Name][]string
+
+func (i fakeReplicaIndex) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
+	replicas, found := i[logicalCluster]
+	if !found || len(replicas) == 0 {
+		return "", false
+	}
+	return replicas[0], true
+}
+
+func (i fakeReplicaIndex) LookupReplicas(logicalCluster logicalcluster.Name) ([]string, bool) {
+	replicas, found := i[logicalCluster]
+	return replicas, found
+}
+
+// serveShardRequests sends n requests for root:org through the shardHandler and
+// returns how many of them were sent to each shard.
+func serveShardRequests(t *testing.T, handler http.Handler, n int) map[string]int {
+	t.Helper()
+
+	hits := map[string]int{}
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "unexpected response: %s", w.Body.String())
+		hits[w.Body.String()]++
+	}
+	return hits
+}
+
+func TestShardHandlerReplicas(t *testing.T) {
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(ShardURLFrom(req.Context()).String())) // nolint: errcheck
+	})
+
+	replica1 := "https://shard-1a.example.com:6443"
+	replica2 := "https://shard-1b.example.com:6443"
+	org := logicalcluster.New("root:org")
+
+	t.Run("single URL index", func(t *testing.T) {
+		handler := shardHandler(fakeIndex{org: replica1}, &replicaSelector{}, nil, pathLimits{}, nil, proxy)
+		require.Equal(t, map[string]int{replica1: 4}, serveShardRequests(t, handler, 4))
+	})
+
+	t.Run("single replica", func(t *testing.T) {
+		handler := shardHandler(fakeReplicaIndex{org: {replica1}}, &replicaSelector{}, nil, pathLimits{}, nil, proxy)
+		require.Equal(t, map[string]int{replica1: 4}, serveShardRequests(t, handler, 4))
+	})
+
+	t.Run("requests are distributed across replicas", func(t *testing.T) {
+		handler := shardHandler(fakeReplicaIndex{org: {replica1, replica2}}, &replicaSelector{}, nil, pathLimits{}, nil, proxy)
+		require.Equal(t, map[string]int{replica1: 5, replica2: 5}, serveShardRequests(t, handler, 10))
+	})
+
+	t.Run("unhealthy replicas are skipped", func(t *testing.T) {
+		selector := &replicaSelector{healthy: func(shardURL string) bool { return shardURL != replica2 }}
+		handler := shardHandler(fakeReplicaIndex{org: {replica1, replica2}}, selector, nil, pathLimits{}, nil, proxy)
+		require.Equal(t, map[string]int{replica1: 10}, serveShardRequests(t, handler, 10))
+	})
+
+	t.Run("all replicas unhealthy", func(t *testing.T) {
+		selector := &replicaSelector{healthy: func(shardURL string) bool { return false }}
+		handler := shardHandler(fakeReplicaIndex{org: {replica1, replica2}}, selector, nil, pathLimits{}, nil, proxy)
+		require.Equal(t, map[string]int{replica1: 5, replica2: 5}, serveShardRequests(t, handler, 10))
+	})
+}
+
+func TestShardHandlerAuditAnnotations(t *testing.T) {
+	proxied := false
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = true
+	})
+	shardURL := "https://shard-1.example.com:6443"
+	handler := shardHandler(fakeIndex{logicalcluster.New("root:org"): shardURL}, &replicaSelector{}, nil, pathLimits{}, nil, proxy)
+
+	t.Run("resolved shard is recorded", func(t *testing.T) {
+		event := &auditinternal.Event{Level: auditinternal.LevelMetadata}
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{})
+		ctx = audit.WithAuditContext(ctx, &audit.AuditContext{Event: event})
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+		require.True(t, proxied)
+		require.Equal(t, map[string]string{
+			shardURLAuditAnnotation:    shardURL,
+			clusterNameAuditAnnotation: "root:org",
+		}, event.Annotations)
+	})
+
+	t.Run("nothing is recorded for unknown clusters", func(t *testing.T) {
+		event := &auditinternal.Event{Level: auditinternal.LevelMetadata}
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:unknown/api/v1/namespaces", nil)
+		ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{})
+		ctx = audit.WithAuditContext(ctx, &audit.AuditContext{Event: event})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req.WithContext(ctx))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Empty(t, event.Annotations)
+	})
+
+	t.Run("annotations are emitted to the audit backend", func(t *testing.T) {
+		var completed *auditinternal.Event
+		backend := &fakeaudit.Backend{OnRequest: func(events []*auditinternal.Event) {
+			for _, event := range events {
+				if event.Stage == auditinternal.StageResponseComplete {
+					completed = event.DeepCopy()
+				}
+			}
+		}}
+		audited := genericapifilters.WithAudit(handler, backend, policy.NewFakePolicyRuleEvaluator(auditinternal.LevelMetadata, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		audited.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.NotNil(t, completed, "expected an audit event for the completed request")
+		require.Equal(t, shardURL, completed.Annotations[shardURLAuditAnnotation])
+		require.Equal(t, "root:org", completed.Annotations[clusterNameAuditAnnotation])
+	})
+}
+
+func TestShardHandlerErrorPages(t *testing.T) {
+	dir := t.TempDir()
+	forbiddenFile := filepath.Join(dir, "forbidden.json")
+	require.NoError(t, ioutil.WriteFile(forbiddenFile, []byte(`{"code":{{.StatusCode}},"cluster":{{json .ClusterName}},"requestID":{{json .RequestID}}}`), 0600))
+	notFoundFile := filepath.Join(dir, "notfound.json")
+	require.NoError(t, ioutil.WriteFile(notFoundFile, []byte(`{"code":{{.StatusCode}},"path":{{json .Path}},"requestID":{{json .RequestID}}}`), 0600))
+
+	o := proxyoptions.NewOptions()
+	o.ForbiddenTemplateFile = forbiddenFile
+	o.NotFoundTemplateFile = notFoundFile
+	pages, err := newErrorPages(o)
+	require.NoError(t, err)
+
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected proxied request to %s", req.URL.Path)
+	})
+	handler := shardHandler(fakeIndex{}, &replicaSelector{}, pages, pathLimits{}, nil, proxy)
+
+	serve := func(path, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		w := serve("/clusters/root:unknown/api/v1/namespaces", "abc")
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, "abc", w.Header().Get(requestIDHeader))
+		require.JSONEq(t, `{"code":403,"cluster":"root:unknown","requestID":"abc"}`, w.Body.String())
+	})
+
+	t.Run("invalid cluster", func(t *testing.T) {
+		w := serve("/clusters/*/api/v1/namespaces", "abc")
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.JSONEq(t, `{"code":403,"cluster":"*","requestID":"abc"}`, w.Body.String())
+	})
+
+	t.Run("not found with generated request ID", func(t *testing.T) {
+		w := serve("/foo", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		requestID := w.Header().Get(requestIDHeader)
+		require.NotEmpty(t, requestID)
+		require.JSONEq(t, fmt.Sprintf(`{"code":404,"path":"/foo","requestID":%q}`, requestID), w.Body.String())
+	})
+
+	t.Run("default responses without templates", func(t *testing.T) {
+		handler := shardHandler(fakeIndex{}, &replicaSelector{}, &errorPages{}, pathLimits{}, nil, proxy)
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:unknown/api/v1/namespaces", nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Contains(t, w.Body.String(), `"kind":"Status"`)
+	})
+}
+
+func TestShardHandlerClusterHeader(t *testing.T) {
+	var got, gotShardHeader []string
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Values(ClusterHeader)
+		gotShardHeader = req.Header.Values(logicalcluster.ClusterHeader)
+	})
+	handler := shardHandler(fakeIndex{logicalcluster.New("root:org"): "https://shard-1.example.com:6443"}, &replicaSelector{}, nil, pathLimits{}, nil, proxy)
+
+	for _, spoofed := range [][]string{nil, {"root:other"}, {"root:other", "root:org"}} {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		for _, v := range spoofed {
+			req.Header.Add(ClusterHeader, v)
+			req.Header.Add(logicalcluster.ClusterHeader, v)
+		}
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, []string{"root:org"}, got, "client supplied header %v", spoofed)
+		require.Equal(t, []string{"root:org"}, gotShardHeader, "client supplied header %v", spoofed)
+	}
+
+	t.Run("stripped for other backends", func(t *testing.T) {
+		got, gotShardHeader = nil, nil
+		req := httptest.NewRequest(http.MethodGet, "/services/foo", nil)
+		req.Header.Set(ClusterHeader, "root:other")
+		req.Header.Set(logicalcluster.ClusterHeader, "root:other")
+		withoutClusterHeader(proxy).ServeHTTP(httptest.NewRecorder(), req)
+		require.Empty(t, got)
+		require.Empty(t, gotShardHeader)
+	})
+}
+
+func TestShardHandlerPathLimits(t *testing.T) {
+	proxied := false
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = true
+	})
+	limits := pathLimits{maxLength: 64, maxSegments: 8}
+	handler := shardHandler(fakeIndex{logicalcluster.New("root:org"): "https://shard-1.example.com:6443"}, &replicaSelector{}, nil, limits, nil, proxy)
+
+	for _, tc := range []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{name: "within limits", path: "/clusters/root:org/api/v1/namespaces/default/pods", expectedCode: http.StatusOK},
+		{name: "too long", path: "/clusters/root:org/api/v1/namespaces/" + strings.Repeat("a", 64), expectedCode: http.StatusRequestURITooLong},
+		{name: "too long escaped", path: "/clusters/root:org/api/v1/namespaces/" + strings.Repeat("%20", 10), expectedCode: http.StatusRequestURITooLong},
+		{name: "too many segments", path: "/clusters/root:org/api/v1/namespaces/default/pods/a/b/c", expectedCode: http.StatusBadRequest},
+		{name: "too many empty segments", path: "/clusters/root:org" + strings.Repeat("/", 10), expectedCode: http.StatusBadRequest},
+		{name: "over limits for unknown cluster", path: "/clusters/root:unknown/" + strings.Repeat("a", 64), expectedCode: http.StatusRequestURITooLong},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			proxied = false
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tc.expectedCode, w.Code, "unexpected response: %s", w.Body.String())
+			require.Equal(t, tc.expectedCode == http.StatusOK, proxied)
+			if tc.expectedCode != http.StatusOK {
+				require.Contains(t, w.Body.String(), `"kind":"Status"`)
+			}
+		})
+	}
+}
+
+func TestWorkspaceHeader(t *testing.T) {
+	var gotPath, gotCluster, gotWorkspace string
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		gotCluster = req.Header.Get(ClusterHeader)
+		gotWorkspace = req.Header.Get("X-Kcp-Workspace")
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/clusters/", shardHandler(fakeIndex{
+		logicalcluster.New("root:org"):   "https://shard-1.example.com:6443",
+		logicalcluster.New("root:other"): "https://shard-2.example.com:6443",
+	}, &replicaSelector{}, nil, pathLimits{}, nil, proxy))
+	var gotServicesPath string
+	mux.Handle("/services/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotServicesPath = req.URL.Path
+		gotWorkspace = req.Header.Get("X-Kcp-Workspace")
+	}))
+	handler := withWorkspaceHeader(mux, "X-Kcp-Workspace", nil)
+
+	for _, tc := range []struct {
+		name             string
+		path             string
+		header           string
+		wantCode         int
+		wantPath         string
+		wantCluster      string
+		wantServicesPath string
+	}{
+		{
+			name:        "header only",
+			path:        "/api/v1/namespaces",
+			header:      "root:org",
+			wantCode:    http.StatusOK,
+			wantPath:    "/clusters/root:org/api/v1/namespaces",
+			wantCluster: "root:org",
+		},
+		{
+			name:        "path only",
+			path:        "/clusters/root:org/api/v1/namespaces",
+			wantCode:    http.StatusOK,
+			wantPath:    "/clusters/root:org/api/v1/namespaces",
+			wantCluster: "root:org",
+		},
+		{
+			name:        "path takes precedence over header",
+			path:        "/clusters/root:org/api/v1/namespaces",
+			header:      "root:other",
+			wantCode:    http.StatusOK,
+			wantPath:    "/clusters/root:org/api/v1/namespaces",
+			wantCluster: "root:org",
+		},
+		{
+			name:        "surrounding whitespace is trimmed",
+			path:        "/api/v1/namespaces",
+			header:      " root:other ",
+			wantCode:    http.StatusOK,
+			wantPath:    "/clusters/root:other/api/v1/namespaces",
+			wantCluster: "root:other",
+		},
+		{
+			name:        "header only for the legacy API group",
+			path:        "/api",
+			header:      "root:org",
+			wantCode:    http.StatusOK,
+			wantPath:    "/clusters/root:org/api",
+			wantCluster: "root:org",
+		},
+		{
+			name:             "virtual workspace paths are not rerouted",
+			path:             "/services/workspaces/root/all/apis",
+			header:           "root:org",
+			wantCode:         http.StatusOK,
+			wantServicesPath: "/services/workspaces/root/all/apis",
+		},
+		{
+			name:     "other paths are not rerouted",
+			path:     "/readyz",
+			header:   "root:org",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "neither path nor header",
+			path:     "/api/v1/namespaces",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "header with path segments is rejected",
+			path:     "/api/v1/namespaces",
+			header:   "root:org/api/v1/secrets",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "wildcard header is rejected",
+			path:     "/api/v1/namespaces",
+			header:   "*",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "unknown cluster in header is rejected",
+			path:     "/api/v1/namespaces",
+			header:   "root:unknown",
+			wantCode: http.StatusForbidden,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotPath, gotCluster, gotWorkspace, gotServicesPath = "", "", "", ""
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("X-Kcp-Workspace", tc.header)
+			}
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tc.wantCode, w.Code, "unexpected response: %s", w.Body.String())
+			require.Equal(t, tc.wantPath, gotPath)
+			require.Equal(t, tc.wantCluster, gotCluster)
+			require.Equal(t, tc.wantServicesPath, gotServicesPath)
+			require.Empty(t, gotWorkspace, "header must not be forwarded")
+		})
+	}
+}
+
+func TestShardHandlerBasePath(t *testing.T) {
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s", req.URL.RequestURI(), req.Header.Get(logicalcluster.ClusterHeader))
+	}))
+	defer shard.Close()
+
+	for _, tc := range []struct {
+		name     string
+		shardURL string
+		path     string
+		want     string
+	}{
+		{
+			name:     "without base path",
+			shardURL: shard.URL,
+			path:     "/clusters/root:org/api/v1/namespaces?limit=1",
+			want:     "/clusters/root:org/api/v1/namespaces?limit=1 root:org",
+		},
+		{
+			name:     "without base path, but with trailing slash",
+			shardURL: shard.URL + "/",
+			path:     "/clusters/root:org/api/v1/namespaces",
+			want:     "/clusters/root:org/api/v1/namespaces root:org",
+		},
+		{
+			name:     "with base path",
+			shardURL: shard.URL + "/base",
+			path:     "/clusters/root:org/api/v1/namespaces?limit=1",
+			want:     "/base/api/v1/namespaces?limit=1 root:org",
+		},
+		{
+			name:     "with nested base path and trailing slash",
+			shardURL: shard.URL + "/kcp/shard-1/",
+			path:     "/clusters/root:org/apis/",
+			want:     "/kcp/shard-1/apis/ root:org",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := shardHandler(fakeIndex{logicalcluster.New("root:org"): tc.shardURL}, &replicaSelector{}, nil, pathLimits{}, nil, newShardReverseProxy(false))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(logicalcluster.ClusterHeader, "root:other")
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, "unexpected response: %s", w.Body.String())
+			require.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	var forwarded, fromContext string
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		forwarded = req.Header.Get(requestIDHeader)
+		fromContext = RequestIDFrom(req.Context())
+	})
+	handler := withRequestID(shardHandler(fakeIndex{logicalcluster.New("root:org"): "https://shard-1.example.com:6443"}, &replicaSelector{}, nil, pathLimits{}, nil, proxy))
+
+	t.Run("generated when absent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.NotEmpty(t, forwarded)
+		require.Equal(t, forwarded, fromContext)
+		require.Equal(t, forwarded, w.Header().Get(requestIDHeader))
+	})
+
+	t.Run("preserved when present", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api/v1/namespaces", nil)
+		req.Header.Set(requestIDHeader, "abc-123")
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, "abc-123", forwarded)
+		require.Equal(t, "abc-123", fromContext)
+		require.Equal(t, "abc-123", w.Header().Get(requestIDHeader))
+	})
+}
@@ -19,6 +19,7 @@ package index
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -43,11 +44,38 @@ const (
 	clusterWorkspaceResyncPeriod = 2 * time.Hour
 )
 
-// Index implements a mapping from logical cluster to (shard) URL.
+// Index implements a mapping from logical cluster to (shard) URL. The path of the
+// URL, if any, is the base path the shard serves the logical cluster under, e.g.
+// https://shard/base maps /clusters/foo/api to /base/api on the shard. Without a
+// path, requests are forwarded to the shard as they are.
 type Index interface {
 	Lookup(logicalCluster logicalcluster.Name) (string, bool)
 }
 
+// ReplicaIndex is an Index that maps a logical cluster to the URLs of one or more
+// equivalent shard replicas.
+type ReplicaIndex interface {
+	Index
+	LookupReplicas(logicalCluster logicalcluster.Name) ([]string, bool)
+}
+
+// Entry is a logical cluster known to an Index, and the URLs it resolves to.
+type Entry struct {
+	Cluster logicalcluster.Name `json:"cluster"`
+	// Shard is the name of the shard of the logical cluster, if known.
+	Shard string `json:"shard,omitempty"`
+	// URLs are the URLs of the shard, or of its replicas. They are empty if the
+	// logical cluster is assigned to a shard which is not known (yet).
+	URLs []string `json:"urls"`
+}
+
+// DumpableIndex is an Index that can list all logical clusters it knows, e.g. for
+// debugging.
+type DumpableIndex interface {
+	Index
+	Dump() []Entry
+}
+
 type ClusterWorkspaceClientGetter func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclientset.ClusterInterface, error)
 
 func NewController(
@@ -293,3 +321,23 @@ func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
 	url, found := c.shardBaseURLs[shardName]
 	return url, found
 }
+
+// Dump returns all logical clusters known to the index, sorted by name.
+func (c *Controller) Dump() []Entry {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	entries := make([]Entry, 0, len(c.workspaceShardNames)+1)
+	entries = append(entries, Entry{Cluster: tenancyv1alpha1.RootCluster, URLs: []string{c.rootHost}})
+	for clusterName, shardName := range c.workspaceShardNames {
+		entry := Entry{Cluster: clusterName, Shard: shardName, URLs: []string{}}
+		if url, found := c.shardBaseURLs[shardName]; found {
+			entry.URLs = append(entry.URLs, url)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Cluster.String() < entries[j].Cluster.String()
+	})
+	return entries
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	c := &Controller{
+		rootHost: "https://root.example.com:6443",
+		workspaceShardNames: map[logicalcluster.Name]string{
+			logicalcluster.New("root:org"):      "shard-1",
+			logicalcluster.New("root:org:team"): "shard-2",
+			logicalcluster.New("root:other"):    "unknown",
+		},
+		shardBaseURLs: map[string]string{
+			"shard-1": "https://shard-1.example.com:6443",
+			"shard-2": "https://shard-2.example.com:6443",
+		},
+	}
+
+	require.Equal(t, []Entry{
+		{Cluster: logicalcluster.New("root"), URLs: []string{"https://root.example.com:6443"}},
+		{Cluster: logicalcluster.New("root:org"), Shard: "shard-1", URLs: []string{"https://shard-1.example.com:6443"}},
+		{Cluster: logicalcluster.New("root:org:team"), Shard: "shard-2", URLs: []string{"https://shard-2.example.com:6443"}},
+		{Cluster: logicalcluster.New("root:other"), Shard: "unknown", URLs: []string{}},
+	}, c.Dump())
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+// shardInflightLimits bounds the number of requests proxied to each shard at the
+// same time, in order to protect a shard from the aggregate of all logical clusters
+// routed to it. Long-running requests like watches hold their slot until they end.
+type shardInflightLimits struct {
+	// defaultLimit applies to shards without a limit in perShard. 0 means unlimited.
+	defaultLimit int
+	// perShard maps shard URLs to their limit. 0 means unlimited.
+	perShard map[string]int
+
+	lock       sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+// newShardInflightLimits returns the in-flight limits for shards with the given default
+// limit and the per-shard limits keyed by shard URL, or nil if no shard is limited.
+func newShardInflightLimits(defaultLimit int, perShard map[string]int) (*shardInflightLimits, error) {
+	limits := &shardInflightLimits{
+		defaultLimit: defaultLimit,
+		perShard:     make(map[string]int, len(perShard)),
+		semaphores:   map[string]chan struct{}{},
+	}
+
+	limited := defaultLimit > 0
+	for shard, limit := range perShard {
+		u, err := url.Parse(shard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard URL %q: %w", shard, err)
+		}
+		limits.perShard[u.String()] = limit
+		limited = limited || limit > 0
+	}
+	if !limited {
+		return nil, nil
+	}
+	return limits, nil
+}
+
+// forShard returns the semaphore of the shard, or nil if the shard is not limited.
+func (l *shardInflightLimits) forShard(shard string) chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if semaphore, found := l.semaphores[shard]; found {
+		return semaphore
+	}
+
+	limit, found := l.perShard[shard]
+	if !found {
+		limit = l.defaultLimit
+	}
+	var semaphore chan struct{}
+	if limit > 0 {
+		semaphore = make(chan struct{}, limit)
+	}
+	l.semaphores[shard] = semaphore
+	return semaphore
+}
+
+// withShardInflightLimits rejects requests with 503 Service Unavailable while the shard
+// in the request context serves its maximum number of in-flight requests.
+func withShardInflightLimits(delegate http.Handler, limits *shardInflightLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		shardURL := ShardURLFrom(req.Context())
+		if shardURL == nil {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		semaphore := limits.forShard(shardURL.String())
+		if semaphore == nil {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+			defer func() { <-semaphore }()
+		default:
+			klog.V(4).Infof("Rejecting %q as shard %s has too many requests in flight%s", req.URL.Path, shardURL, logRequestID(req.Context()))
+			w.Header().Set("Retry-After", "1")
+			err := apierrors.NewServiceUnavailable(fmt.Sprintf("shard %s has too many requests in flight", shardURL.Host))
+			responsewriters.ErrorNegotiated(err, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+			return
+		}
+
+		delegate.ServeHTTP(w, req)
+	}
+}
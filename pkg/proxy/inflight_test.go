@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestShardInflightLimits(t *testing.T) {
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	slowShard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer slowShard.Close()
+	otherShard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer otherShard.Close()
+
+	limits, err := newShardInflightLimits(1, map[string]int{slowShard.URL: 2})
+	require.NoError(t, err)
+	handler := shardHandler(fakeIndex{
+		logicalcluster.New("root:slow"):  slowShard.URL,
+		logicalcluster.New("root:other"): otherShard.URL,
+	}, &replicaSelector{}, nil, pathLimits{}, nil, withShardInflightLimits(newShardReverseProxy(false), limits))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Log("Saturate the slow shard")
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("/clusters/root:slow/api/v1/namespaces?watch=true")
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("timed out waiting for the requests to reach the slow shard")
+		}
+	}
+
+	t.Log("Requests exceeding the limit of the slow shard are rejected")
+	w := serve("/clusters/root:slow/api/v1/namespaces")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	t.Log("Other shards remain available")
+	w = serve("/clusters/root:other/api/v1/namespaces")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Log("The slow shard accepts requests again once the in-flight requests finished")
+	close(release)
+	wg.Wait()
+	w = serve("/clusters/root:slow/api/v1/namespaces")
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNewShardInflightLimits(t *testing.T) {
+	limits, err := newShardInflightLimits(0, map[string]int{"https://shard-1.example.com:6443": 0})
+	require.NoError(t, err)
+	require.Nil(t, limits, "no shard is limited")
+
+	limits, err = newShardInflightLimits(0, map[string]int{"https://shard-1.example.com:6443": 5})
+	require.NoError(t, err)
+	require.Equal(t, 5, cap(limits.forShard("https://shard-1.example.com:6443")))
+	require.Nil(t, limits.forShard("https://shard-2.example.com:6443"), "shard without limit")
+}
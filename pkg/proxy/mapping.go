@@ -23,7 +23,10 @@ import (
 	"net/http/httputil"
 	"net/url"
 
+	"github.com/kcp-dev/logicalcluster"
+
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/yaml"
 
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
@@ -41,6 +44,9 @@ type PathMapping struct {
 	ProxyClientKey  string `json:"proxy_client_key"`
 	UserHeader      string `json:"user_header,omitempty"`
 	GroupHeader     string `json:"group_header,omitempty"`
+	// DisableHTTP2 forces HTTP/1.1 to the backend, e.g. for backends behind
+	// intermediaries that do not handle HTTP/2 correctly.
+	DisableHTTP2 bool `json:"disable_http2,omitempty"`
 }
 
 func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error) {
@@ -54,6 +60,42 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		return nil, fmt.Errorf("failed to unmarshal mapping file %q: %w", o.MappingFile, err)
 	}
 
+	var breakers *shardCircuitBreakers
+	if o.ShardCircuitBreakerErrorRatio > 0 {
+		breakers = newShardCircuitBreakers(CircuitBreakerConfig{
+			ErrorRatio:   o.ShardCircuitBreakerErrorRatio,
+			MinRequests:  o.ShardCircuitBreakerMinRequests,
+			Window:       o.ShardCircuitBreakerWindow,
+			OpenDuration: o.ShardCircuitBreakerOpenDuration,
+		}, clock.RealClock{})
+	}
+
+	inflightLimits, err := newShardInflightLimits(o.ShardMaxInflightRequests, o.ShardMaxInflightRequestsPerShard)
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts := transportTimeouts{
+		dialTimeout:           o.BackendDialTimeout,
+		keepAlive:             o.BackendKeepAlive,
+		tlsHandshakeTimeout:   o.BackendTLSHandshakeTimeout,
+		responseHeaderTimeout: o.BackendResponseHeaderTimeout,
+		expectContinueTimeout: o.BackendExpectContinueTimeout,
+	}
+
+	errorPages, err := newErrorPages(o)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocklist *clusterBlocklist
+	if o.ClusterBlocklistFile != "" {
+		blocklist, err = newClusterBlocklist(o.ClusterBlocklistFile, o.ClusterBlocklistStatusCode, o.ClusterBlocklistMessage, o.ClusterBlocklistReloadInterval, clock.RealClock{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	mux := http.NewServeMux()
 
 	// TODO: implement proper readyz handler
@@ -61,6 +103,12 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		w.Write([]byte("OK")) // nolint: errcheck
 		w.WriteHeader(http.StatusOK)
 	}))
+	if o.EnableMetrics {
+		mux.Handle(metricsPath, metricsHandler())
+	}
+	if o.EnableIndexDebugHandler {
+		mux.Handle(indexDebugPath, indexDebugHandler(index))
+	}
 
 	for _, m := range mapping {
 		klog.V(2).Infof("Adding mapping %v", m)
@@ -70,21 +118,38 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 			return nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
 		}
 
-		transport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA)
+		transport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA, m.DisableHTTP2, timeouts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create path mapping for path %q: %w", m.Path, err)
 		}
 
 		var handler http.HandlerFunc
 		if m.Path == "/clusters/" {
-			clusterProxy := newShardReverseProxy()
+			clusterProxy := newShardReverseProxy(o.PreserveHost)
 			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
+			var shardProxy http.Handler = clusterProxy
+			selector := &replicaSelector{}
+			if breakers != nil {
+				shardProxy = withShardCircuitBreakers(clusterProxy, breakers)
+				selector.healthy = breakers.healthy
+			}
+			if inflightLimits != nil {
+				// rejected requests are not failures of the shard for the circuit breaker.
+				shardProxy = withShardInflightLimits(shardProxy, inflightLimits)
+			}
+			limits := pathLimits{maxLength: o.MaxRequestPathLength, maxSegments: o.MaxRequestPathSegments}
+			handler = shardHandler(index, selector, errorPages, limits, blocklist, shardProxy)
+			if o.InjectRequestID {
+				handler = withRequestID(handler)
+			}
 		} else {
 			// TODO: handle virtual workspace apiservers per shard
 			proxy := httputil.NewSingleHostReverseProxy(u)
 			proxy.Transport = transport
-			handler = proxy.ServeHTTP
+			handler = withoutClusterHeader(proxy)
+		}
+		if o.EnableGzipCompression {
+			handler = withGzipCompression(handler, o.GzipMinSize)
 		}
 
 		userHeader := "X-Remote-User"
@@ -101,5 +166,18 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		mux.Handle(m.Path, handler)
 	}
 
+	if o.WorkspaceHeader != "" {
+		return withWorkspaceHeader(mux, o.WorkspaceHeader, errorPages), nil
+	}
 	return mux, nil
 }
+
+// withoutClusterHeader strips a client supplied ClusterHeader and logicalcluster.ClusterHeader
+// from requests which are not proxied to the shard of a logical cluster.
+func withoutClusterHeader(delegate http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		req.Header.Del(ClusterHeader)
+		req.Header.Del(logicalcluster.ClusterHeader)
+		delegate.ServeHTTP(w, req)
+	}
+}
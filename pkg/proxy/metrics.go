@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	shardRequestsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "front_proxy",
+			Name:           "shard_requests_total",
+			Help:           "Number of requests proxied to a shard, partitioned by shard and status class of the response, or error if no response was received.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "code"},
+	)
+
+	shardRequestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      "kcp",
+			Subsystem:      "front_proxy",
+			Name:           "shard_request_duration_seconds",
+			Help:           "Time until the response headers of a shard are received, partitioned by shard and status class of the response, or error if no response was received.",
+			Buckets:        []float64{0.005, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "code"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(shardCircuitBreakerState)
+		legacyregistry.MustRegister(shardRequestsTotal)
+		legacyregistry.MustRegister(shardRequestDuration)
+	})
+}
+
+// shardRequestErrorCode is the code label of requests without a response, e.g.
+// because the shard is unreachable.
+const shardRequestErrorCode = "error"
+
+type requestStartKey struct{}
+
+// withRequestStart returns a context carrying the time the request was sent to the shard.
+func withRequestStart(parent context.Context, start time.Time) context.Context {
+	return context.WithValue(parent, requestStartKey{}, start)
+}
+
+// observeShardRequest records a request to a shard with the given code label. The shard label
+// is the shard URL, hence the number of series is bounded by the number of shards.
+func observeShardRequest(req *http.Request, code string) {
+	shard := "unknown"
+	if shardURL := ShardURLFrom(req.Context()); shardURL != nil {
+		shard = shardURL.String()
+	}
+	shardRequestsTotal.WithLabelValues(shard, code).Inc()
+	if start, ok := req.Context().Value(requestStartKey{}).(time.Time); ok {
+		shardRequestDuration.WithLabelValues(shard, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// statusClass returns the class of an HTTP status code, e.g. 2xx.
+func statusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
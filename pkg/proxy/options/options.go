@@ -18,21 +18,100 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 type Options struct {
 	MappingFile string
+
+	ShardCircuitBreakerErrorRatio   float64
+	ShardCircuitBreakerMinRequests  int
+	ShardCircuitBreakerWindow       time.Duration
+	ShardCircuitBreakerOpenDuration time.Duration
+
+	ShardMaxInflightRequests         int
+	ShardMaxInflightRequestsPerShard map[string]int
+
+	ForbiddenTemplateFile    string
+	NotFoundTemplateFile     string
+	ErrorTemplateContentType string
+
+	BackendDialTimeout           time.Duration
+	BackendKeepAlive             time.Duration
+	BackendTLSHandshakeTimeout   time.Duration
+	BackendResponseHeaderTimeout time.Duration
+	BackendExpectContinueTimeout time.Duration
+
+	MaxRequestPathLength   int
+	MaxRequestPathSegments int
+
+	ClusterBlocklistFile           string
+	ClusterBlocklistStatusCode     int
+	ClusterBlocklistMessage        string
+	ClusterBlocklistReloadInterval time.Duration
+
+	PreserveHost    bool
+	InjectRequestID bool
+	WorkspaceHeader string
+
+	EnableIndexDebugHandler bool
+	EnableMetrics           bool
+
+	EnableGzipCompression bool
+	GzipMinSize           int
 }
 
 func NewOptions() *Options {
-	o := &Options{}
+	o := &Options{
+		ShardCircuitBreakerMinRequests:  20,
+		ShardCircuitBreakerWindow:       time.Minute,
+		ShardCircuitBreakerOpenDuration: 30 * time.Second,
+		ErrorTemplateContentType:        "application/json",
+		GzipMinSize:                     1024,
+		MaxRequestPathLength:            8192,
+		BackendDialTimeout:              30 * time.Second,
+		BackendKeepAlive:                30 * time.Second,
+		BackendTLSHandshakeTimeout:      10 * time.Second,
+		BackendExpectContinueTimeout:    time.Second,
+		MaxRequestPathSegments:          128,
+		ClusterBlocklistStatusCode:      503,
+		ClusterBlocklistMessage:         "the workspace is temporarily unavailable",
+		ClusterBlocklistReloadInterval:  10 * time.Second,
+	}
 	return o
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
+	fs.Float64Var(&o.ShardCircuitBreakerErrorRatio, "shard-circuit-breaker-error-ratio", o.ShardCircuitBreakerErrorRatio, "Ratio of 5xx responses of a shard within the circuit breaker window at which requests to the shard are rejected for the circuit breaker open duration. 0 disables the circuit breaker.")
+	fs.IntVar(&o.ShardCircuitBreakerMinRequests, "shard-circuit-breaker-min-requests", o.ShardCircuitBreakerMinRequests, "Minimum number of requests to a shard within the circuit breaker window before the error ratio is evaluated.")
+	fs.DurationVar(&o.ShardCircuitBreakerWindow, "shard-circuit-breaker-window", o.ShardCircuitBreakerWindow, "Interval over which the requests to a shard are counted by the circuit breaker.")
+	fs.DurationVar(&o.ShardCircuitBreakerOpenDuration, "shard-circuit-breaker-open-duration", o.ShardCircuitBreakerOpenDuration, "Time requests to a shard are rejected by an open circuit breaker before a probe request is let through.")
+	fs.IntVar(&o.ShardMaxInflightRequests, "shard-max-inflight-requests", o.ShardMaxInflightRequests, "Maximum number of requests proxied to a shard at the same time, including long-running requests like watches. Further requests to the shard are rejected with 503 Service Unavailable. 0 means unlimited.")
+	fs.StringToIntVar(&o.ShardMaxInflightRequestsPerShard, "shard-max-inflight-requests-per-shard", o.ShardMaxInflightRequestsPerShard, "Maximum number of requests proxied to the shard with the given URL at the same time, overriding --shard-max-inflight-requests, e.g. https://shard-1.example.com:6443=500. 0 means unlimited.")
+	fs.StringVar(&o.ForbiddenTemplateFile, "forbidden-template-file", o.ForbiddenTemplateFile, "Go text/template file rendering the body of responses for unknown or not permitted logical clusters. The template is executed with .StatusCode, .Reason, .Message, .ClusterName, .Path and .RequestID, and a json function quoting values. If empty, a Kubernetes Status is returned.")
+	fs.StringVar(&o.NotFoundTemplateFile, "not-found-template-file", o.NotFoundTemplateFile, "Go text/template file rendering the body of responses for paths not served by the proxy, executed with the same data as --forbidden-template-file. If empty, a plain text response is returned.")
+	fs.StringVar(&o.ErrorTemplateContentType, "error-template-content-type", o.ErrorTemplateContentType, "Content type of the responses rendered from --forbidden-template-file and --not-found-template-file.")
+	fs.DurationVar(&o.BackendDialTimeout, "backend-dial-timeout", o.BackendDialTimeout, "Timeout of establishing connections to the backends. 0 means no timeout.")
+	fs.DurationVar(&o.BackendKeepAlive, "backend-keep-alive", o.BackendKeepAlive, "Interval of TCP keep-alive probes on connections to the backends. 0 means the default of the operating system.")
+	fs.DurationVar(&o.BackendTLSHandshakeTimeout, "backend-tls-handshake-timeout", o.BackendTLSHandshakeTimeout, "Timeout of the TLS handshake with the backends. 0 means no timeout.")
+	fs.DurationVar(&o.BackendResponseHeaderTimeout, "backend-response-header-timeout", o.BackendResponseHeaderTimeout, "Time to wait for the response headers of a backend after sending a request. 0 means no timeout.")
+	fs.DurationVar(&o.BackendExpectContinueTimeout, "backend-expect-continue-timeout", o.BackendExpectContinueTimeout, "Time to wait for the first response headers of a backend after sending the headers of a request with an \"Expect: 100-continue\" header. 0 means the body is sent immediately.")
+	fs.IntVar(&o.MaxRequestPathLength, "max-request-path-length", o.MaxRequestPathLength, "Maximum length in bytes of the escaped path of requests to logical clusters. Longer paths are rejected with 414 URI Too Long. 0 means unlimited.")
+	fs.IntVar(&o.MaxRequestPathSegments, "max-request-path-segments", o.MaxRequestPathSegments, "Maximum number of /-separated segments of the path of requests to logical clusters. Paths with more segments are rejected with 400 Bad Request. 0 means unlimited.")
+	fs.StringVar(&o.ClusterBlocklistFile, "cluster-blocklist-file", o.ClusterBlocklistFile, "File listing logical clusters, one per line, whose requests are rejected with --cluster-blocklist-status-code before they are proxied, e.g. to quarantine a workspace during an incident. Empty lines and lines starting with # are ignored. The file is re-read when it changes.")
+	fs.IntVar(&o.ClusterBlocklistStatusCode, "cluster-blocklist-status-code", o.ClusterBlocklistStatusCode, "HTTP status code of responses to requests for clusters in --cluster-blocklist-file.")
+	fs.StringVar(&o.ClusterBlocklistMessage, "cluster-blocklist-message", o.ClusterBlocklistMessage, "Message of responses to requests for clusters in --cluster-blocklist-file.")
+	fs.DurationVar(&o.ClusterBlocklistReloadInterval, "cluster-blocklist-reload-interval", o.ClusterBlocklistReloadInterval, "Minimum interval between checks of --cluster-blocklist-file for changes.")
+	fs.BoolVar(&o.PreserveHost, "preserve-host", o.PreserveHost, "Forward the Host header of the client to the shards instead of setting it to the host of the shard URL.")
+	fs.BoolVar(&o.InjectRequestID, "inject-request-id", o.InjectRequestID, "Forward the X-Request-Id header of requests to the shards, generating it if not set by the client, echo it back in the response and add it to the proxy log lines of the request.")
+	fs.BoolVar(&o.EnableIndexDebugHandler, "enable-index-debug-handler", o.EnableIndexDebugHandler, "Serve the logical clusters known to the proxy and the shard URLs they resolve to as JSON under /debug/index, to clients authenticated with a client certificate in the system:masters group.")
+	fs.BoolVar(&o.EnableMetrics, "enable-metrics", o.EnableMetrics, "Serve the metrics of the proxy under /metrics, to clients authenticated with a client certificate in the system:masters group.")
+	fs.BoolVar(&o.EnableGzipCompression, "enable-gzip-compression", o.EnableGzipCompression, "Compress responses with gzip for clients accepting it, unless the response is encoded already or streamed like a watch.")
+	fs.IntVar(&o.GzipMinSize, "gzip-min-size", o.GzipMinSize, "Minimum size in bytes of response bodies compressed with --enable-gzip-compression. Smaller bodies are passed through uncompressed.")
+	fs.StringVar(&o.WorkspaceHeader, "workspace-header", o.WorkspaceHeader, "Header conveying the logical cluster of API requests under /api and /apis without /clusters/<name> in the path, e.g. X-Kcp-Workspace. The path takes precedence over the header, and other paths are not rerouted. If empty, the logical cluster is only taken from the path.")
 }
 
 func (o *Options) Complete() error {
@@ -45,6 +124,62 @@ func (o *Options) Validate() []error {
 	if o.MappingFile == "" {
 		errs = append(errs, fmt.Errorf("--mapping-file is required"))
 	}
+	if o.ShardCircuitBreakerErrorRatio < 0 || o.ShardCircuitBreakerErrorRatio > 1 {
+		errs = append(errs, fmt.Errorf("--shard-circuit-breaker-error-ratio must be between 0 and 1"))
+	}
+	if o.ShardCircuitBreakerErrorRatio > 0 {
+		if o.ShardCircuitBreakerMinRequests < 1 {
+			errs = append(errs, fmt.Errorf("--shard-circuit-breaker-min-requests must be at least 1"))
+		}
+		if o.ShardCircuitBreakerWindow <= 0 {
+			errs = append(errs, fmt.Errorf("--shard-circuit-breaker-window must be positive"))
+		}
+		if o.ShardCircuitBreakerOpenDuration <= 0 {
+			errs = append(errs, fmt.Errorf("--shard-circuit-breaker-open-duration must be positive"))
+		}
+	}
+	if o.ShardMaxInflightRequests < 0 {
+		errs = append(errs, fmt.Errorf("--shard-max-inflight-requests must not be negative"))
+	}
+	for shard, limit := range o.ShardMaxInflightRequestsPerShard {
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("--shard-max-inflight-requests-per-shard must not be negative for shard %q", shard))
+		}
+	}
+	for _, timeout := range []struct {
+		flag     string
+		duration time.Duration
+	}{
+		{"--backend-dial-timeout", o.BackendDialTimeout},
+		{"--backend-keep-alive", o.BackendKeepAlive},
+		{"--backend-tls-handshake-timeout", o.BackendTLSHandshakeTimeout},
+		{"--backend-response-header-timeout", o.BackendResponseHeaderTimeout},
+		{"--backend-expect-continue-timeout", o.BackendExpectContinueTimeout},
+	} {
+		if timeout.duration < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", timeout.flag))
+		}
+	}
+	if o.MaxRequestPathLength < 0 {
+		errs = append(errs, fmt.Errorf("--max-request-path-length must not be negative"))
+	}
+	if o.MaxRequestPathSegments < 0 {
+		errs = append(errs, fmt.Errorf("--max-request-path-segments must not be negative"))
+	}
+	if o.ClusterBlocklistFile != "" {
+		if o.ClusterBlocklistStatusCode < 400 || o.ClusterBlocklistStatusCode > 599 {
+			errs = append(errs, fmt.Errorf("--cluster-blocklist-status-code must be between 400 and 599"))
+		}
+		if o.ClusterBlocklistReloadInterval <= 0 {
+			errs = append(errs, fmt.Errorf("--cluster-blocklist-reload-interval must be positive"))
+		}
+	}
+	if o.GzipMinSize < 0 {
+		errs = append(errs, fmt.Errorf("--gzip-min-size must not be negative"))
+	}
+	if (o.ForbiddenTemplateFile != "" || o.NotFoundTemplateFile != "") && o.ErrorTemplateContentType == "" {
+		errs = append(errs, fmt.Errorf("--error-template-content-type is required with error templates"))
+	}
 
 	return errs
 }
@@ -22,16 +22,33 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/runtime"
 	userinfo "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
 )
 
-func newTransport(clientCert, clientKeyFile, caFile string) (*http.Transport, error) {
+// transportTimeouts configures the dialing and the timeouts of the transport to a backend.
+// Zero values mean no timeout, except for keepAlive, for which zero means the default
+// keep-alive period of net.Dialer.
+type transportTimeouts struct {
+	dialTimeout           time.Duration
+	keepAlive             time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	expectContinueTimeout time.Duration
+}
+
+// newTransport returns a transport to a backend shard authenticating with the given
+// client certificate. HTTP/2 is negotiated via ALPN when the backend supports it,
+// falling back to HTTP/1.1 otherwise. If disableHTTP2 is true, only HTTP/1.1 is used.
+func newTransport(clientCert, clientKeyFile, caFile string, disableHTTP2 bool, timeouts transportTimeouts) (*http.Transport, error) {
 	caCert, err := ioutil.ReadFile(caFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CA file %q: %w", caFile, err)
@@ -46,18 +63,38 @@ func newTransport(clientCert, clientKeyFile, caFile string) (*http.Transport, er
 	}
 
 	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeouts.dialTimeout,
+		KeepAlive: timeouts.keepAlive,
+	}).DialContext
+	transport.TLSHandshakeTimeout = timeouts.tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = timeouts.responseHeaderTimeout
+	transport.ExpectContinueTimeout = timeouts.expectContinueTimeout
 	transport.TLSClientConfig = &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		RootCAs:      caCertPool,
 	}
 
+	if disableHTTP2 {
+		// a non-nil, empty TLSNextProto map disables HTTP/2 support
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else {
+		// a custom TLSClientConfig disables HTTP/2 unless explicitly forced
+		transport.ForceAttemptHTTP2 = true
+	}
+
 	return transport, nil
 }
 
 // WithProxyAuthHeaders does client cert termination by extracting the user and groups and
-// passing them through access headers to the shard.
+// passing them through access headers to the shard. Values of these headers supplied by the
+// client are removed first, such that clients cannot claim another identity. A user name or
+// group which is not a valid header value is not passed.
 func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(UserHeader)
+		r.Header.Del(GroupHeader)
 		if u, ok := request.UserFrom(r.Context()); ok {
 			appendClientCertAuthHeaders(r.Header, u, UserHeader, GroupHeader)
 		}
@@ -67,15 +104,45 @@ func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader str
 }
 
 func appendClientCertAuthHeaders(header http.Header, user userinfo.Info, UserHeader, GroupHeader string) {
+	if !isValidHeaderValue(user.GetName()) {
+		klog.Warningf("Not passing user %q with invalid header value characters to the shard", user.GetName())
+		return
+	}
 	header.Set(UserHeader, user.GetName())
 
 	for _, group := range user.GetGroups() {
+		if !isValidHeaderValue(group) {
+			klog.Warningf("Not passing group %q of user %q with invalid header value characters to the shard", group, user.GetName())
+			continue
+		}
 		header.Add(GroupHeader, group)
 	}
 }
 
-func newShardReverseProxy() *httputil.ReverseProxy {
+// isValidHeaderValue returns whether value is non-empty and has no control characters
+// other than horizontal tab, i.e. cannot split or terminate the header.
+func isValidHeaderValue(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// newShardReverseProxy returns a reverse proxy to the shard URL in the request context.
+// The Host header is set to the host of the shard URL, e.g. for shards doing virtual
+// hosting, unless preserveHost is true. The number, latency and status class of the
+// responses of every shard are recorded in metrics.
+func newShardReverseProxy(preserveHost bool) *httputil.ReverseProxy {
+	registerMetrics()
+
 	director := func(req *http.Request) {
+		*req = *req.WithContext(withRequestStart(req.Context(), time.Now()))
+
 		shardURL := ShardURLFrom(req.Context())
 		if shardURL == nil {
 			// should not happen if wiring is correct
@@ -87,13 +154,31 @@ func newShardReverseProxy() *httputil.ReverseProxy {
 
 		req.URL.Scheme = shardURL.Scheme
 		req.URL.Host = shardURL.Host
+		if !preserveHost {
+			req.Host = shardURL.Host
+		}
+	}
+	return &httputil.ReverseProxy{
+		Director: director,
+		ModifyResponse: func(resp *http.Response) error {
+			observeShardRequest(resp.Request, statusClass(resp.StatusCode))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			observeShardRequest(req, shardRequestErrorCode)
+			// like the default error handler of httputil.ReverseProxy
+			klog.Errorf("Failed to proxy %q to shard %s: %v%s", req.URL.Path, req.URL.Host, err, logRequestID(req.Context()))
+			w.WriteHeader(http.StatusBadGateway)
+		},
 	}
-	return &httputil.ReverseProxy{Director: director}
 }
 
 type shardKey int
 
-const shardContextKey shardKey = iota
+const (
+	shardContextKey shardKey = iota
+	requestIDContextKey
+)
 
 func WithShardURL(parent context.Context, shardURL *url.URL) context.Context {
 	return context.WithValue(parent, shardContextKey, shardURL)
@@ -106,3 +191,23 @@ func ShardURLFrom(ctx context.Context) *url.URL {
 	}
 	return shardURL
 }
+
+// WithRequestID returns a context carrying the ID of the request, for logging.
+func WithRequestID(parent context.Context, requestID string) context.Context {
+	return context.WithValue(parent, requestIDContextKey, requestID)
+}
+
+// RequestIDFrom returns the ID of the request in the context, or an empty string.
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// logRequestID returns a suffix for log lines identifying the request of the
+// context, or an empty string if the request has no ID.
+func logRequestID(ctx context.Context) string {
+	if requestID := RequestIDFrom(ctx); requestID != "" {
+		return fmt.Sprintf(" (request %s)", requestID)
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	userinfo "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+// writeTransportFiles writes the CA of the given TLS server and a self-signed
+// client certificate into dir, returning the paths of the client cert, key and CA.
+func writeTransportFiles(t *testing.T, dir string, server *httptest.Server) (string, string, string) {
+	t.Helper()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(caFile, caPEM, 0600))
+
+	certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey("proxy-client", nil, nil)
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	return certFile, keyFile, caFile
+}
+
+func TestNewTransportProtocol(t *testing.T) {
+	tests := map[string]struct {
+		disableHTTP2 bool
+		wantProto    string
+	}{
+		"h2-capable backend negotiates HTTP/2": {
+			wantProto: "HTTP/2.0",
+		},
+		"HTTP/2 disabled falls back to HTTP/1.1": {
+			disableHTTP2: true,
+			wantProto:    "HTTP/1.1",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.Proto)) // nolint: errcheck
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+			defer server.Close()
+
+			certFile, keyFile, caFile := writeTransportFiles(t, t.TempDir(), server)
+
+			transport, err := newTransport(certFile, keyFile, caFile, tc.disableHTTP2, transportTimeouts{})
+			require.NoError(t, err)
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantProto, resp.Proto)
+			require.Equal(t, tc.wantProto, string(body))
+		})
+	}
+}
+
+func TestNewTransportTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	certFile, keyFile, caFile := writeTransportFiles(t, t.TempDir(), server)
+
+	transport, err := newTransport(certFile, keyFile, caFile, false, transportTimeouts{
+		dialTimeout:           5 * time.Second,
+		keepAlive:             time.Minute,
+		tlsHandshakeTimeout:   3 * time.Second,
+		responseHeaderTimeout: 100 * time.Millisecond,
+		expectContinueTimeout: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	defer transport.CloseIdleConnections()
+
+	require.NotNil(t, transport.DialContext)
+	require.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+	require.Equal(t, 100*time.Millisecond, transport.ResponseHeaderTimeout)
+	require.Equal(t, 2*time.Second, transport.ExpectContinueTimeout)
+
+	t.Log("A backend not sending response headers in time fails the request")
+	_, err = (&http.Client{Transport: transport}).Get(server.URL) // nolint: bodyclose
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timeout awaiting response headers")
+}
+
+func TestShardReverseProxyHost(t *testing.T) {
+	tests := map[string]struct {
+		preserveHost bool
+		wantHost     func(shardURL *url.URL) string
+	}{
+		"Host is rewritten to the shard host": {
+			wantHost: func(shardURL *url.URL) string { return shardURL.Host },
+		},
+		"original Host is preserved": {
+			preserveHost: true,
+			wantHost:     func(*url.URL) string { return "kcp.example.com" },
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.Host)) // nolint: errcheck
+			}))
+			defer shard.Close()
+			shardURL, err := url.Parse(shard.URL)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "https://kcp.example.com/clusters/root:org/api", nil)
+			req = req.WithContext(WithShardURL(req.Context(), shardURL))
+			w := httptest.NewRecorder()
+			newShardReverseProxy(tc.preserveHost).ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tc.wantHost(shardURL), w.Body.String())
+		})
+	}
+}
+
+func TestShardReverseProxyMetrics(t *testing.T) {
+	backendStatus := http.StatusOK
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(backendStatus)
+	}))
+	defer shard.Close()
+	shardURL, err := url.Parse(shard.URL)
+	require.NoError(t, err)
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachableURL, err := url.Parse(unreachable.URL)
+	require.NoError(t, err)
+	unreachable.Close()
+
+	proxy := newShardReverseProxy(false)
+	request := func(shardURL *url.URL) int {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api", nil)
+		req = req.WithContext(WithShardURL(req.Context(), shardURL))
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, req)
+		return w.Code
+	}
+	requireCount := func(shardURL *url.URL, code string, want int) {
+		t.Helper()
+		count, err := testutil.GetCounterMetricValue(shardRequestsTotal.WithLabelValues(shardURL.String(), code))
+		require.NoError(t, err)
+		require.Equal(t, float64(want), count)
+		observations, err := testutil.GetHistogramMetricCount(shardRequestDuration.WithLabelValues(shardURL.String(), code))
+		require.NoError(t, err)
+		require.Equal(t, uint64(want), observations)
+	}
+
+	t.Log("Successful responses are counted as 2xx")
+	require.Equal(t, http.StatusOK, request(shardURL))
+	require.Equal(t, http.StatusOK, request(shardURL))
+	requireCount(shardURL, "2xx", 2)
+	requireCount(shardURL, "5xx", 0)
+
+	t.Log("Server errors are counted as 5xx")
+	backendStatus = http.StatusServiceUnavailable
+	require.Equal(t, http.StatusServiceUnavailable, request(shardURL))
+	requireCount(shardURL, "2xx", 2)
+	requireCount(shardURL, "5xx", 1)
+
+	t.Log("Unreachable shards are counted as errors")
+	require.Equal(t, http.StatusBadGateway, request(unreachableURL))
+	requireCount(unreachableURL, "error", 1)
+	requireCount(shardURL, "error", 0)
+}
+
+func TestProxyAuthHeaders(t *testing.T) {
+	tests := map[string]struct {
+		user          userinfo.Info
+		clientHeaders http.Header
+		wantUser      []string
+		wantGroups    []string
+	}{
+		"authenticated user and groups are forwarded": {
+			user:       &userinfo.DefaultInfo{Name: "alice", Groups: []string{"team-a", "system:authenticated"}},
+			wantUser:   []string{"alice"},
+			wantGroups: []string{"team-a", "system:authenticated"},
+		},
+		"client supplied values are replaced": {
+			user: &userinfo.DefaultInfo{Name: "alice", Groups: []string{"team-a"}},
+			clientHeaders: http.Header{
+				"X-Remote-User":  []string{"admin"},
+				"X-Remote-Group": []string{"system:masters"},
+			},
+			wantUser:   []string{"alice"},
+			wantGroups: []string{"team-a"},
+		},
+		"client supplied values are stripped without authenticated user": {
+			clientHeaders: http.Header{
+				"X-Remote-User":  []string{"admin"},
+				"X-Remote-Group": []string{"system:masters"},
+			},
+		},
+		"groups with invalid header characters are not forwarded": {
+			user:       &userinfo.DefaultInfo{Name: "alice", Groups: []string{"team-a", "team-b\r\nX-Remote-Group: system:masters"}},
+			wantUser:   []string{"alice"},
+			wantGroups: []string{"team-a"},
+		},
+		"user with invalid header characters is not forwarded": {
+			user: &userinfo.DefaultInfo{Name: "alice\nadmin", Groups: []string{"team-a"}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			received := make(chan http.Header, 1)
+			shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.Header.Clone()
+			}))
+			defer shard.Close()
+
+			handler := WithProxyAuthHeaders(shardHandler(fakeIndex{
+				logicalcluster.New("root:org"): shard.URL,
+			}, &replicaSelector{}, nil, pathLimits{}, nil, newShardReverseProxy(false)), "X-Remote-User", "X-Remote-Group")
+
+			req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/api", nil)
+			for key, values := range tc.clientHeaders {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{})
+			if tc.user != nil {
+				ctx = request.WithUser(ctx, tc.user)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req.WithContext(ctx))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			header := <-received
+			require.Equal(t, tc.wantUser, header.Values("X-Remote-User"))
+			require.Equal(t, tc.wantGroups, header.Values("X-Remote-Group"))
+		})
+	}
+}
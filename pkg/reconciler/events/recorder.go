@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events records Kubernetes Events for objects in logical clusters.
+package events
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+)
+
+// clusterAnnotationKey is the annotation of an event conveying the logical cluster it is created in.
+const clusterAnnotationKey = "events.kcp.dev/cluster"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kcpscheme.AddToScheme(scheme))
+}
+
+// NewRecorder returns an EventRecorder for Kubernetes and kcp objects of any logical cluster.
+// The events are created in the logical cluster of the object they are about, like in the
+// namespace of the object, or in the default namespace for cluster-scoped objects.
+func NewRecorder(kubeClusterClient kubernetesclient.ClusterInterface, component string) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&clusterEventSink{kubeClusterClient: kubeClusterClient})
+	return &clusterRecorder{
+		delegate: broadcaster.NewRecorder(scheme, corev1.EventSource{Component: component}),
+	}
+}
+
+// clusterRecorder annotates events with the logical cluster of their object, for
+// clusterEventSink to create them in that logical cluster.
+type clusterRecorder struct {
+	delegate record.EventRecorder
+}
+
+func (r *clusterRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r *clusterRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r *clusterRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	withCluster := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		withCluster[k] = v
+	}
+	if obj, ok := object.(metav1.Object); ok {
+		withCluster[clusterAnnotationKey] = logicalcluster.From(obj).String()
+	}
+	r.delegate.AnnotatedEventf(object, withCluster, eventtype, reason, messageFmt, args...)
+}
+
+// clusterEventSink writes events to the logical cluster of their cluster annotation.
+type clusterEventSink struct {
+	kubeClusterClient kubernetesclient.ClusterInterface
+}
+
+func (s *clusterEventSink) clusterClient(event *corev1.Event) kubernetesclient.Interface {
+	return s.kubeClusterClient.Cluster(logicalcluster.New(event.Annotations[clusterAnnotationKey]))
+}
+
+func (s *clusterEventSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	return s.clusterClient(event).CoreV1().Events(event.Namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+}
+
+func (s *clusterEventSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	return s.clusterClient(event).CoreV1().Events(event.Namespace).Update(context.TODO(), event, metav1.UpdateOptions{})
+}
+
+func (s *clusterEventSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	return s.clusterClient(event).CoreV1().Events(event.Namespace).Patch(context.TODO(), event.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+}
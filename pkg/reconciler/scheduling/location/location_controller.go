@@ -158,6 +158,12 @@ func (c *controller) enqueueSyncTarget(obj interface{}) {
 	for _, domain := range domains {
 		c.enqueueLocation(domain)
 	}
+	if len(domains) == 0 {
+		// without Locations, the key of the SyncTarget is processed like a deleted Location
+		// to maintain its LocationMember condition.
+		klog.Infof("Queueing SyncTarget %q of workspace without Locations", key)
+		c.queue.Add(key)
+	}
 }
 
 // Start starts the controller, which stops when ctx.Done() is closed.
@@ -212,7 +218,8 @@ func (c *controller) process(ctx context.Context, key string) error {
 	obj, err := c.locationLister.Get(key)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil // object deleted before we handled it
+			// object deleted before we handled it, which may change the membership of the sync targets.
+			return c.reconcileSyncTargetMembership(ctx, clusterName)
 		}
 		return err
 	}
@@ -222,6 +229,9 @@ func (c *controller) process(ctx context.Context, key string) error {
 	if err := c.reconcile(ctx, obj); err != nil {
 		return err
 	}
+	if err := c.reconcileSyncTargetMembership(ctx, clusterName); err != nil {
+		return err
+	}
 
 	// If the object being reconciled changed as a result, update it.
 	if !equality.Semantic.DeepEqual(old.Status, obj.Status) {
@@ -121,6 +121,33 @@ func (c *controller) reconcile(ctx context.Context, location *schedulingv1alpha1
 	return utilserrors.NewAggregate(errs)
 }
 
+// reconcileSyncTargetMembership maintains the LocationMember condition of the SyncTargets in the
+// workspace. Unlike the reconcilers of a Location, it also runs when the Location got deleted.
+func (c *controller) reconcileSyncTargetMembership(ctx context.Context, clusterName logicalcluster.Name) error {
+	r := &syncTargetMembershipReconciler{
+		listLocations:          c.listLocations,
+		listSyncTargets:        c.listSyncTarget,
+		updateSyncTargetStatus: c.updateSyncTargetStatus,
+	}
+	return r.reconcile(ctx, clusterName)
+}
+
+func (c *controller) listLocations(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error) {
+	items, err := c.locationIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.Location, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.Location))
+	}
+	return ret, nil
+}
+
+func (c *controller) updateSyncTargetStatus(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+	return c.kcpClusterClient.Cluster(clusterName).WorkloadV1alpha1().SyncTargets().UpdateStatus(ctx, syncTarget, metav1.UpdateOptions{})
+}
+
 func (c *controller) listSyncTarget(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
 	items, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
 	if err != nil {
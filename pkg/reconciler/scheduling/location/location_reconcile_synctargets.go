@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package location
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// syncTargetMembershipReconciler maintains the LocationMember condition of the SyncTargets
+// in a workspace, such that SyncTargets which never receive workloads because their labels
+// match no Location are visible.
+type syncTargetMembershipReconciler struct {
+	listLocations          func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	listSyncTargets        func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
+	updateSyncTargetStatus func(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error)
+}
+
+func (r *syncTargetMembershipReconciler) reconcile(ctx context.Context, clusterName logicalcluster.Name) error {
+	locations, err := r.listLocations(clusterName)
+	if err != nil {
+		return err
+	}
+	syncTargets, err := r.listSyncTargets(clusterName)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	members := sets.NewString()
+	for _, location := range locations {
+		// a location with an invalid selector selects no sync target.
+		locationSyncTargets, err := LocationSyncTargets(syncTargets, location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, syncTarget := range locationSyncTargets {
+			members.Insert(syncTarget.Name)
+		}
+	}
+
+	for _, syncTarget := range syncTargets {
+		updated := syncTarget.DeepCopy()
+		if members.Has(syncTarget.Name) {
+			conditions.MarkTrue(updated, workloadv1alpha1.LocationMember)
+		} else {
+			conditions.MarkFalse(updated,
+				workloadv1alpha1.LocationMember,
+				workloadv1alpha1.NoMatchingLocationReason,
+				conditionsv1alpha1.ConditionSeverityWarning,
+				"The labels of the SyncTarget match the instance selector of none of the %d Locations in the workspace", len(locations))
+		}
+
+		if equality.Semantic.DeepEqual(syncTarget.Status, updated.Status) {
+			continue
+		}
+		klog.V(2).Infof("Updating %s condition of SyncTarget %s|%s", workloadv1alpha1.LocationMember, clusterName, syncTarget.Name)
+		if _, err := r.updateSyncTargetStatus(ctx, clusterName, updated); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.NewAggregate(errs)
+}
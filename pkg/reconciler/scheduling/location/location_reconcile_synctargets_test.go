@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package location
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncTargetMembershipReconciler(t *testing.T) {
+	clusterName := logicalcluster.New("root:org:ws")
+	newSyncTarget := func(name string, labels map[string]string) *workloadv1alpha1.SyncTarget {
+		return &workloadv1alpha1.SyncTarget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				ClusterName: clusterName.String(),
+				Labels:      labels,
+			},
+		}
+	}
+	syncTargets := map[string]*workloadv1alpha1.SyncTarget{
+		"us-east1":   newSyncTarget("us-east1", map[string]string{"region": "us-east1"}),
+		"mislabeled": newSyncTarget("mislabeled", map[string]string{"regoin": "us-east1"}),
+	}
+	locations := []*schedulingv1alpha1.Location{{
+		ObjectMeta: metav1.ObjectMeta{Name: "us", ClusterName: clusterName.String()},
+		Spec: schedulingv1alpha1.LocationSpec{
+			InstanceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east1", "us-west1"}},
+			}},
+		},
+	}}
+
+	var updates int
+	r := &syncTargetMembershipReconciler{
+		listLocations: func(logicalcluster.Name) ([]*schedulingv1alpha1.Location, error) {
+			return locations, nil
+		},
+		listSyncTargets: func(logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+			return []*workloadv1alpha1.SyncTarget{syncTargets["us-east1"], syncTargets["mislabeled"]}, nil
+		},
+		updateSyncTargetStatus: func(_ context.Context, _ logicalcluster.Name, updated *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+			updates++
+			syncTargets[updated.Name] = updated
+			return updated, nil
+		},
+	}
+	ctx := context.Background()
+
+	t.Log("The sync target whose labels match no location is not a member")
+	require.NoError(t, r.reconcile(ctx, clusterName))
+	require.True(t, conditions.IsTrue(syncTargets["us-east1"], workloadv1alpha1.LocationMember))
+	require.True(t, conditions.IsFalse(syncTargets["mislabeled"], workloadv1alpha1.LocationMember))
+	require.Equal(t, workloadv1alpha1.NoMatchingLocationReason, conditions.GetReason(syncTargets["mislabeled"], workloadv1alpha1.LocationMember))
+	require.Equal(t, 2, updates)
+
+	t.Log("Unchanged conditions are not updated again")
+	require.NoError(t, r.reconcile(ctx, clusterName))
+	require.Equal(t, 2, updates)
+
+	t.Log("The sync target is relabeled")
+	syncTargets["mislabeled"].Labels = map[string]string{"region": "us-west1"}
+	require.NoError(t, r.reconcile(ctx, clusterName))
+	require.True(t, conditions.IsTrue(syncTargets["mislabeled"], workloadv1alpha1.LocationMember))
+	require.Equal(t, 3, updates)
+
+	t.Log("Without locations, no sync target is a member")
+	locations = nil
+	require.NoError(t, r.reconcile(ctx, clusterName))
+	require.True(t, conditions.IsFalse(syncTargets["us-east1"], workloadv1alpha1.LocationMember))
+	require.True(t, conditions.IsFalse(syncTargets["mislabeled"], workloadv1alpha1.LocationMember))
+	require.Equal(t, 5, updates)
+}
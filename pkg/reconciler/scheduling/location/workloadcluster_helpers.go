@@ -20,8 +20,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -46,17 +51,37 @@ func LocationSyncTargets(syncTargets []*workloadv1alpha1.SyncTarget, location *s
 	return ret, nil
 }
 
-// FilterReady returns the ready sync targets.
+// FilterReady returns the ready sync targets which are not cordoned, and which sync workloads,
+// i.e. are not in Import mode.
 func FilterReady(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1.SyncTarget {
+	ready := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, wc := range FilterReadyIncludingCordoned(syncTargets) {
+		if !IsCordoned(wc) {
+			ready = append(ready, wc)
+		}
+	}
+	return ready
+}
+
+// FilterReadyIncludingCordoned returns the ready sync targets which sync workloads, i.e. are
+// not in Import mode, including cordoned ones. Cordoned sync targets keep the workloads
+// scheduled to them.
+func FilterReadyIncludingCordoned(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1.SyncTarget {
 	ready := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
 	for _, wc := range syncTargets {
-		if conditions.IsTrue(wc, conditionsapi.ReadyCondition) && !wc.Spec.Unschedulable {
+		if conditions.IsTrue(wc, conditionsapi.ReadyCondition) && wc.Spec.Mode != workloadv1alpha1.SyncTargetModeImport {
 			ready = append(ready, wc)
 		}
 	}
 	return ready
 }
 
+// IsCordoned returns whether the sync target does not get new workloads, either through
+// spec.unschedulable or through an active maintenance window.
+func IsCordoned(syncTarget *workloadv1alpha1.SyncTarget) bool {
+	return syncTarget.Spec.Unschedulable || conditions.IsFalse(syncTarget, workloadv1alpha1.OutsideMaintenanceWindow)
+}
+
 // FilterNonEvicting filters out the evicting sync targets.
 func FilterNonEvicting(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1.SyncTarget {
 	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
@@ -68,3 +93,118 @@ func FilterNonEvicting(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1
 	}
 	return ret
 }
+
+// SchedulableAllocatable returns the allocatable resources of the sync target, capped at
+// its capacity. A syncer reporting more allocatable than capacity for a resource is
+// inconsistent, and the capacity is the more conservative value to schedule against.
+func SchedulableAllocatable(syncTarget *workloadv1alpha1.SyncTarget) corev1.ResourceList {
+	if syncTarget.Status.Allocatable == nil {
+		return nil
+	}
+
+	ret := make(corev1.ResourceList, len(*syncTarget.Status.Allocatable))
+	for name, allocatable := range *syncTarget.Status.Allocatable {
+		ret[name] = allocatable
+		if syncTarget.Status.Capacity == nil {
+			continue
+		}
+		if capacity, found := (*syncTarget.Status.Capacity)[name]; found && allocatable.Cmp(capacity) > 0 {
+			ret[name] = capacity
+		}
+	}
+	return ret
+}
+
+// ScheduledSyncTargets returns the names of the sync targets the given namespaces are synced to,
+// not including those they are being removed from.
+func ScheduledSyncTargets(nss []*corev1.Namespace) sets.String {
+	ret := sets.NewString()
+	for _, ns := range nss {
+		for key := range ns.Labels {
+			syncTarget, ok := workloadv1alpha1.ParseClusterResourceStateLabel(key)
+			if !ok {
+				continue
+			}
+			if _, removing := ns.Annotations[workloadv1alpha1.DeletionAnnotation(syncTarget)]; removing {
+				continue
+			}
+			ret.Insert(syncTarget)
+		}
+	}
+	return ret
+}
+
+// FilterCandidates returns the sync targets the namespace can be scheduled to, or stay scheduled to,
+// for the placement: those accepting the namespace and reporting the capabilities required by the
+// placement. With spec.placementAffinity, these are narrowed to the given sync targets the namespaces
+// of the affine placement are scheduled to, plus those the namespace is synced to already. If there
+// are none, all of them are returned unless the affinity is required.
+func FilterCandidates(placement *schedulingv1alpha1.Placement, syncTargets []*workloadv1alpha1.SyncTarget, ns *corev1.Namespace, affineSyncTargets sets.String) []*workloadv1alpha1.SyncTarget {
+	syncTargets = filterAcceptingNamespace(syncTargets, ns)
+	syncTargets = filterCapable(syncTargets, placement.Spec.RequiredCapabilities)
+	return filterAffine(syncTargets, placement.Spec.PlacementAffinity, ScheduledSyncTargets([]*corev1.Namespace{ns}), affineSyncTargets)
+}
+
+// filterAcceptingNamespace returns the sync targets whose namespace selector matches the given namespace.
+// A nil namespace selector accepts all namespaces.
+func filterAcceptingNamespace(syncTargets []*workloadv1alpha1.SyncTarget, ns *corev1.Namespace) []*workloadv1alpha1.SyncTarget {
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		if syncTarget.Spec.NamespaceSelector == nil {
+			ret = append(ret, syncTarget)
+			continue
+		}
+
+		sel, err := metav1.LabelSelectorAsSelector(syncTarget.Spec.NamespaceSelector)
+		if err != nil {
+			klog.Errorf("Failed to parse namespace selector of SyncTarget %s|%s: %v", logicalcluster.From(syncTarget), syncTarget.Name, err)
+			continue
+		}
+		if sel.Matches(labels.Set(ns.Labels)) {
+			ret = append(ret, syncTarget)
+		}
+	}
+	return ret
+}
+
+// filterCapable returns the sync targets reporting all of the given capabilities in status.capabilities.
+func filterCapable(syncTargets []*workloadv1alpha1.SyncTarget, required []string) []*workloadv1alpha1.SyncTarget {
+	if len(required) == 0 {
+		return syncTargets
+	}
+
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		if sets.NewString(syncTarget.Status.Capabilities...).HasAll(required...) {
+			ret = append(ret, syncTarget)
+		}
+	}
+	return ret
+}
+
+// filterAffine returns the affine sync targets plus the synced ones. If there are no affine
+// ones, all sync targets are returned unless the affinity is required.
+func filterAffine(syncTargets []*workloadv1alpha1.SyncTarget, affinity *schedulingv1alpha1.PlacementAffinity, synced, affineSyncTargets sets.String) []*workloadv1alpha1.SyncTarget {
+	if affinity == nil {
+		return syncTargets
+	}
+
+	var affineCandidates, syncedCandidates []*workloadv1alpha1.SyncTarget
+	for _, syncTarget := range syncTargets {
+		switch {
+		case synced.Has(syncTarget.Name):
+			syncedCandidates = append(syncedCandidates, syncTarget)
+		case affineSyncTargets.Has(syncTarget.Name):
+			affineCandidates = append(affineCandidates, syncTarget)
+		}
+	}
+
+	switch {
+	case len(affineCandidates) > 0:
+		return append(syncedCandidates, affineCandidates...)
+	case affinity.Required:
+		return syncedCandidates
+	default:
+		return syncTargets
+	}
+}
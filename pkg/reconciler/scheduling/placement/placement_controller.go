@@ -45,6 +45,7 @@ import (
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	schedulinginformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/scheduling/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 )
 
@@ -62,6 +63,7 @@ func NewController(
 	namespaceInformer coreinformers.NamespaceInformer,
 	locationInformer schedulinginformers.LocationInformer,
 	placementInformer schedulinginformers.PlacementInformer,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
 ) (*controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
@@ -71,6 +73,10 @@ func NewController(
 			key := clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name)
 			queue.AddAfter(key, duration)
 		},
+		enqueuePlacementAfter: func(placement *schedulingv1alpha1.Placement, duration time.Duration) {
+			key := clusters.ToClusterAwareKey(logicalcluster.From(placement), placement.Name)
+			queue.AddAfter(key, duration)
+		},
 
 		kubeClusterClient: kubeClusterClient,
 		kcpClusterClient:  kcpClusterClient,
@@ -83,6 +89,8 @@ func NewController(
 
 		placementLister:  placementInformer.Lister(),
 		placementIndexer: placementInformer.Informer().GetIndexer(),
+
+		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
 	}
 
 	if err := locationInformer.Informer().AddIndexers(cache.Indexers{
@@ -104,6 +112,12 @@ func NewController(
 		return nil, err
 	}
 
+	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
+		byWorkspace: indexByWorkspace,
+	}); err != nil {
+		return nil, err
+	}
+
 	// namespaceBlocklist holds a set of namespaces that should never be synced from kcp to physical clusters.
 	var namespaceBlocklist = sets.NewString("kube-system", "kube-public", "kube-node-lease")
 	namespaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
@@ -123,7 +137,7 @@ func NewController(
 				oldNs := old.(*corev1.Namespace)
 				newNs := obj.(*corev1.Namespace)
 
-				if !reflect.DeepEqual(oldNs.Annotations, newNs.Annotations) {
+				if !reflect.DeepEqual(oldNs.Annotations, newNs.Annotations) || !reflect.DeepEqual(oldNs.Labels, newNs.Labels) {
 					c.enqueueNamespace(obj)
 				}
 			},
@@ -156,8 +170,9 @@ func NewController(
 
 // controller
 type controller struct {
-	queue        workqueue.RateLimitingInterface
-	enqueueAfter func(*corev1.Namespace, time.Duration)
+	queue                 workqueue.RateLimitingInterface
+	enqueueAfter          func(*corev1.Namespace, time.Duration)
+	enqueuePlacementAfter func(*schedulingv1alpha1.Placement, time.Duration)
 
 	kubeClusterClient kubernetesclient.ClusterInterface
 	kcpClusterClient  kcpclient.ClusterInterface
@@ -170,6 +185,8 @@ type controller struct {
 
 	placementLister  schedulinglisters.PlacementLister
 	placementIndexer cache.Indexer
+
+	syncTargetIndexer cache.Indexer
 }
 
 func (c *controller) enqueuePlacement(obj interface{}) {
@@ -182,6 +199,21 @@ func (c *controller) enqueuePlacement(obj interface{}) {
 
 	klog.V(2).Infof("Queueing Placement %s|%s", clusterName.String(), name)
 	c.queue.Add(key)
+
+	// placements with affinity to this placement depend on its namespaces and location.
+	placements, err := c.placementIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, obj := range placements {
+		placement := obj.(*schedulingv1alpha1.Placement)
+		if placement.Spec.PlacementAffinity == nil || placement.Spec.PlacementAffinity.Placement != name || placement.Name == name {
+			continue
+		}
+		klog.V(2).Infof("Queueing Placement %s|%s because of its affinity to Placement %q", clusterName, placement.Name, name)
+		c.queue.Add(clusters.ToClusterAwareKey(logicalcluster.From(placement), placement.Name))
+	}
 }
 
 // enqueueNamespace enqueues all placements for the namespace.
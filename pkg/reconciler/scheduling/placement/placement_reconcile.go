@@ -18,13 +18,18 @@ package placement
 
 import (
 	"context"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clusters"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 type reconcileStatus int
@@ -39,12 +44,29 @@ type reconciler interface {
 }
 
 func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) error {
+	namespaceReconciler := &placementNamespaceReconciler{
+		listNamespacesWithAnnotation: c.listNamespacesWithAnnotation,
+	}
 	reconcilers := []reconciler{
 		&placementReconciler{
 			listLocations: c.listLocations,
+			getPlacement:  c.getPlacement,
+		},
+		namespaceReconciler,
+		&placementAffinityReconciler{
+			getPlacement:     c.getPlacement,
+			getLocation:      c.getLocation,
+			listSyncTargets:  c.listSyncTargets,
+			selectNamespaces: namespaceReconciler.selectNamespaces,
 		},
-		&placementNamespaceReconciler{
-			listNamespacesWithAnnotation: c.listNamespacesWithAnnotation,
+		&placementRebalanceReconciler{
+			getPlacement:     c.getPlacement,
+			getLocation:      c.getLocation,
+			listSyncTargets:  c.listSyncTargets,
+			selectNamespaces: namespaceReconciler.selectNamespaces,
+			patchNamespace:   c.patchNamespace,
+			enqueueAfter:     c.enqueuePlacementAfter,
+			now:              time.Now,
 		},
 	}
 
@@ -77,6 +99,32 @@ func (c *controller) listLocations(clusterName logicalcluster.Name) ([]*scheduli
 	return ret, nil
 }
 
+func (c *controller) getLocation(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
+	key := clusters.ToClusterAwareKey(clusterName, name)
+	return c.locationLister.Get(key)
+}
+
+func (c *controller) getPlacement(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Placement, error) {
+	key := clusters.ToClusterAwareKey(clusterName, name)
+	return c.placementLister.Get(key)
+}
+
+func (c *controller) listSyncTargets(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+	items, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*workloadv1alpha1.SyncTarget))
+	}
+	return ret, nil
+}
+
+func (c *controller) patchNamespace(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+	return c.kubeClusterClient.Cluster(clusterName).CoreV1().Namespaces().Patch(ctx, name, pt, data, opts, subresources...)
+}
+
 func (c *controller) listNamespacesWithAnnotation(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
 	items, err := c.namespaceIndexer.ByIndex(byWorkspace, clusterName.String())
 	if err != nil {
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+)
+
+// placementAffinityReconciler sets the PlacementAffinitySatisfied condition of a placement with
+// spec.placementAffinity, depending on whether any of the sync targets the namespaces of the
+// referenced placement are scheduled to is schedulable in the selected location. The namespace
+// scheduler prefers, or requires, these sync targets.
+type placementAffinityReconciler struct {
+	getPlacement     func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Placement, error)
+	getLocation      func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
+	listSyncTargets  func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
+	selectNamespaces func(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error)
+}
+
+func (r *placementAffinityReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
+	affinity := placement.Spec.PlacementAffinity
+	if affinity == nil {
+		conditions.Delete(placement, schedulingv1alpha1.PlacementAffinitySatisfied)
+		return reconcileStatusContinue, placement, nil
+	}
+	if placement.Status.SelectedLocation == nil {
+		return reconcileStatusContinue, placement, nil
+	}
+
+	affine, err := r.getPlacement(logicalcluster.From(placement), affinity.Placement)
+	switch {
+	case errors.IsNotFound(err):
+		markAffinityUnsatisfiable(placement, "Placement %q not found", affinity.Placement)
+		return reconcileStatusContinue, placement, nil
+	case err != nil:
+		return reconcileStatusContinue, placement, err
+	}
+
+	if affine.Status.SelectedLocation == nil || affine.Status.SelectedLocation.Path != placement.Status.SelectedLocation.Path {
+		markAffinityUnsatisfiable(placement, "Placement %q selected no location in workspace %s", affine.Name, placement.Status.SelectedLocation.Path)
+		return reconcileStatusContinue, placement, nil
+	}
+
+	nss, err := r.selectNamespaces(affine)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+	affineSyncTargets := locationreconciler.ScheduledSyncTargets(nss)
+	if affineSyncTargets.Len() == 0 {
+		markAffinityUnsatisfiable(placement, "Placement %q has no namespaces scheduled to sync targets yet", affine.Name)
+		return reconcileStatusContinue, placement, nil
+	}
+
+	locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
+	location, err := r.getLocation(locationWorkspace, placement.Status.SelectedLocation.LocationName)
+	switch {
+	case errors.IsNotFound(err):
+		return reconcileStatusContinue, placement, nil
+	case err != nil:
+		return reconcileStatusContinue, placement, err
+	}
+	syncTargets, err := r.listSyncTargets(locationWorkspace)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+	syncTargets, err = locationreconciler.LocationSyncTargets(syncTargets, location)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+
+	for _, syncTarget := range locationreconciler.FilterReady(syncTargets) {
+		if affineSyncTargets.Has(syncTarget.Name) {
+			conditions.MarkTrue(placement, schedulingv1alpha1.PlacementAffinitySatisfied)
+			return reconcileStatusContinue, placement, nil
+		}
+	}
+
+	markAffinityUnsatisfiable(placement, "None of the sync targets %v of placement %q is schedulable in location %s", affineSyncTargets.List(), affine.Name, location.Name)
+	return reconcileStatusContinue, placement, nil
+}
+
+func markAffinityUnsatisfiable(placement *schedulingv1alpha1.Placement, messageFormat string, messageArgs ...interface{}) {
+	severity := conditionsv1alpha1.ConditionSeverityInfo
+	if placement.Spec.PlacementAffinity.Required {
+		severity = conditionsv1alpha1.ConditionSeverityWarning
+	}
+	conditions.MarkFalse(placement, schedulingv1alpha1.PlacementAffinitySatisfied, schedulingv1alpha1.PlacementAffinityUnsatisfiableReason, severity, messageFormat, messageArgs...)
+}
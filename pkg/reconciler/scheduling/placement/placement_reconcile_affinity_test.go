@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestPlacementAffinity(t *testing.T) {
+	selectedLocation := &schedulingv1alpha1.LocationReference{Path: "root:org:ws", LocationName: "us-east1"}
+
+	testCases := []struct {
+		name        string
+		affinity    *schedulingv1alpha1.PlacementAffinity
+		affine      *schedulingv1alpha1.Placement
+		syncTargets []*workloadv1alpha1.SyncTarget
+		nss         []*corev1.Namespace
+
+		wantStatus   corev1.ConditionStatus
+		wantSeverity conditionsapi.ConditionSeverity
+	}{
+		{
+			name: "no affinity",
+		},
+		{
+			name:         "affine placement not found",
+			affinity:     &schedulingv1alpha1.PlacementAffinity{Placement: "db"},
+			wantStatus:   corev1.ConditionFalse,
+			wantSeverity: conditionsapi.ConditionSeverityInfo,
+		},
+		{
+			name:         "affine placement in another location workspace",
+			affinity:     &schedulingv1alpha1.PlacementAffinity{Placement: "db", Required: true},
+			affine:       newAffinePlacement("db", &schedulingv1alpha1.LocationReference{Path: "root:org:other", LocationName: "us-east1"}),
+			wantStatus:   corev1.ConditionFalse,
+			wantSeverity: conditionsapi.ConditionSeverityWarning,
+		},
+		{
+			name:         "affine placement without scheduled namespaces",
+			affinity:     &schedulingv1alpha1.PlacementAffinity{Placement: "db"},
+			affine:       newAffinePlacement("db", selectedLocation),
+			syncTargets:  []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a")},
+			nss:          []*corev1.Namespace{newSyncedNamespace("ns1"), withRemoving(newSyncedNamespace("ns2", "a"), "a")},
+			wantStatus:   corev1.ConditionFalse,
+			wantSeverity: conditionsapi.ConditionSeverityInfo,
+		},
+		{
+			name:        "sync target of the affine placement is schedulable",
+			affinity:    &schedulingv1alpha1.PlacementAffinity{Placement: "db"},
+			affine:      newAffinePlacement("db", selectedLocation),
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "b")},
+			wantStatus:  corev1.ConditionTrue,
+		},
+		{
+			name:         "sync target of the affine placement is cordoned",
+			affinity:     &schedulingv1alpha1.PlacementAffinity{Placement: "db", Required: true},
+			affine:       newAffinePlacement("db", selectedLocation),
+			syncTargets:  []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withUnschedulable(newReadySyncTarget("b"))},
+			nss:          []*corev1.Namespace{newSyncedNamespace("ns1", "b")},
+			wantStatus:   corev1.ConditionFalse,
+			wantSeverity: conditionsapi.ConditionSeverityWarning,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			placement := &schedulingv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "web"},
+				Spec:       schedulingv1alpha1.PlacementSpec{PlacementAffinity: testCase.affinity},
+				Status:     schedulingv1alpha1.PlacementStatus{SelectedLocation: selectedLocation},
+			}
+
+			reconciler := &placementAffinityReconciler{
+				getPlacement: func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Placement, error) {
+					if testCase.affine == nil || testCase.affine.Name != name {
+						return nil, errors.NewNotFound(schedulingv1alpha1.Resource("placements"), name)
+					}
+					return testCase.affine, nil
+				},
+				getLocation: func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
+					return &schedulingv1alpha1.Location{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: schedulingv1alpha1.LocationSpec{
+							InstanceSelector: &metav1.LabelSelector{},
+						},
+					}, nil
+				},
+				listSyncTargets: func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+					return testCase.syncTargets, nil
+				},
+				selectNamespaces: func(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error) {
+					return testCase.nss, nil
+				},
+			}
+
+			_, updated, err := reconciler.reconcile(context.TODO(), placement)
+			require.NoError(t, err)
+
+			c := conditions.Get(updated, schedulingv1alpha1.PlacementAffinitySatisfied)
+			if testCase.wantStatus == "" {
+				require.Nil(t, c)
+				return
+			}
+			require.NotNil(t, c)
+			require.Equal(t, testCase.wantStatus, c.Status)
+			require.Equal(t, testCase.wantSeverity, c.Severity)
+		})
+	}
+}
+
+func newAffinePlacement(name string, selectedLocation *schedulingv1alpha1.LocationReference) *schedulingv1alpha1.Placement {
+	return &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     schedulingv1alpha1.PlacementStatus{SelectedLocation: selectedLocation},
+	}
+}
+
+func withUnschedulable(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.Unschedulable = true
+	return syncTarget
+}
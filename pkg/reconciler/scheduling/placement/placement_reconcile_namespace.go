@@ -18,6 +18,8 @@ package placement
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/kcp-dev/logicalcluster"
 
@@ -27,21 +29,29 @@ import (
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
 // placementNamespaceReconciler checkes the namespaces bound to this placement and set the phase.
 // If there are at least one namespace bound to this placement, the placement is in bound state.
+// The bound namespaces are listed in the status, up to MaxBoundNamespaces of them, and the
+// NamespacesSelected condition tells how many namespaces the namespace selector matches.
 type placementNamespaceReconciler struct {
 	listNamespacesWithAnnotation func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error)
 }
 
 func (r *placementNamespaceReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
 	if placement.Status.Phase == schedulingv1alpha1.PlacementPending {
+		setBoundNamespaces(placement, nil)
+		conditions.Delete(placement, schedulingv1alpha1.PlacementNamespacesSelected)
 		return reconcileStatusContinue, placement, nil
 	}
 
 	if placement.Status.SelectedLocation == nil {
 		placement.Status.Phase = schedulingv1alpha1.PlacementPending
+		setBoundNamespaces(placement, nil)
+		conditions.Delete(placement, schedulingv1alpha1.PlacementNamespacesSelected)
 		return reconcileStatusContinue, placement, nil
 	}
 
@@ -56,10 +66,48 @@ func (r *placementNamespaceReconciler) reconcile(ctx context.Context, placement
 	} else {
 		placement.Status.Phase = schedulingv1alpha1.PlacementUnbound
 	}
+	setBoundNamespaces(placement, nss)
+	setNamespacesSelected(placement, len(nss))
 
 	return reconcileStatusContinue, placement, err
 }
 
+// setNamespacesSelected sets the NamespacesSelected condition explaining how many namespaces
+// the namespace selector of the placement matches.
+func setNamespacesSelected(placement *schedulingv1alpha1.Placement, count int) {
+	switch {
+	case placement.Spec.NamespaceSelector == nil:
+		conditions.MarkFalse(placement, schedulingv1alpha1.PlacementNamespacesSelected, schedulingv1alpha1.NamespaceSelectorNotSetReason,
+			conditionsv1alpha1.ConditionSeverityInfo, "No namespace selector is set, no namespace is selected")
+	case count == 0:
+		conditions.MarkFalse(placement, schedulingv1alpha1.PlacementNamespacesSelected, schedulingv1alpha1.NoNamespaceSelectedReason,
+			conditionsv1alpha1.ConditionSeverityWarning, "The namespace selector matches 0 namespaces")
+	default:
+		condition := conditions.TrueCondition(schedulingv1alpha1.PlacementNamespacesSelected)
+		condition.Message = fmt.Sprintf("The namespace selector matches %d namespaces", count)
+		conditions.Set(placement, condition)
+	}
+}
+
+// setBoundNamespaces sets status.boundNamespaces to the first MaxBoundNamespaces names of
+// the given namespaces in alphabetical order, and status.boundNamespaceCount to their number.
+func setBoundNamespaces(placement *schedulingv1alpha1.Placement, nss []*corev1.Namespace) {
+	names := make([]string, 0, len(nss))
+	for _, ns := range nss {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+
+	placement.Status.BoundNamespaceCount = int32(len(names))
+	if len(names) > schedulingv1alpha1.MaxBoundNamespaces {
+		names = names[:schedulingv1alpha1.MaxBoundNamespaces]
+	}
+	if len(names) == 0 {
+		names = nil
+	}
+	placement.Status.BoundNamespaces = names
+}
+
 func (r *placementNamespaceReconciler) selectNamespaces(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error) {
 	clusterName := logicalcluster.From(placement)
 	nss, err := r.listNamespacesWithAnnotation(clusterName)
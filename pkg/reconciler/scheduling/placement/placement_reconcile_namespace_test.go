@@ -18,6 +18,7 @@ package placement
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/kcp-dev/logicalcluster"
@@ -27,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
 func TestPlacementPhase(t *testing.T) {
@@ -120,3 +122,124 @@ func TestPlacementPhase(t *testing.T) {
 		})
 	}
 }
+
+func TestBoundNamespaces(t *testing.T) {
+	newNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	newPlacement := func() *schedulingv1alpha1.Placement {
+		return &schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-placement",
+			},
+			Spec: schedulingv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+			},
+			Status: schedulingv1alpha1.PlacementStatus{
+				Phase: schedulingv1alpha1.PlacementUnbound,
+				SelectedLocation: &schedulingv1alpha1.LocationReference{
+					Path:         "root",
+					LocationName: "test-location",
+				},
+			},
+		}
+	}
+
+	nss := []*corev1.Namespace{
+		newNamespace("ns-c", map[string]string{"app": "foo"}),
+		newNamespace("ns-a", map[string]string{"app": "foo"}),
+		newNamespace("ns-other", map[string]string{"app": "bar"}),
+		newNamespace("ns-b", map[string]string{"app": "foo"}),
+	}
+	reconciler := &placementNamespaceReconciler{
+		listNamespacesWithAnnotation: func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+			return nss, nil
+		},
+	}
+
+	_, updated, err := reconciler.reconcile(context.TODO(), newPlacement())
+	require.NoError(t, err)
+	require.Equal(t, schedulingv1alpha1.PlacementPhase(schedulingv1alpha1.PlacementBound), updated.Status.Phase)
+	require.Equal(t, []string{"ns-a", "ns-b", "ns-c"}, updated.Status.BoundNamespaces)
+	require.Equal(t, int32(3), updated.Status.BoundNamespaceCount)
+
+	t.Log("The list is truncated for many namespaces, but the count is not")
+	nss = nil
+	for i := 0; i < schedulingv1alpha1.MaxBoundNamespaces+10; i++ {
+		nss = append(nss, newNamespace(fmt.Sprintf("ns-%03d", i), map[string]string{"app": "foo"}))
+	}
+	_, updated, err = reconciler.reconcile(context.TODO(), newPlacement())
+	require.NoError(t, err)
+	require.Len(t, updated.Status.BoundNamespaces, schedulingv1alpha1.MaxBoundNamespaces)
+	require.Equal(t, "ns-000", updated.Status.BoundNamespaces[0])
+	require.Equal(t, int32(schedulingv1alpha1.MaxBoundNamespaces+10), updated.Status.BoundNamespaceCount)
+
+	t.Log("No namespace is listed when all are gone")
+	placement := updated
+	nss = nil
+	_, updated, err = reconciler.reconcile(context.TODO(), placement)
+	require.NoError(t, err)
+	require.Equal(t, schedulingv1alpha1.PlacementPhase(schedulingv1alpha1.PlacementUnbound), updated.Status.Phase)
+	require.Empty(t, updated.Status.BoundNamespaces)
+	require.Zero(t, updated.Status.BoundNamespaceCount)
+}
+
+func TestNamespacesSelectedCondition(t *testing.T) {
+	newPlacement := func(selector *metav1.LabelSelector) *schedulingv1alpha1.Placement {
+		return &schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-placement",
+			},
+			Spec: schedulingv1alpha1.PlacementSpec{
+				NamespaceSelector: selector,
+			},
+			Status: schedulingv1alpha1.PlacementStatus{
+				Phase: schedulingv1alpha1.PlacementUnbound,
+				SelectedLocation: &schedulingv1alpha1.LocationReference{
+					Path:         "root",
+					LocationName: "test-location",
+				},
+			},
+		}
+	}
+	reconciler := &placementNamespaceReconciler{
+		listNamespacesWithAnnotation: func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+			return []*corev1.Namespace{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ns-a", Labels: map[string]string{"app": "foo"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "ns-b", Labels: map[string]string{"app": "foo"}}},
+			}, nil
+		},
+	}
+
+	t.Log("A nil selector selects nothing")
+	_, updated, err := reconciler.reconcile(context.TODO(), newPlacement(nil))
+	require.NoError(t, err)
+	condition := conditions.Get(updated, schedulingv1alpha1.PlacementNamespacesSelected)
+	require.NotNil(t, condition)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, schedulingv1alpha1.NamespaceSelectorNotSetReason, condition.Reason)
+
+	t.Log("A selector matching no namespace is explained")
+	_, updated, err = reconciler.reconcile(context.TODO(), newPlacement(&metav1.LabelSelector{MatchLabels: map[string]string{"app": "bar"}}))
+	require.NoError(t, err)
+	condition = conditions.Get(updated, schedulingv1alpha1.PlacementNamespacesSelected)
+	require.NotNil(t, condition)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, schedulingv1alpha1.NoNamespaceSelectedReason, condition.Reason)
+	require.Equal(t, "The namespace selector matches 0 namespaces", condition.Message)
+
+	t.Log("A matching selector reports the number of namespaces")
+	updated.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}
+	_, updated, err = reconciler.reconcile(context.TODO(), updated)
+	require.NoError(t, err)
+	condition = conditions.Get(updated, schedulingv1alpha1.PlacementNamespacesSelected)
+	require.NotNil(t, condition)
+	require.Equal(t, corev1.ConditionTrue, condition.Status)
+	require.Equal(t, "The namespace selector matches 2 namespaces", condition.Message)
+
+	t.Log("The condition is removed while the placement is pending")
+	updated.Status.SelectedLocation = nil
+	_, updated, err = reconciler.reconcile(context.TODO(), updated)
+	require.NoError(t, err)
+	require.Nil(t, conditions.Get(updated, schedulingv1alpha1.PlacementNamespacesSelected))
+}
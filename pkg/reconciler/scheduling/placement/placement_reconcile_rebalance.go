@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+)
+
+// placementRebalanceReconciler moves namespaces bound to the placement from the most loaded
+// to the least loaded sync target of the selected location according to spec.rebalance. A
+// namespace is moved by setting it to sync to the new sync target and marking the old one as
+// removing, like the workload namespace scheduler does for invalid sync targets. Namespaces are
+// only moved to sync targets the namespace scheduler considers candidates for the placement.
+type placementRebalanceReconciler struct {
+	getPlacement     func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Placement, error)
+	getLocation      func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
+	listSyncTargets  func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
+	selectNamespaces func(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error)
+
+	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
+
+	enqueueAfter func(*schedulingv1alpha1.Placement, time.Duration)
+
+	now func() time.Time
+}
+
+func (r *placementRebalanceReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
+	policy := placement.Spec.Rebalance
+	if policy == nil || policy.Interval.Duration <= 0 {
+		return reconcileStatusContinue, placement, nil
+	}
+	if placement.Status.Phase != schedulingv1alpha1.PlacementBound || placement.Status.SelectedLocation == nil {
+		return reconcileStatusContinue, placement, nil
+	}
+	if placement.Spec.LocationResource.Group != workloadv1alpha1.SchemeGroupVersion.Group || placement.Spec.LocationResource.Resource != "synctargets" {
+		return reconcileStatusContinue, placement, nil
+	}
+
+	now := r.now()
+	if last := placement.Status.LastRebalanceTime; last != nil {
+		if next := last.Add(policy.Interval.Duration); now.Before(next) {
+			r.enqueueAfter(placement, next.Sub(now))
+			return reconcileStatusContinue, placement, nil
+		}
+	}
+
+	// re-evaluate after the interval, e.g. when sync targets have been added in the meantime
+	r.enqueueAfter(placement, policy.Interval.Duration)
+
+	locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
+	location, err := r.getLocation(locationWorkspace, placement.Status.SelectedLocation.LocationName)
+	switch {
+	case errors.IsNotFound(err):
+		return reconcileStatusContinue, placement, nil
+	case err != nil:
+		return reconcileStatusContinue, placement, err
+	}
+
+	syncTargets, err := r.listSyncTargets(locationWorkspace)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+	syncTargets, err = locationreconciler.LocationSyncTargets(syncTargets, location)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+
+	nss, err := r.selectNamespaces(placement)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+
+	affineSyncTargets, err := r.affineSyncTargets(placement)
+	if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+
+	maxMoves := int(policy.MaxNamespacesPerInterval)
+	if maxMoves < 1 {
+		maxMoves = 1
+	}
+	moves := planRebalance(placement, affineSyncTargets, syncTargets, nss, maxMoves, now)
+
+	var errs []error
+	moved := 0
+	for _, move := range moves {
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{
+					workloadv1alpha1.ClusterResourceStateLabel(move.to): string(workloadv1alpha1.ResourceStateSync),
+				},
+				"annotations": map[string]interface{}{
+					workloadv1alpha1.DeletionAnnotation(move.from): now.UTC().Format(time.RFC3339),
+				},
+			},
+		}
+		patchBytes, err := json.Marshal(patch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		klog.V(2).Infof("Rebalancing namespace %s|%s of placement %s|%s from sync target %s to %s", logicalcluster.From(move.ns), move.ns.Name, logicalcluster.From(placement), placement.Name, move.from, move.to)
+		if _, err := r.patchNamespace(ctx, logicalcluster.From(move.ns), move.ns.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		moved++
+	}
+
+	if moved > 0 {
+		placement.Status.LastRebalanceTime = &metav1.Time{Time: now}
+	}
+
+	return reconcileStatusContinue, placement, utilserrors.NewAggregate(errs)
+}
+
+// affineSyncTargets returns the sync targets the namespaces of the placement referenced by
+// spec.placementAffinity are scheduled to, if it selected the same location workspace.
+func (r *placementRebalanceReconciler) affineSyncTargets(placement *schedulingv1alpha1.Placement) (sets.String, error) {
+	affinity := placement.Spec.PlacementAffinity
+	if affinity == nil {
+		return nil, nil
+	}
+
+	affine, err := r.getPlacement(logicalcluster.From(placement), affinity.Placement)
+	switch {
+	case errors.IsNotFound(err):
+		return sets.NewString(), nil
+	case err != nil:
+		return nil, err
+	}
+	if affine.Status.SelectedLocation == nil || affine.Status.SelectedLocation.Path != placement.Status.SelectedLocation.Path {
+		return sets.NewString(), nil
+	}
+
+	nss, err := r.selectNamespaces(affine)
+	if err != nil {
+		return nil, err
+	}
+	return locationreconciler.ScheduledSyncTargets(nss), nil
+}
+
+type namespaceMove struct {
+	ns       *corev1.Namespace
+	from, to string
+}
+
+// planRebalance returns at most maxMoves namespaces to move from the most loaded to the least
+// loaded sync targets, as long as a move improves the balance. The load of a sync target is the
+// number of the given namespaces synced to it, relative to its allocatable cpu if all sync targets
+// report it. Only namespaces synced to exactly one of the sync targets, and not being removed from
+// any of them, are moved, and only to sync targets which are candidates for the namespace and the
+// placement, as the namespace scheduler would otherwise move them back.
+func planRebalance(placement *schedulingv1alpha1.Placement, affineSyncTargets sets.String, syncTargets []*workloadv1alpha1.SyncTarget, nss []*corev1.Namespace, maxMoves int, now time.Time) []namespaceMove {
+	sort.Slice(syncTargets, func(i, j int) bool { return syncTargets[i].Name < syncTargets[j].Name })
+	sort.Slice(nss, func(i, j int) bool { return nss[i].Name < nss[j].Name })
+
+	weights := capacityWeights(syncTargets)
+	counts := map[string]int{}
+	movable := map[string][]*corev1.Namespace{}
+	for _, ns := range nss {
+		var synced []string
+		removing := false
+		for _, syncTarget := range syncTargets {
+			if _, found := ns.Labels[workloadv1alpha1.ClusterResourceStateLabel(syncTarget.Name)]; !found {
+				continue
+			}
+			if _, found := ns.Annotations[workloadv1alpha1.DeletionAnnotation(syncTarget.Name)]; found {
+				removing = true
+				continue
+			}
+			synced = append(synced, syncTarget.Name)
+			counts[syncTarget.Name]++
+		}
+		if len(synced) == 1 && !removing {
+			movable[synced[0]] = append(movable[synced[0]], ns)
+		}
+	}
+
+	load := func(name string, delta int) float64 {
+		return float64(counts[name]+delta) / weights[name]
+	}
+
+	ready := locationreconciler.FilterReady(syncTargets)
+	var sources, destinations []*workloadv1alpha1.SyncTarget
+	for _, syncTarget := range ready {
+		if syncTarget.Spec.EvictAfter != nil {
+			// evicting sync targets are drained by the namespace scheduler
+			continue
+		}
+		sources = append(sources, syncTarget)
+		if until := syncTarget.Status.RescheduleCooldownUntil; until != nil && now.Before(until.Time) {
+			continue
+		}
+		destinations = append(destinations, syncTarget)
+	}
+
+	var moves []namespaceMove
+	for len(moves) < maxMoves {
+		var from string
+		for _, syncTarget := range sources {
+			if len(movable[syncTarget.Name]) > 0 && (from == "" || load(syncTarget.Name, 0) > load(from, 0)) {
+				from = syncTarget.Name
+			}
+		}
+		if from == "" {
+			break
+		}
+
+		var to *workloadv1alpha1.SyncTarget
+		var ns *corev1.Namespace
+		for _, syncTarget := range destinations {
+			if syncTarget.Name == from || (to != nil && load(syncTarget.Name, 0) >= load(to.Name, 0)) {
+				continue
+			}
+			// only move to sync targets that improve the balance, i.e. never back and forth
+			if load(syncTarget.Name, 1) >= load(from, 0) {
+				continue
+			}
+			if candidate := firstCandidate(placement, affineSyncTargets, destinations, syncTarget, movable[from]); candidate != nil {
+				to, ns = syncTarget, candidate
+			}
+		}
+		if to == nil {
+			break
+		}
+
+		moves = append(moves, namespaceMove{ns: ns, from: from, to: to.Name})
+		counts[from]--
+		counts[to.Name]++
+		movable[from] = removeNamespace(movable[from], ns)
+	}
+
+	return moves
+}
+
+// capacityWeights returns the allocatable cpu, capped at the cpu capacity, of the sync targets
+// if all of them report it, and equal weights otherwise.
+func capacityWeights(syncTargets []*workloadv1alpha1.SyncTarget) map[string]float64 {
+	weights := make(map[string]float64, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		cpu, found := locationreconciler.SchedulableAllocatable(syncTarget)[corev1.ResourceCPU]
+		if !found || cpu.MilliValue() <= 0 {
+			return equalWeights(syncTargets)
+		}
+		weights[syncTarget.Name] = float64(cpu.MilliValue())
+	}
+	return weights
+}
+
+func equalWeights(syncTargets []*workloadv1alpha1.SyncTarget) map[string]float64 {
+	weights := make(map[string]float64, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		weights[syncTarget.Name] = 1
+	}
+	return weights
+}
+
+// firstCandidate returns the first of the namespaces for which the sync target is one of the
+// candidates among the destinations.
+func firstCandidate(placement *schedulingv1alpha1.Placement, affineSyncTargets sets.String, destinations []*workloadv1alpha1.SyncTarget, syncTarget *workloadv1alpha1.SyncTarget, nss []*corev1.Namespace) *corev1.Namespace {
+	for _, ns := range nss {
+		for _, candidate := range locationreconciler.FilterCandidates(placement, destinations, ns, affineSyncTargets) {
+			if candidate.Name == syncTarget.Name {
+				return ns
+			}
+		}
+	}
+	return nil
+}
+
+func removeNamespace(nss []*corev1.Namespace, ns *corev1.Namespace) []*corev1.Namespace {
+	ret := make([]*corev1.Namespace, 0, len(nss))
+	for _, n := range nss {
+		if n != ns {
+			ret = append(ret, n)
+		}
+	}
+	return ret
+}
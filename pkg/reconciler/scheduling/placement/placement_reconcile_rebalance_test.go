@@ -0,0 +1,338 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestPlanRebalance(t *testing.T) {
+	now := time.Now()
+
+	requiringGPU := &schedulingv1alpha1.Placement{Spec: schedulingv1alpha1.PlacementSpec{RequiredCapabilities: []string{"gpu"}}}
+	requiringAffinity := &schedulingv1alpha1.Placement{Spec: schedulingv1alpha1.PlacementSpec{PlacementAffinity: &schedulingv1alpha1.PlacementAffinity{Placement: "other", Required: true}}}
+
+	testCases := []struct {
+		name              string
+		placement         *schedulingv1alpha1.Placement
+		affineSyncTargets sets.String
+		syncTargets       []*workloadv1alpha1.SyncTarget
+		nss               []*corev1.Namespace
+		maxMoves          int
+		wantMoves         []string
+	}{
+		{
+			name:        "balanced",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "b"), newSyncedNamespace("ns3", "a")},
+			maxMoves:    1,
+		},
+		{
+			name:        "new sync target gets a bounded number of namespaces",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:    1,
+			wantMoves:   []string{"ns1:a->b"},
+		},
+		{
+			name:        "moves stop when balanced",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:    5,
+			wantMoves:   []string{"ns1:a->b", "ns2:a->b"},
+		},
+		{
+			name:        "destination in reschedule cooldown",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withCooldownUntil(newReadySyncTarget("b"), now.Add(time.Minute))},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    1,
+		},
+		{
+			name:        "destination cooldown passed",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withCooldownUntil(newReadySyncTarget("b"), now.Add(-time.Minute))},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    1,
+			wantMoves:   []string{"ns1:a->b"},
+		},
+		{
+			name:        "destination evicting",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withEvictAfter(newReadySyncTarget("b"), now.Add(time.Hour))},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    1,
+		},
+		{
+			name:        "evicting source is left to the eviction",
+			syncTargets: []*workloadv1alpha1.SyncTarget{withEvictAfter(newReadySyncTarget("a"), now.Add(time.Hour)), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    1,
+		},
+		{
+			name: "destination not ready",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), func() *workloadv1alpha1.SyncTarget {
+				st := newReadySyncTarget("b")
+				st.Status.Conditions[0].Status = corev1.ConditionFalse
+				return st
+			}()},
+			nss:      []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves: 1,
+		},
+		{
+			name:        "namespaces being removed are not moved",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{withRemoving(newSyncedNamespace("ns1", "a"), "a"), withRemoving(newSyncedNamespace("ns2", "a", "b"), "a")},
+			maxMoves:    1,
+		},
+		{
+			name:        "destination not accepting the namespaces",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withNamespaceSelector(newReadySyncTarget("b"), map[string]string{"foo": "bar"})},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    1,
+		},
+		{
+			name:        "relative to allocatable cpu",
+			syncTargets: []*workloadv1alpha1.SyncTarget{withAllocatableCPU(newReadySyncTarget("a"), "1"), withAllocatableCPU(newReadySyncTarget("b"), "4")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:    5,
+			wantMoves:   []string{"ns1:a->b", "ns2:a->b", "ns3:a->b"},
+		},
+		{
+			name:        "allocatable cpu capped at capacity",
+			syncTargets: []*workloadv1alpha1.SyncTarget{withCapacityCPU(withAllocatableCPU(newReadySyncTarget("a"), "100"), "1"), withAllocatableCPU(newReadySyncTarget("b"), "4")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "b"), newSyncedNamespace("ns2", "b"), newSyncedNamespace("ns3", "b"), newSyncedNamespace("ns4", "b")},
+			maxMoves:    5,
+		},
+		{
+			name:        "equal weights unless all sync targets report allocatable cpu",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), withAllocatableCPU(newReadySyncTarget("b"), "4")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:    5,
+			wantMoves:   []string{"ns1:a->b", "ns2:a->b"},
+		},
+		{
+			name:        "destination without the required capabilities",
+			placement:   requiringGPU,
+			syncTargets: []*workloadv1alpha1.SyncTarget{withCapabilities(newReadySyncTarget("a"), "gpu"), newReadySyncTarget("b")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:    5,
+		},
+		{
+			name:        "only destinations with the required capabilities",
+			placement:   requiringGPU,
+			syncTargets: []*workloadv1alpha1.SyncTarget{withCapabilities(newReadySyncTarget("a"), "gpu"), newReadySyncTarget("b"), withCapabilities(newReadySyncTarget("c"), "gpu", "ssd")},
+			nss:         []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:    5,
+			wantMoves:   []string{"ns1:a->c", "ns2:a->c"},
+		},
+		{
+			name:              "only affine destinations with required affinity",
+			placement:         requiringAffinity,
+			affineSyncTargets: sets.NewString("a", "c"),
+			syncTargets:       []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b"), newReadySyncTarget("c")},
+			nss:               []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a"), newSyncedNamespace("ns3", "a"), newSyncedNamespace("ns4", "a")},
+			maxMoves:          5,
+			wantMoves:         []string{"ns1:a->c", "ns2:a->c"},
+		},
+		{
+			name:              "no destinations without affine sync targets",
+			placement:         requiringAffinity,
+			affineSyncTargets: sets.NewString(),
+			syncTargets:       []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")},
+			nss:               []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")},
+			maxMoves:          5,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			placement := testCase.placement
+			if placement == nil {
+				placement = &schedulingv1alpha1.Placement{}
+			}
+			var got []string
+			for _, move := range planRebalance(placement, testCase.affineSyncTargets, testCase.syncTargets, testCase.nss, testCase.maxMoves, now) {
+				got = append(got, fmt.Sprintf("%s:%s->%s", move.ns.Name, move.from, move.to))
+			}
+			require.Equal(t, testCase.wantMoves, got)
+		})
+	}
+}
+
+func TestPlacementRebalance(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name              string
+		lastRebalanceTime *time.Time
+		wantPatches       []string
+		wantEnqueueAfter  time.Duration
+		wantLastRebalance *metav1.Time
+	}{
+		{
+			name:              "namespace moved",
+			wantPatches:       []string{fmt.Sprintf(`ns1:{"metadata":{"annotations":{"deletion.internal.workload.kcp.dev/a":%q},"labels":{"state.workload.kcp.dev/b":"Sync"}}}`, now.UTC().Format(time.RFC3339))},
+			wantEnqueueAfter:  time.Minute,
+			wantLastRebalance: &metav1.Time{Time: now},
+		},
+		{
+			name:              "interval not passed",
+			lastRebalanceTime: timePtr(now.Add(-20 * time.Second)),
+			wantEnqueueAfter:  40 * time.Second,
+			wantLastRebalance: &metav1.Time{Time: now.Add(-20 * time.Second)},
+		},
+		{
+			name:              "interval passed",
+			lastRebalanceTime: timePtr(now.Add(-2 * time.Minute)),
+			wantPatches:       []string{fmt.Sprintf(`ns1:{"metadata":{"annotations":{"deletion.internal.workload.kcp.dev/a":%q},"labels":{"state.workload.kcp.dev/b":"Sync"}}}`, now.UTC().Format(time.RFC3339))},
+			wantEnqueueAfter:  time.Minute,
+			wantLastRebalance: &metav1.Time{Time: now},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			placement := &schedulingv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-placement"},
+				Spec: schedulingv1alpha1.PlacementSpec{
+					LocationResource: schedulingv1alpha1.GroupVersionResource{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"},
+					Rebalance:        &schedulingv1alpha1.RebalancePolicy{Interval: metav1.Duration{Duration: time.Minute}, MaxNamespacesPerInterval: 1},
+				},
+				Status: schedulingv1alpha1.PlacementStatus{
+					Phase:            schedulingv1alpha1.PlacementBound,
+					SelectedLocation: &schedulingv1alpha1.LocationReference{Path: "root:org", LocationName: "us-east1"},
+				},
+			}
+			if testCase.lastRebalanceTime != nil {
+				placement.Status.LastRebalanceTime = &metav1.Time{Time: *testCase.lastRebalanceTime}
+			}
+
+			var patches []string
+			var enqueuedAfter time.Duration
+			reconciler := &placementRebalanceReconciler{
+				getLocation: func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
+					return &schedulingv1alpha1.Location{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec:       schedulingv1alpha1.LocationSpec{InstanceSelector: &metav1.LabelSelector{}},
+					}, nil
+				},
+				listSyncTargets: func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+					return []*workloadv1alpha1.SyncTarget{newReadySyncTarget("a"), newReadySyncTarget("b")}, nil
+				},
+				selectNamespaces: func(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error) {
+					return []*corev1.Namespace{newSyncedNamespace("ns1", "a"), newSyncedNamespace("ns2", "a")}, nil
+				},
+				patchNamespace: func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+					require.Equal(t, types.MergePatchType, pt)
+					patches = append(patches, name+":"+string(data))
+					return &corev1.Namespace{}, nil
+				},
+				enqueueAfter: func(_ *schedulingv1alpha1.Placement, d time.Duration) {
+					enqueuedAfter = d
+				},
+				now: func() time.Time { return now },
+			}
+
+			_, updated, err := reconciler.reconcile(context.TODO(), placement)
+			require.NoError(t, err)
+			require.Equal(t, testCase.wantPatches, patches)
+			require.Equal(t, testCase.wantEnqueueAfter, enqueuedAfter)
+			require.Equal(t, testCase.wantLastRebalance, updated.Status.LastRebalanceTime)
+		})
+	}
+}
+
+func newReadySyncTarget(name string) *workloadv1alpha1.SyncTarget {
+	return &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: workloadv1alpha1.SyncTargetStatus{
+			Conditions: []conditionsapi.Condition{
+				{
+					Type:   conditionsapi.ReadyCondition,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+}
+
+func withCooldownUntil(syncTarget *workloadv1alpha1.SyncTarget, until time.Time) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.RescheduleCooldownUntil = &metav1.Time{Time: until}
+	return syncTarget
+}
+
+func withEvictAfter(syncTarget *workloadv1alpha1.SyncTarget, evictAfter time.Time) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.EvictAfter = &metav1.Time{Time: evictAfter}
+	return syncTarget
+}
+
+func withNamespaceSelector(syncTarget *workloadv1alpha1.SyncTarget, selector map[string]string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: selector}
+	return syncTarget
+}
+
+func withCapabilities(syncTarget *workloadv1alpha1.SyncTarget, capabilities ...string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Capabilities = capabilities
+	return syncTarget
+}
+
+func withAllocatableCPU(syncTarget *workloadv1alpha1.SyncTarget, cpu string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Allocatable = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	return syncTarget
+}
+
+func withCapacityCPU(syncTarget *workloadv1alpha1.SyncTarget, cpu string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Capacity = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	return syncTarget
+}
+
+func newSyncedNamespace(name string, syncTargets ...string) *corev1.Namespace {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{schedulingv1alpha1.PlacementAnnotationKey: ""},
+		},
+	}
+	for _, syncTarget := range syncTargets {
+		ns.Labels[workloadv1alpha1.ClusterResourceStateLabel(syncTarget)] = string(workloadv1alpha1.ResourceStateSync)
+	}
+	return ns
+}
+
+func withRemoving(ns *corev1.Namespace, syncTarget string) *corev1.Namespace {
+	ns.Annotations[workloadv1alpha1.DeletionAnnotation(syncTarget)] = time.Now().UTC().Format(time.RFC3339)
+	return ns
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
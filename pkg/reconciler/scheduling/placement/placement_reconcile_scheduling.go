@@ -35,6 +35,7 @@ import (
 // the location domain of the cluster workspace.
 type placementReconciler struct {
 	listLocations func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	getPlacement  func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Placement, error)
 }
 
 func (r *placementReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -98,6 +99,9 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 	// TODO(qiujian16): two placements could select the same location. We should
 	// consider whether placements in a workspace should always select different locations.
 	chosenLocation := candidates[rand.Intn(len(candidates))]
+	if affineLocation := r.affineLocation(placement, locationWorkspace, validLocationNames); affineLocation != "" {
+		chosenLocation = affineLocation
+	}
 	placement.Status.SelectedLocation = &schedulingv1alpha1.LocationReference{
 		Path:         locationWorkspace.String(),
 		LocationName: chosenLocation,
@@ -108,6 +112,23 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 	return reconcileStatusContinue, placement, nil
 }
 
+// affineLocation returns the location selected by the placement referenced in spec.placementAffinity
+// if it is valid for the given placement too, and an empty string otherwise.
+func (r *placementReconciler) affineLocation(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, validLocationNames sets.String) string {
+	if placement.Spec.PlacementAffinity == nil {
+		return ""
+	}
+
+	affine, err := r.getPlacement(logicalcluster.From(placement), placement.Spec.PlacementAffinity.Placement)
+	if err != nil {
+		return ""
+	}
+	if !isValidLocationSelected(affine, locationWorkspace, validLocationNames) {
+		return ""
+	}
+	return affine.Status.SelectedLocation.LocationName
+}
+
 func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name) (sets.String, error) {
 	selectedLocations := sets.NewString()
 
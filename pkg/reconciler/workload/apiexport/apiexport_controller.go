@@ -33,9 +33,11 @@ import (
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	apiresourcelisters "github.com/kcp-dev/kcp/pkg/client/listers/apiresource/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 )
@@ -54,6 +56,7 @@ func NewController(
 	apiExportInformer apisinformers.APIExportInformer,
 	apiResourceSchemaInformer apisinformers.APIResourceSchemaInformer,
 	negotiatedAPIResourceInformer apiresourceinformer.NegotiatedAPIResourceInformer,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
 ) (*controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
@@ -70,6 +73,7 @@ func NewController(
 		apiResourceSchemaIndexer:     apiResourceSchemaInformer.Informer().GetIndexer(),
 		negotiatedAPIResourceLister:  negotiatedAPIResourceInformer.Lister(),
 		negotiatedAPIResourceIndexer: negotiatedAPIResourceInformer.Informer().GetIndexer(),
+		syncTargetIndexer:            syncTargetInformer.Informer().GetIndexer(),
 	}
 
 	if err := c.apiResourceSchemaIndexer.AddIndexers(cache.Indexers{
@@ -90,6 +94,12 @@ func NewController(
 		return nil, err
 	}
 
+	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
+		byWorkspace: indexByWorkspace,
+	}); err != nil {
+		return nil, err
+	}
+
 	apiExportInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
 			switch t := obj.(type) {
@@ -111,6 +121,11 @@ func NewController(
 		DeleteFunc: func(obj interface{}) { c.enqueueNegotiatedAPIResource(obj) },
 	})
 
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueSyncTarget(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueSyncTarget(obj) },
+	})
+
 	return c, nil
 }
 
@@ -119,6 +134,7 @@ func NewController(
 // - it creates APIResourceSchemas for every NegotiatedAPIResource in the workspace
 // - it maintains the list of latest resource schemas in the APIExport
 // - it deletes APIResourceSchemas that have no NegotiatedAPIResource in the workspace anymore, but are listed in the APIExport.
+// - it maintains the APIImportUpToDate condition of the SyncTargets in the workspace.
 //
 // It does NOT create APIExport.
 type controller struct {
@@ -133,6 +149,7 @@ type controller struct {
 	apiResourceSchemaIndexer     cache.Indexer
 	negotiatedAPIResourceLister  apiresourcelisters.NegotiatedAPIResourceLister
 	negotiatedAPIResourceIndexer cache.Indexer
+	syncTargetIndexer            cache.Indexer
 }
 
 func (c *controller) enqueueNegotiatedAPIResource(obj interface{}) {
@@ -155,6 +172,26 @@ func (c *controller) enqueueNegotiatedAPIResource(obj interface{}) {
 	c.queue.Add(key)
 }
 
+func (c *controller) enqueueSyncTarget(obj interface{}) {
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a SyncTarget, but is %T", obj))
+		return
+	}
+
+	clusterName := logicalcluster.From(syncTarget)
+	key := clusters.ToClusterAwareKey(clusterName, TemporaryComputeServiceExportName)
+	if _, err := c.apiExportsLister.Get(key); errors.IsNotFound(err) {
+		return // no compute APIExport in the workspace
+	} else if err != nil {
+		runtime.HandleError(fmt.Errorf("failed to get APIExport %s|%s: %w", clusterName, TemporaryComputeServiceExportName, err))
+		return
+	}
+
+	klog.V(4).Infof("Mapping SyncTarget %s|%s to APIExport %q", clusterName, syncTarget.Name, key)
+	c.queue.Add(key)
+}
+
 func (c *controller) enqueueAPIExport(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
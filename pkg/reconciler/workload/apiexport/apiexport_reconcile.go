@@ -35,6 +35,7 @@ import (
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 type reconcileStatus int
@@ -198,6 +199,11 @@ func split3(s string, sep string) (string, string, string, bool) {
 
 func (c *controller) reconcile(ctx context.Context, export *apisv1alpha1.APIExport) error {
 	reconcilers := []reconciler{
+		// runs first, the schema reconciler stops for exports without negotiated resources
+		&syncTargetImportReconciler{
+			listSyncTargets:        c.listSyncTargets,
+			updateSyncTargetStatus: c.updateSyncTargetStatus,
+		},
 		&schemaReconciler{
 			listNegotiatedAPIResources: c.listNegotiatedAPIResources,
 			listAPIResourceSchemas:     c.listAPIResourceSchemas,
@@ -248,6 +254,22 @@ func (c *controller) listAPIResourceSchemas(clusterName logicalcluster.Name) ([]
 	return ret, nil
 }
 
+func (c *controller) listSyncTargets(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+	objs, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, obj.(*workloadv1alpha1.SyncTarget))
+	}
+	return ret, nil
+}
+
+func (c *controller) updateSyncTargetStatus(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+	return c.kcpClusterClient.Cluster(clusterName).WorkloadV1alpha1().SyncTargets().UpdateStatus(ctx, syncTarget, metav1.UpdateOptions{})
+}
+
 func (c *controller) getAPIResourceSchema(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
 	schema, err := c.apiResourceSchemaLister.Get(clusters.ToClusterAwareKey(clusterName, name))
 	if apierrors.IsNotFound(err) {
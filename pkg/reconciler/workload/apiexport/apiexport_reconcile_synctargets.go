@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// syncTargetImportReconciler maintains the APIImportUpToDate condition of the SyncTargets
+// in the workspace of the compute APIExport, comparing the resources of the APIExport
+// with the resources synced by the SyncTargets.
+type syncTargetImportReconciler struct {
+	listSyncTargets        func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
+	updateSyncTargetStatus func(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error)
+}
+
+func (r *syncTargetImportReconciler) reconcile(ctx context.Context, export *apisv1alpha1.APIExport) (reconcileStatus, error) {
+	clusterName := logicalcluster.From(export)
+
+	if export.Name != TemporaryComputeServiceExportName {
+		return reconcileStatusStop, nil
+	}
+
+	exported := exportedResources(export)
+
+	syncTargets, err := r.listSyncTargets(clusterName)
+	if err != nil {
+		return reconcileStatusStop, err
+	}
+
+	var errs []error
+	for _, syncTarget := range syncTargets {
+		updated := syncTarget.DeepCopy()
+		if missing := exported.Difference(sets.NewString(syncTarget.Status.SyncedResources...)); missing.Len() > 0 {
+			conditions.MarkFalse(updated,
+				workloadv1alpha1.APIImportUpToDate,
+				workloadv1alpha1.MissingImportedResourcesReason,
+				conditionsv1alpha1.ConditionSeverityWarning,
+				"Resources of APIExport %s not imported: %s", export.Name, strings.Join(missing.List(), ", "))
+		} else {
+			conditions.MarkTrue(updated, workloadv1alpha1.APIImportUpToDate)
+		}
+
+		if equality.Semantic.DeepEqual(syncTarget.Status, updated.Status) {
+			continue
+		}
+		klog.V(2).Infof("Updating %s condition of SyncTarget %s|%s", workloadv1alpha1.APIImportUpToDate, clusterName, syncTarget.Name)
+		if _, err := r.updateSyncTargetStatus(ctx, clusterName, updated); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return reconcileStatusContinue, errors.NewAggregate(errs)
+}
+
+// exportedResources returns the resources of the latest resource schemas of the export,
+// in the <resource>.<group> format of status.syncedResources of SyncTargets.
+func exportedResources(export *apisv1alpha1.APIExport) sets.String {
+	resources := sets.NewString()
+	for _, schemaName := range export.Spec.LatestResourceSchemas {
+		_, resource, group, ok := split3(schemaName, ".")
+		if !ok {
+			continue
+		}
+		if group == "core" {
+			group = ""
+		}
+		resources.Insert(schema.GroupResource{Group: group, Resource: resource}.String())
+	}
+	return resources
+}
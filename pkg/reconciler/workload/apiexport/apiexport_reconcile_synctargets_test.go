@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncTargetImportReconciler(t *testing.T) {
+	clusterName := logicalcluster.New("root:org:ws")
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster1",
+			ClusterName: clusterName.String(),
+		},
+		Status: workloadv1alpha1.SyncTargetStatus{
+			SyncedResources: []string{"deployments.apps", "services"},
+		},
+	}
+
+	var updates int
+	r := &syncTargetImportReconciler{
+		listSyncTargets: func(logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+			return []*workloadv1alpha1.SyncTarget{syncTarget}, nil
+		},
+		updateSyncTargetStatus: func(_ context.Context, _ logicalcluster.Name, updated *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+			updates++
+			syncTarget = updated
+			return updated, nil
+		},
+	}
+	ctx := context.Background()
+
+	// all exported resources are imported
+	computeExport := export(clusterName, TemporaryComputeServiceExportName, "rev-10.deployments.apps", "rev-11.services.core")
+	_, err := r.reconcile(ctx, computeExport)
+	require.NoError(t, err)
+	require.True(t, conditions.IsTrue(syncTarget, workloadv1alpha1.APIImportUpToDate))
+	require.Equal(t, 1, updates)
+
+	// unchanged condition is not updated again
+	_, err = r.reconcile(ctx, computeExport)
+	require.NoError(t, err)
+	require.Equal(t, 1, updates)
+
+	// the export gains resources which are not imported yet
+	computeExport = export(clusterName, TemporaryComputeServiceExportName, "rev-10.deployments.apps", "rev-12.ingresses.networking.k8s.io", "rev-13.pods.core", "rev-11.services.core")
+	_, err = r.reconcile(ctx, computeExport)
+	require.NoError(t, err)
+	require.True(t, conditions.IsFalse(syncTarget, workloadv1alpha1.APIImportUpToDate))
+	require.Equal(t, workloadv1alpha1.MissingImportedResourcesReason, conditions.GetReason(syncTarget, workloadv1alpha1.APIImportUpToDate))
+	require.Equal(t, "Resources of APIExport kubernetes not imported: ingresses.networking.k8s.io, pods", conditions.GetMessage(syncTarget, workloadv1alpha1.APIImportUpToDate))
+	require.Equal(t, 2, updates)
+
+	// the resources get imported
+	syncTarget.Status.SyncedResources = []string{"deployments.apps", "ingresses.networking.k8s.io", "pods", "services"}
+	_, err = r.reconcile(ctx, computeExport)
+	require.NoError(t, err)
+	require.True(t, conditions.IsTrue(syncTarget, workloadv1alpha1.APIImportUpToDate))
+	require.Equal(t, 3, updates)
+
+	// other exports are ignored
+	_, err = r.reconcile(ctx, export(clusterName, "other", "rev-20.widgets.example.com"))
+	require.NoError(t, err)
+	require.Equal(t, 3, updates)
+}
@@ -19,24 +19,35 @@ package heartbeat
 import (
 	"time"
 
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
 	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/basecontroller"
 )
 
+const controllerName = "kcp-cluster-heartbeat-manager"
+
 func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
 	kcpClusterClient *kcpclient.Cluster,
 	clusterInformer workloadinformer.SyncTargetInformer,
 	apiResourceImportInformer apiresourceinformer.APIResourceImportInformer,
 	heartbeatThreshold time.Duration,
+	syncerCertExpiryWarningWindow time.Duration,
 ) (*basecontroller.ClusterReconciler, error) {
 	cm := &clusterManager{
-		heartbeatThreshold: heartbeatThreshold,
+		heartbeatThreshold:            heartbeatThreshold,
+		syncerCertExpiryWarningWindow: syncerCertExpiryWarningWindow,
+		clock:                         clock.RealClock{},
+		recorder:                      events.NewRecorder(kubeClusterClient, controllerName),
 	}
 
 	r, queue, err := basecontroller.NewClusterReconciler(
-		"kcp-cluster-heartbeat-manager",
+		controllerName,
 		cm,
 		kcpClusterClient,
 		clusterInformer,
@@ -18,11 +18,18 @@ package heartbeat
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
@@ -32,21 +39,35 @@ import (
 
 var _ basecontroller.ClusterReconcileImpl = (*clusterManager)(nil)
 
+// clusterManager marks a SyncTarget HeartbeatHealthy as long as its syncer has
+// reported a heartbeat within heartbeatThreshold. While healthy, the SyncTarget is
+// requeued for the moment the heartbeat would become stale, such that the
+// condition flips to false without waiting for another update event. A fresh
+// heartbeat updates the SyncTarget status, which triggers a reconcile flipping
+// the condition back to true.
 type clusterManager struct {
-	heartbeatThreshold  time.Duration
-	enqueueClusterAfter func(*workloadv1alpha1.SyncTarget, time.Duration)
+	heartbeatThreshold            time.Duration
+	syncerCertExpiryWarningWindow time.Duration
+	enqueueClusterAfter           func(*workloadv1alpha1.SyncTarget, time.Duration)
+	clock                         clock.PassiveClock
+	recorder                      record.EventRecorder
 }
 
 func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.SyncTarget) error {
 	clusterClusterName := logicalcluster.From(cluster)
-	defer conditions.SetSummary(
-		cluster,
-		conditions.WithConditions(
-			workloadv1alpha1.SyncerReady,
-			workloadv1alpha1.APIImporterReady,
-			workloadv1alpha1.HeartbeatHealthy,
-		),
-	)
+	wasNotReady := conditions.Has(cluster, conditionsapi.ReadyCondition) && !conditions.IsTrue(cluster, conditionsapi.ReadyCondition)
+	previousConditions := append(conditionsapi.Conditions(nil), cluster.Status.Conditions...)
+	defer func() {
+		setReadyCondition(cluster)
+		c.updateRescheduleCooldown(cluster, wasNotReady)
+		c.updateTransitionHistory(cluster, previousConditions)
+	}()
+
+	c.updateEvictionProgress(cluster)
+	updateResourceReportConsistency(cluster)
+	updateNamespaceCollisions(cluster)
+	c.updateMaintenanceWindows(cluster)
+	c.updateSyncerCertValidity(cluster)
 
 	latestHeartbeat := time.Time{}
 	if cluster.Status.LastSyncerHeartbeatTime != nil {
@@ -59,7 +80,7 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 			workloadv1alpha1.ErrorHeartbeatMissedReason,
 			conditionsapi.ConditionSeverityWarning,
 			"No heartbeat yet seen")
-	} else if time.Since(latestHeartbeat) > c.heartbeatThreshold {
+	} else if c.clock.Since(latestHeartbeat) > c.heartbeatThreshold {
 		klog.V(5).Infof("Marking HeartbeatHealthy false for SyncTarget %s|%s due to a stale heartbeat", clusterClusterName, cluster.Name)
 		conditions.MarkFalse(cluster,
 			workloadv1alpha1.HeartbeatHealthy,
@@ -71,12 +92,199 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 		conditions.MarkTrue(cluster, workloadv1alpha1.HeartbeatHealthy)
 
 		// Enqueue another check after which the heartbeat should have been updated again.
-		dur := time.Until(latestHeartbeat.Add(c.heartbeatThreshold))
+		dur := latestHeartbeat.Add(c.heartbeatThreshold).Sub(c.clock.Now())
 		c.enqueueClusterAfter(cluster, dur)
 	}
 
 	return nil
 }
 
+const (
+	// evictionStartedReason is the reason of the event recorded when a SyncTarget starts evicting.
+	evictionStartedReason = "EvictionStarted"
+	// evictionCompletedReason is the reason of the event recorded when the eviction grace period of
+	// a SyncTarget has passed.
+	evictionCompletedReason = "EvictionCompleted"
+)
+
+// evictionProgressStep is the granularity in percent in which the eviction progress
+// is updated, in order to limit the number of status updates.
+const evictionProgressStep = 10
+
+// updateEvictionProgress sets status.evictionProgress to the percentage of the
+// eviction grace period passed since spec.evictAfter, rounded down to
+// evictionProgressStep, and requeues the SyncTarget for the next step. Events
+// are recorded when the eviction starts and when it is complete.
+func (c *clusterManager) updateEvictionProgress(cluster *workloadv1alpha1.SyncTarget) {
+	previous := cluster.Status.EvictionProgress
+	defer func() {
+		c.recordEvictionEvents(cluster, previous, cluster.Status.EvictionProgress)
+	}()
+
+	if cluster.Spec.EvictAfter == nil {
+		cluster.Status.EvictionProgress = nil
+		return
+	}
+
+	evictAfter := cluster.Spec.EvictAfter.Time
+	now := c.clock.Now()
+	if now.Before(evictAfter) {
+		cluster.Status.EvictionProgress = nil
+		c.enqueueClusterAfter(cluster, evictAfter.Sub(now))
+		return
+	}
+
+	var gracePeriod time.Duration
+	if cluster.Spec.EvictionGracePeriod != nil {
+		gracePeriod = cluster.Spec.EvictionGracePeriod.Duration
+	}
+	progress := int32(100)
+	if elapsed := now.Sub(evictAfter); elapsed < gracePeriod {
+		progress = int32(elapsed * 100 / gracePeriod)
+		progress -= progress % evictionProgressStep
+
+		next := evictAfter.Add(gracePeriod * time.Duration(progress+evictionProgressStep) / 100)
+		c.enqueueClusterAfter(cluster, next.Sub(now))
+	}
+	cluster.Status.EvictionProgress = &progress
+}
+
+// recordEvictionEvents records an EvictionStarted event when the eviction progress
+// appears, and an EvictionCompleted event when it reaches 100%.
+func (c *clusterManager) recordEvictionEvents(cluster *workloadv1alpha1.SyncTarget, previous, current *int32) {
+	if current == nil {
+		return
+	}
+	if previous == nil {
+		gracePeriod := "at once"
+		if cluster.Spec.EvictionGracePeriod != nil && cluster.Spec.EvictionGracePeriod.Duration > 0 {
+			gracePeriod = "over " + cluster.Spec.EvictionGracePeriod.Duration.String()
+		}
+		c.recorder.Eventf(cluster, corev1.EventTypeNormal, evictionStartedReason,
+			"spec.evictAfter %s has passed, evicting the namespaces scheduled to the SyncTarget %s", cluster.Spec.EvictAfter.UTC().Format(time.RFC3339), gracePeriod)
+	}
+	if *current == 100 && (previous == nil || *previous < 100) {
+		c.recorder.Event(cluster, corev1.EventTypeNormal, evictionCompletedReason,
+			"The eviction grace period has passed, all namespaces scheduled to the SyncTarget are evicted")
+	}
+}
+
+// updateRescheduleCooldown sets status.rescheduleCooldownUntil to spec.rescheduleCooldown
+// from now when the SyncTarget returns to Ready after having been not Ready, and clears
+// it once the cooldown has passed. While the cooldown is in effect, the SyncTarget is
+// requeued for its end.
+func (c *clusterManager) updateRescheduleCooldown(cluster *workloadv1alpha1.SyncTarget, wasNotReady bool) {
+	if cluster.Spec.RescheduleCooldown == nil || cluster.Spec.RescheduleCooldown.Duration <= 0 {
+		cluster.Status.RescheduleCooldownUntil = nil
+		return
+	}
+
+	now := c.clock.Now()
+	if wasNotReady && conditions.IsTrue(cluster, conditionsapi.ReadyCondition) {
+		until := metav1.NewTime(now.Add(cluster.Spec.RescheduleCooldown.Duration))
+		cluster.Status.RescheduleCooldownUntil = &until
+	}
+
+	if until := cluster.Status.RescheduleCooldownUntil; until != nil {
+		if !now.Before(until.Time) {
+			cluster.Status.RescheduleCooldownUntil = nil
+			return
+		}
+		c.enqueueClusterAfter(cluster, until.Sub(now))
+	}
+}
+
+// updateResourceReportConsistency marks ResourceReportConsistent false if the syncer reports
+// more allocatable than capacity for any resource. Schedulers cap the allocatable resources at
+// the capacity in that case. The condition is removed while allocatable or capacity are not reported.
+func updateResourceReportConsistency(cluster *workloadv1alpha1.SyncTarget) {
+	if cluster.Status.Allocatable == nil || cluster.Status.Capacity == nil {
+		conditions.Delete(cluster, workloadv1alpha1.ResourceReportConsistent)
+		return
+	}
+
+	var inconsistent []string
+	for name, allocatable := range *cluster.Status.Allocatable {
+		if capacity, found := (*cluster.Status.Capacity)[name]; found && allocatable.Cmp(capacity) > 0 {
+			inconsistent = append(inconsistent, fmt.Sprintf("%s (allocatable %s, capacity %s)", name, allocatable.String(), capacity.String()))
+		}
+	}
+	if len(inconsistent) == 0 {
+		conditions.MarkTrue(cluster, workloadv1alpha1.ResourceReportConsistent)
+		return
+	}
+
+	sort.Strings(inconsistent)
+	klog.V(2).Infof("SyncTarget %s|%s reports more allocatable than capacity: %s", logicalcluster.From(cluster), cluster.Name, strings.Join(inconsistent, ", "))
+	conditions.MarkFalse(cluster,
+		workloadv1alpha1.ResourceReportConsistent,
+		workloadv1alpha1.ResourceReportInconsistentReason,
+		conditionsapi.ConditionSeverityWarning,
+		"Allocatable exceeds capacity for %s", strings.Join(inconsistent, ", "))
+}
+
+// updateNamespaceCollisions marks NoNamespaceCollision false, naming the downstream namespaces, if
+// the syncer reports upstream namespaces which are not synced because of namespace collisions. The
+// condition is only added on the first collision, and turns true once the collisions are resolved.
+func updateNamespaceCollisions(cluster *workloadv1alpha1.SyncTarget) {
+	if len(cluster.Status.NamespaceCollisions) == 0 {
+		if conditions.Has(cluster, workloadv1alpha1.NoNamespaceCollision) {
+			conditions.MarkTrue(cluster, workloadv1alpha1.NoNamespaceCollision)
+		}
+		return
+	}
+
+	collisions := make([]string, 0, len(cluster.Status.NamespaceCollisions))
+	for _, collision := range cluster.Status.NamespaceCollisions {
+		collisions = append(collisions, fmt.Sprintf("%s (upstream namespace %s|%s)", collision.DownstreamNamespace, collision.Workspace, collision.Namespace))
+	}
+	klog.V(2).Infof("SyncTarget %s|%s reports namespace collisions: %s", logicalcluster.From(cluster), cluster.Name, strings.Join(collisions, ", "))
+	conditions.MarkFalse(cluster,
+		workloadv1alpha1.NoNamespaceCollision,
+		workloadv1alpha1.NamespaceCollisionReason,
+		conditionsapi.ConditionSeverityWarning,
+		"Downstream namespaces collide: %s", strings.Join(collisions, ", "))
+}
+
+// readySubConditions are the conditions aggregated into the Ready condition of a
+// SyncTarget, in the order they are reported.
+var readySubConditions = []conditionsapi.ConditionType{
+	workloadv1alpha1.SyncerReady,
+	workloadv1alpha1.APIImporterReady,
+	workloadv1alpha1.HeartbeatHealthy,
+}
+
+// setReadyCondition sets the Ready condition of the SyncTarget to true if all of
+// readySubConditions are true and at least spec.minSyncedResources resources are
+// synced. Otherwise, Ready takes the status and severity of the first sub-condition
+// which is not true, and its type as reason. Sub-conditions not reported yet are
+// ignored.
+func setReadyCondition(cluster *workloadv1alpha1.SyncTarget) {
+	for _, t := range readySubConditions {
+		c := conditions.Get(cluster, t)
+		if c == nil || c.Status == corev1.ConditionTrue {
+			continue
+		}
+
+		if c.Status == corev1.ConditionFalse {
+			conditions.MarkFalse(cluster, conditionsapi.ReadyCondition, string(t), c.Severity, "%s: %s", c.Reason, c.Message)
+		} else {
+			conditions.MarkUnknown(cluster, conditionsapi.ReadyCondition, string(t), "%s: %s", c.Reason, c.Message)
+		}
+		return
+	}
+
+	if synced, min := len(cluster.Status.SyncedResources), int(cluster.Spec.MinSyncedResources); synced < min {
+		conditions.MarkFalse(cluster,
+			conditionsapi.ReadyCondition,
+			workloadv1alpha1.InsufficientSyncedResourcesReason,
+			conditionsapi.ConditionSeverityInfo,
+			"%d of at least %d resources synced", synced, min)
+		return
+	}
+
+	conditions.MarkTrue(cluster, conditionsapi.ReadyCondition)
+}
+
 func (c *clusterManager) Cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.SyncTarget) {
 }
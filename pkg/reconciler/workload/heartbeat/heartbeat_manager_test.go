@@ -18,13 +18,23 @@ package heartbeat
 
 import (
 	"context"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
@@ -55,6 +65,7 @@ func TestManager(t *testing.T) {
 			mgr := clusterManager{
 				heartbeatThreshold:  time.Minute,
 				enqueueClusterAfter: enqueueFunc,
+				clock:               clock.RealClock{},
 			}
 			ctx := context.Background()
 			heartbeat := metav1.NewTime(c.lastHeartbeatTime)
@@ -84,3 +95,445 @@ func TestManager(t *testing.T) {
 		})
 	}
 }
+
+func TestManagerHeartbeatTransitions(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+
+	var enqueued time.Duration
+	mgr := clusterManager{
+		heartbeatThreshold: time.Minute,
+		enqueueClusterAfter: func(_ *workloadv1alpha1.SyncTarget, dur time.Duration) {
+			enqueued = dur
+		},
+		clock: fakeClock,
+	}
+	ctx := context.Background()
+
+	heartbeat := metav1.NewTime(fakeClock.Now())
+	cl := &workloadv1alpha1.SyncTarget{
+		Status: workloadv1alpha1.SyncTargetStatus{
+			LastSyncerHeartbeatTime: &heartbeat,
+		},
+	}
+
+	// fresh heartbeat
+	if err := mgr.Reconcile(ctx, cl); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !conditions.IsTrue(cl, workloadv1alpha1.HeartbeatHealthy) {
+		t.Errorf("expected HeartbeatHealthy to be true after a fresh heartbeat")
+	}
+	if enqueued != time.Minute {
+		t.Errorf("next enqueue time; got %s, want %s", enqueued, time.Minute)
+	}
+
+	// advance the clock past the threshold without a new heartbeat
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute + time.Second))
+	if err := mgr.Reconcile(ctx, cl); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !conditions.IsFalse(cl, workloadv1alpha1.HeartbeatHealthy) {
+		t.Errorf("expected HeartbeatHealthy to be false after the threshold passed")
+	}
+	if got := conditions.GetReason(cl, workloadv1alpha1.HeartbeatHealthy); got != workloadv1alpha1.ErrorHeartbeatMissedReason {
+		t.Errorf("HeartbeatHealthy reason; got %q, want %q", got, workloadv1alpha1.ErrorHeartbeatMissedReason)
+	}
+
+	// a new heartbeat arrives
+	heartbeat = metav1.NewTime(fakeClock.Now())
+	cl.Status.LastSyncerHeartbeatTime = &heartbeat
+	if err := mgr.Reconcile(ctx, cl); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !conditions.IsTrue(cl, workloadv1alpha1.HeartbeatHealthy) {
+		t.Errorf("expected HeartbeatHealthy to be true after a new heartbeat")
+	}
+}
+
+func TestSetReadyCondition(t *testing.T) {
+	trueCondition := func(t conditionsv1alpha1.ConditionType) conditionsv1alpha1.Condition {
+		return conditionsv1alpha1.Condition{Type: t, Status: corev1.ConditionTrue}
+	}
+	falseCondition := func(t conditionsv1alpha1.ConditionType) conditionsv1alpha1.Condition {
+		return conditionsv1alpha1.Condition{Type: t, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityError, Reason: "Broken", Message: "it broke"}
+	}
+
+	for _, c := range []struct {
+		desc       string
+		conditions conditionsv1alpha1.Conditions
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{{
+		desc: "all sub-conditions true",
+		conditions: conditionsv1alpha1.Conditions{
+			trueCondition(workloadv1alpha1.SyncerReady),
+			trueCondition(workloadv1alpha1.APIImporterReady),
+			trueCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionTrue,
+	}, {
+		desc: "syncer not ready",
+		conditions: conditionsv1alpha1.Conditions{
+			falseCondition(workloadv1alpha1.SyncerReady),
+			trueCondition(workloadv1alpha1.APIImporterReady),
+			trueCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: string(workloadv1alpha1.SyncerReady),
+	}, {
+		desc: "api importer not ready",
+		conditions: conditionsv1alpha1.Conditions{
+			trueCondition(workloadv1alpha1.SyncerReady),
+			falseCondition(workloadv1alpha1.APIImporterReady),
+			trueCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: string(workloadv1alpha1.APIImporterReady),
+	}, {
+		desc: "heartbeat not healthy",
+		conditions: conditionsv1alpha1.Conditions{
+			trueCondition(workloadv1alpha1.SyncerReady),
+			trueCondition(workloadv1alpha1.APIImporterReady),
+			falseCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: string(workloadv1alpha1.HeartbeatHealthy),
+	}, {
+		desc: "first failing sub-condition is reported",
+		conditions: conditionsv1alpha1.Conditions{
+			trueCondition(workloadv1alpha1.SyncerReady),
+			falseCondition(workloadv1alpha1.APIImporterReady),
+			falseCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: string(workloadv1alpha1.APIImporterReady),
+	}, {
+		desc: "unknown sub-condition",
+		conditions: conditionsv1alpha1.Conditions{
+			{Type: workloadv1alpha1.SyncerReady, Status: corev1.ConditionUnknown},
+			trueCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionUnknown,
+		wantReason: string(workloadv1alpha1.SyncerReady),
+	}, {
+		desc: "unreported sub-conditions are ignored",
+		conditions: conditionsv1alpha1.Conditions{
+			trueCondition(workloadv1alpha1.HeartbeatHealthy),
+		},
+		wantStatus: corev1.ConditionTrue,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			cl := &workloadv1alpha1.SyncTarget{
+				Status: workloadv1alpha1.SyncTargetStatus{Conditions: c.conditions},
+			}
+			setReadyCondition(cl)
+
+			ready := conditions.Get(cl, conditionsv1alpha1.ReadyCondition)
+			if ready == nil {
+				t.Fatalf("expected a Ready condition")
+			}
+			if ready.Status != c.wantStatus {
+				t.Errorf("Ready status; got %q, want %q", ready.Status, c.wantStatus)
+			}
+			if ready.Reason != c.wantReason {
+				t.Errorf("Ready reason; got %q, want %q", ready.Reason, c.wantReason)
+			}
+		})
+	}
+}
+
+func TestEvictionProgress(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	evictAfter := fakeClock.Now().Add(time.Minute)
+
+	var enqueued []time.Duration
+	recorder := record.NewFakeRecorder(10)
+	mgr := clusterManager{
+		heartbeatThreshold: time.Minute,
+		enqueueClusterAfter: func(_ *workloadv1alpha1.SyncTarget, dur time.Duration) {
+			enqueued = append(enqueued, dur)
+		},
+		clock:    fakeClock,
+		recorder: recorder,
+	}
+	cl := &workloadv1alpha1.SyncTarget{
+		Spec: workloadv1alpha1.SyncTargetSpec{
+			EvictAfter:          &metav1.Time{Time: evictAfter},
+			EvictionGracePeriod: &metav1.Duration{Duration: 100 * time.Minute},
+		},
+	}
+
+	for _, c := range []struct {
+		desc         string
+		now          time.Time
+		wantProgress *int32
+		wantEnqueued time.Duration
+		wantEvents   []string
+	}{{
+		desc:         "before evictAfter",
+		now:          fakeClock.Now(),
+		wantEnqueued: time.Minute,
+	}, {
+		desc:         "at evictAfter",
+		now:          evictAfter,
+		wantProgress: pointer.Int32(0),
+		wantEnqueued: 10 * time.Minute,
+		wantEvents: []string{
+			"Normal EvictionStarted spec.evictAfter " + evictAfter.UTC().Format(time.RFC3339) + " has passed, evicting the namespaces scheduled to the SyncTarget over 1h40m0s",
+		},
+	}, {
+		desc:         "within the grace period",
+		now:          evictAfter.Add(35 * time.Minute),
+		wantProgress: pointer.Int32(30),
+		wantEnqueued: 5 * time.Minute,
+	}, {
+		desc:         "after the grace period",
+		now:          evictAfter.Add(2 * time.Hour),
+		wantProgress: pointer.Int32(100),
+		wantEvents: []string{
+			"Normal EvictionCompleted The eviction grace period has passed, all namespaces scheduled to the SyncTarget are evicted",
+		},
+	}, {
+		desc:         "completed eviction is not reported again",
+		now:          evictAfter.Add(3 * time.Hour),
+		wantProgress: pointer.Int32(100),
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			fakeClock.SetTime(c.now)
+			enqueued = nil
+			if err := mgr.Reconcile(context.Background(), cl); err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			if !reflect.DeepEqual(cl.Status.EvictionProgress, c.wantProgress) {
+				t.Errorf("eviction progress; got %v, want %v", printInt32(cl.Status.EvictionProgress), printInt32(c.wantProgress))
+			}
+			// the first enqueue is the eviction progress, the next ones are for the heartbeat.
+			if c.wantEnqueued != 0 && (len(enqueued) == 0 || enqueued[0] != c.wantEnqueued) {
+				t.Errorf("next enqueue time; got %v, want %s", enqueued, c.wantEnqueued)
+			}
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			if !reflect.DeepEqual(events, c.wantEvents) {
+				t.Errorf("events; got %q, want %q", events, c.wantEvents)
+			}
+		})
+	}
+}
+
+func printInt32(i *int32) string {
+	if i == nil {
+		return "<nil>"
+	}
+	return strconv.Itoa(int(*i))
+}
+
+func TestSetReadyConditionMinSyncedResources(t *testing.T) {
+	cl := &workloadv1alpha1.SyncTarget{
+		Spec: workloadv1alpha1.SyncTargetSpec{MinSyncedResources: 3},
+		Status: workloadv1alpha1.SyncTargetStatus{
+			Conditions: conditionsv1alpha1.Conditions{
+				{Type: workloadv1alpha1.SyncerReady, Status: corev1.ConditionTrue},
+				{Type: workloadv1alpha1.HeartbeatHealthy, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	for i, resource := range []string{"", "deployments.apps", "services", "ingresses.networking.k8s.io"} {
+		if resource != "" {
+			cl.Status.SyncedResources = append(cl.Status.SyncedResources, resource)
+		}
+		setReadyCondition(cl)
+
+		wantReady := i == 3
+		if got := conditions.IsTrue(cl, conditionsv1alpha1.ReadyCondition); got != wantReady {
+			t.Errorf("Ready with %d synced resources; got %t, want %t", len(cl.Status.SyncedResources), got, wantReady)
+		}
+		if !wantReady {
+			if got := conditions.GetReason(cl, conditionsv1alpha1.ReadyCondition); got != workloadv1alpha1.InsufficientSyncedResourcesReason {
+				t.Errorf("Ready reason with %d synced resources; got %q, want %q", len(cl.Status.SyncedResources), got, workloadv1alpha1.InsufficientSyncedResourcesReason)
+			}
+		}
+	}
+}
+
+func TestResourceReportConsistency(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		allocatable *corev1.ResourceList
+		capacity    *corev1.ResourceList
+		want        *corev1.ConditionStatus
+	}{
+		{
+			name: "nothing reported",
+		},
+		{
+			name:        "only allocatable reported",
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+		},
+		{
+			name:        "consistent",
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3500m"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			capacity:    &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			want:        conditionStatusPtr(corev1.ConditionTrue),
+		},
+		{
+			name:        "allocatable exceeds capacity",
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			capacity:    &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			want:        conditionStatusPtr(corev1.ConditionFalse),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := &workloadv1alpha1.SyncTarget{
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Allocatable: tc.allocatable,
+					Capacity:    tc.capacity,
+					Conditions: conditionsv1alpha1.Conditions{
+						{Type: workloadv1alpha1.ResourceReportConsistent, Status: corev1.ConditionUnknown},
+					},
+				},
+			}
+			updateResourceReportConsistency(cl)
+
+			c := conditions.Get(cl, workloadv1alpha1.ResourceReportConsistent)
+			if tc.want == nil {
+				if c != nil {
+					t.Errorf("ResourceReportConsistent = %v, want none", c)
+				}
+				return
+			}
+			if c == nil {
+				t.Fatalf("ResourceReportConsistent not set, want %s", *tc.want)
+			}
+			if c.Status != *tc.want {
+				t.Errorf("ResourceReportConsistent = %s, want %s", c.Status, *tc.want)
+			}
+			if c.Status == corev1.ConditionFalse && c.Reason != workloadv1alpha1.ResourceReportInconsistentReason {
+				t.Errorf("ResourceReportConsistent reason = %q, want %q", c.Reason, workloadv1alpha1.ResourceReportInconsistentReason)
+			}
+		})
+	}
+}
+
+func TestUpdateNamespaceCollisions(t *testing.T) {
+	cl := &workloadv1alpha1.SyncTarget{}
+	updateNamespaceCollisions(cl)
+	require.Nil(t, conditions.Get(cl, workloadv1alpha1.NoNamespaceCollision), "expected no condition without collisions")
+
+	cl.Status.NamespaceCollisions = []workloadv1alpha1.NamespaceCollision{
+		{DownstreamNamespace: "kcp-abc", Workspace: "root:org:a", Namespace: "test"},
+	}
+	updateNamespaceCollisions(cl)
+	c := conditions.Get(cl, workloadv1alpha1.NoNamespaceCollision)
+	require.NotNil(t, c)
+	require.Equal(t, corev1.ConditionFalse, c.Status)
+	require.Equal(t, workloadv1alpha1.NamespaceCollisionReason, c.Reason)
+	require.Contains(t, c.Message, "kcp-abc (upstream namespace root:org:a|test)")
+
+	cl.Status.NamespaceCollisions = nil
+	updateNamespaceCollisions(cl)
+	require.True(t, conditions.IsTrue(cl, workloadv1alpha1.NoNamespaceCollision), "expected the condition to turn true once resolved")
+}
+
+func conditionStatusPtr(s corev1.ConditionStatus) *corev1.ConditionStatus {
+	return &s
+}
+
+func TestRescheduleCooldown(t *testing.T) {
+	start := time.Now()
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+
+	var enqueued []time.Duration
+	mgr := clusterManager{
+		heartbeatThreshold: time.Minute,
+		enqueueClusterAfter: func(_ *workloadv1alpha1.SyncTarget, dur time.Duration) {
+			enqueued = append(enqueued, dur)
+		},
+		clock: fakeClock,
+	}
+	cl := &workloadv1alpha1.SyncTarget{
+		Spec: workloadv1alpha1.SyncTargetSpec{
+			RescheduleCooldown: &metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+
+	for _, c := range []struct {
+		desc         string
+		now          time.Time
+		heartbeat    bool
+		wantReady    bool
+		wantUntil    *time.Time
+		wantEnqueued time.Duration
+	}{{
+		desc:      "initially ready without cooldown",
+		now:       start,
+		heartbeat: true,
+		wantReady: true,
+	}, {
+		desc:      "unreachable",
+		now:       start.Add(2 * time.Minute),
+		wantReady: false,
+	}, {
+		desc:         "back to ready starts the cooldown",
+		now:          start.Add(3 * time.Minute),
+		heartbeat:    true,
+		wantReady:    true,
+		wantUntil:    timePtr(start.Add(8 * time.Minute)),
+		wantEnqueued: 5 * time.Minute,
+	}, {
+		desc:         "cooldown is kept while ready",
+		now:          start.Add(4 * time.Minute),
+		heartbeat:    true,
+		wantReady:    true,
+		wantUntil:    timePtr(start.Add(8 * time.Minute)),
+		wantEnqueued: 4 * time.Minute,
+	}, {
+		desc:      "unreachable again during the cooldown",
+		now:       start.Add(6 * time.Minute),
+		wantReady: false,
+		wantUntil: timePtr(start.Add(8 * time.Minute)),
+	}, {
+		desc:         "back to ready restarts the cooldown",
+		now:          start.Add(7 * time.Minute),
+		heartbeat:    true,
+		wantReady:    true,
+		wantUntil:    timePtr(start.Add(12 * time.Minute)),
+		wantEnqueued: 5 * time.Minute,
+	}, {
+		desc:      "cooldown passed",
+		now:       start.Add(12 * time.Minute),
+		heartbeat: true,
+		wantReady: true,
+	}} {
+		fakeClock.SetTime(c.now)
+		if c.heartbeat {
+			heartbeat := metav1.NewTime(c.now)
+			cl.Status.LastSyncerHeartbeatTime = &heartbeat
+		}
+		enqueued = nil
+		if err := mgr.Reconcile(context.Background(), cl); err != nil {
+			t.Fatalf("%s: Reconcile: %v", c.desc, err)
+		}
+
+		if got := conditions.IsTrue(cl, conditionsv1alpha1.ReadyCondition); got != c.wantReady {
+			t.Errorf("%s: ready; got %v, want %v", c.desc, got, c.wantReady)
+		}
+		var gotUntil *time.Time
+		if cl.Status.RescheduleCooldownUntil != nil {
+			gotUntil = &cl.Status.RescheduleCooldownUntil.Time
+		}
+		if !reflect.DeepEqual(gotUntil, c.wantUntil) {
+			t.Errorf("%s: cooldown until; got %v, want %v", c.desc, gotUntil, c.wantUntil)
+		}
+		// the cooldown enqueue is the last one.
+		if c.wantEnqueued != 0 && (len(enqueued) == 0 || enqueued[len(enqueued)-1] != c.wantEnqueued) {
+			t.Errorf("%s: next enqueue time; got %v, want %s", c.desc, enqueued, c.wantEnqueued)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -25,22 +25,28 @@ import (
 
 func DefaultOptions() *Options {
 	return &Options{
-		HeartbeatThreshold: time.Minute,
+		HeartbeatThreshold:            time.Minute,
+		SyncerCertExpiryWarningWindow: 30 * 24 * time.Hour,
 	}
 }
 
 func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
 	fs.DurationVar(&o.HeartbeatThreshold, "sync-target-heartbeat-threshold", o.HeartbeatThreshold, "Amount of time to wait for a successful heartbeat before marking the cluster as not ready")
+	fs.DurationVar(&o.SyncerCertExpiryWarningWindow, "sync-target-syncer-cert-expiry-warning-window", o.SyncerCertExpiryWarningWindow, "Amount of time before the expiry of the client certificate of a syncer in which the SyncerCertValid condition of the SyncTarget warns about the expiry")
 	return o
 }
 
 type Options struct {
-	HeartbeatThreshold time.Duration
+	HeartbeatThreshold            time.Duration
+	SyncerCertExpiryWarningWindow time.Duration
 }
 
 func (o *Options) Validate() error {
 	if o.HeartbeatThreshold <= 0 {
 		return fmt.Errorf("--sync-target-heartbeat-threshold must be >0 (%s)", o.HeartbeatThreshold)
 	}
+	if o.SyncerCertExpiryWarningWindow < 0 {
+		return fmt.Errorf("--sync-target-syncer-cert-expiry-warning-window must be >=0 (%s)", o.SyncerCertExpiryWarningWindow)
+	}
 	return nil
 }
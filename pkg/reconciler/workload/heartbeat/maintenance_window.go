@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cron"
+)
+
+// updateMaintenanceWindows marks OutsideMaintenanceWindow false while one of spec.maintenanceWindows
+// is active, sets status.nextMaintenanceWindow, and requeues the SyncTarget for the next window
+// boundary. Windows with an invalid schedule are ignored. The condition is removed when no
+// maintenance windows are configured.
+func (c *clusterManager) updateMaintenanceWindows(cluster *workloadv1alpha1.SyncTarget) {
+	if len(cluster.Spec.MaintenanceWindows) == 0 {
+		conditions.Delete(cluster, workloadv1alpha1.OutsideMaintenanceWindow)
+		cluster.Status.NextMaintenanceWindow = nil
+		return
+	}
+
+	now := c.clock.Now()
+	var activeUntil, next time.Time
+	for _, window := range cluster.Spec.MaintenanceWindows {
+		schedule, err := cron.Parse(window.Schedule)
+		if err != nil {
+			klog.Errorf("Invalid maintenance window schedule %q of SyncTarget %s|%s: %v", window.Schedule, logicalcluster.From(cluster), cluster.Name, err)
+			continue
+		}
+		duration := window.Duration.Duration
+		if duration <= 0 {
+			continue
+		}
+
+		// windows started less than their duration ago are active.
+		for start := schedule.Next(now.Add(-duration)); !start.IsZero() && !start.After(now); start = schedule.Next(start) {
+			if end := start.Add(duration); end.After(activeUntil) {
+				activeUntil = end
+			}
+		}
+		if start := schedule.Next(now); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+
+	if next.IsZero() {
+		cluster.Status.NextMaintenanceWindow = nil
+	} else {
+		nextTime := metav1.NewTime(next)
+		cluster.Status.NextMaintenanceWindow = &nextTime
+	}
+
+	boundary := next
+	if activeUntil.After(now) {
+		klog.V(4).Infof("SyncTarget %s|%s is in a maintenance window until %s", logicalcluster.From(cluster), cluster.Name, activeUntil)
+		conditions.MarkFalse(cluster,
+			workloadv1alpha1.OutsideMaintenanceWindow,
+			workloadv1alpha1.InMaintenanceWindowReason,
+			conditionsapi.ConditionSeverityInfo,
+			"In maintenance window until %s", activeUntil.Format(time.RFC3339))
+		if boundary.IsZero() || activeUntil.Before(boundary) {
+			boundary = activeUntil
+		}
+	} else {
+		conditions.MarkTrue(cluster, workloadv1alpha1.OutsideMaintenanceWindow)
+	}
+	if !boundary.IsZero() {
+		c.enqueueClusterAfter(cluster, boundary.Sub(now))
+	}
+}
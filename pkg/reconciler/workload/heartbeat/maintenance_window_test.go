@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	// a Saturday
+	saturday := time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)
+	saturdayNight := []workloadv1alpha1.MaintenanceWindow{
+		{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+	}
+
+	for _, tc := range []struct {
+		name         string
+		windows      []workloadv1alpha1.MaintenanceWindow
+		now          time.Time
+		want         *corev1.ConditionStatus
+		wantNext     *time.Time
+		wantEnqueued time.Duration
+	}{
+		{
+			name: "no maintenance windows",
+			now:  saturday,
+		},
+		{
+			name:         "future window",
+			windows:      saturdayNight,
+			now:          saturday.Add(-12 * time.Hour),
+			want:         conditionStatusPtr(corev1.ConditionTrue),
+			wantNext:     timePtr(saturday.Add(2 * time.Hour)),
+			wantEnqueued: 14 * time.Hour,
+		},
+		{
+			name:         "active window",
+			windows:      saturdayNight,
+			now:          saturday.Add(3 * time.Hour),
+			want:         conditionStatusPtr(corev1.ConditionFalse),
+			wantNext:     timePtr(saturday.Add(7*24*time.Hour + 2*time.Hour)),
+			wantEnqueued: 3 * time.Hour,
+		},
+		{
+			name:         "window just ended",
+			windows:      saturdayNight,
+			now:          saturday.Add(6 * time.Hour),
+			want:         conditionStatusPtr(corev1.ConditionTrue),
+			wantNext:     timePtr(saturday.Add(7*24*time.Hour + 2*time.Hour)),
+			wantEnqueued: 7*24*time.Hour - 4*time.Hour,
+		},
+		{
+			name: "active window is followed by the next window",
+			windows: append([]workloadv1alpha1.MaintenanceWindow{
+				{Schedule: "30 3 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}, saturdayNight...),
+			now:          saturday.Add(3 * time.Hour),
+			want:         conditionStatusPtr(corev1.ConditionFalse),
+			wantNext:     timePtr(saturday.Add(3*time.Hour + 30*time.Minute)),
+			wantEnqueued: 30 * time.Minute,
+		},
+		{
+			name: "invalid schedules are ignored",
+			windows: []workloadv1alpha1.MaintenanceWindow{
+				{Schedule: "every saturday", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			now:  saturday,
+			want: conditionStatusPtr(corev1.ConditionTrue),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var enqueued []time.Duration
+			mgr := clusterManager{
+				enqueueClusterAfter: func(_ *workloadv1alpha1.SyncTarget, dur time.Duration) {
+					enqueued = append(enqueued, dur)
+				},
+				clock: clocktesting.NewFakePassiveClock(tc.now),
+			}
+			cl := &workloadv1alpha1.SyncTarget{
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					MaintenanceWindows: tc.windows,
+				},
+			}
+			mgr.updateMaintenanceWindows(cl)
+
+			c := conditions.Get(cl, workloadv1alpha1.OutsideMaintenanceWindow)
+			switch {
+			case tc.want == nil && c != nil:
+				t.Errorf("OutsideMaintenanceWindow = %v, want none", c)
+			case tc.want != nil && c == nil:
+				t.Errorf("OutsideMaintenanceWindow not set, want %s", *tc.want)
+			case tc.want != nil && c.Status != *tc.want:
+				t.Errorf("OutsideMaintenanceWindow = %s, want %s", c.Status, *tc.want)
+			case c != nil && c.Status == corev1.ConditionFalse && c.Reason != workloadv1alpha1.InMaintenanceWindowReason:
+				t.Errorf("OutsideMaintenanceWindow reason = %q, want %q", c.Reason, workloadv1alpha1.InMaintenanceWindowReason)
+			}
+
+			var gotNext *time.Time
+			if cl.Status.NextMaintenanceWindow != nil {
+				gotNext = &cl.Status.NextMaintenanceWindow.Time
+			}
+			if (gotNext == nil) != (tc.wantNext == nil) || (gotNext != nil && !gotNext.Equal(*tc.wantNext)) {
+				t.Errorf("next maintenance window; got %v, want %v", gotNext, tc.wantNext)
+			}
+
+			switch {
+			case tc.wantEnqueued == 0 && len(enqueued) > 0:
+				t.Errorf("next enqueue time; got %v, want none", enqueued)
+			case tc.wantEnqueued != 0 && (len(enqueued) != 1 || enqueued[0] != tc.wantEnqueued):
+				t.Errorf("next enqueue time; got %v, want %s", enqueued, tc.wantEnqueued)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// syncerCertExpiringEventReason is the reason of the event recorded when the client certificate
+// of the syncer enters the expiry warning window.
+const syncerCertExpiringEventReason = "SyncerCertExpiring"
+
+// updateSyncerCertValidity sets SyncerCertValid and status.syncerCertificateDaysToExpiry from the
+// status.syncerCertificateNotAfter reported by the syncer. The condition is true with the
+// SyncerCertExpiring reason while the certificate expires within syncerCertExpiryWarningWindow,
+// and false once it has expired. The SyncTarget is requeued for the next change of the days to
+// expiry or of the condition. Both are removed if the syncer does not report a certificate.
+func (c *clusterManager) updateSyncerCertValidity(cluster *workloadv1alpha1.SyncTarget) {
+	if cluster.Status.SyncerCertificateNotAfter == nil {
+		conditions.Delete(cluster, workloadv1alpha1.SyncerCertValid)
+		cluster.Status.SyncerCertificateDaysToExpiry = nil
+		return
+	}
+
+	notAfter := cluster.Status.SyncerCertificateNotAfter.Time
+	remaining := notAfter.Sub(c.clock.Now())
+	if remaining <= 0 {
+		days := int64(0)
+		cluster.Status.SyncerCertificateDaysToExpiry = &days
+		conditions.MarkFalse(cluster,
+			workloadv1alpha1.SyncerCertValid,
+			workloadv1alpha1.SyncerCertExpiredReason,
+			conditionsapi.ConditionSeverityError,
+			"The client certificate of the syncer expired at %s", notAfter.UTC().Format(time.RFC3339))
+		return
+	}
+
+	days := int64(remaining / (24 * time.Hour))
+	cluster.Status.SyncerCertificateDaysToExpiry = &days
+
+	if untilWarning := remaining - c.syncerCertExpiryWarningWindow; untilWarning > 0 {
+		conditions.MarkTrue(cluster, workloadv1alpha1.SyncerCertValid)
+		c.enqueueClusterAfter(cluster, minDuration(untilWarning, untilNextDay(remaining)))
+		return
+	}
+
+	if previous := conditions.Get(cluster, workloadv1alpha1.SyncerCertValid); previous == nil || previous.Reason != workloadv1alpha1.SyncerCertExpiringReason {
+		klog.V(2).Infof("The client certificate of the syncer of SyncTarget %s|%s expires at %s", logicalcluster.From(cluster), cluster.Name, notAfter)
+		c.recorder.Eventf(cluster, corev1.EventTypeWarning, syncerCertExpiringEventReason,
+			"The client certificate of the syncer expires at %s, in %d days", notAfter.UTC().Format(time.RFC3339), days)
+	}
+	condition := conditions.TrueCondition(workloadv1alpha1.SyncerCertValid)
+	condition.Reason = workloadv1alpha1.SyncerCertExpiringReason
+	condition.Message = "The client certificate of the syncer expires at " + notAfter.UTC().Format(time.RFC3339)
+	conditions.Set(cluster, condition)
+	c.enqueueClusterAfter(cluster, untilNextDay(remaining))
+}
+
+// untilNextDay returns the duration until the remaining time drops to the next lower number of
+// full days, i.e. until the days to expiry change.
+func untilNextDay(remaining time.Duration) time.Duration {
+	if d := remaining % (24 * time.Hour); d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
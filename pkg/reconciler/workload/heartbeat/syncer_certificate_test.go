@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncerCertValidity(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	notAfter := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: now.Add(d)}
+	}
+
+	for _, tc := range []struct {
+		name          string
+		notAfter      *metav1.Time
+		wantStatus    corev1.ConditionStatus
+		wantReason    string
+		wantSeverity  conditionsv1alpha1.ConditionSeverity
+		wantDays      *int64
+		wantEnqueued  time.Duration
+		wantEvent     bool
+		wantCondition bool
+	}{
+		{
+			name: "no certificate reported",
+		},
+		{
+			name:          "valid certificate",
+			notAfter:      notAfter(90*24*time.Hour + time.Hour),
+			wantCondition: true,
+			wantStatus:    corev1.ConditionTrue,
+			wantDays:      pointer.Int64(90),
+			wantEnqueued:  time.Hour,
+		},
+		{
+			name:          "valid certificate requeued for the warning window",
+			notAfter:      notAfter(30*24*time.Hour + time.Hour),
+			wantCondition: true,
+			wantStatus:    corev1.ConditionTrue,
+			wantDays:      pointer.Int64(30),
+			wantEnqueued:  30 * time.Minute,
+		},
+		{
+			name:          "certificate about to expire",
+			notAfter:      notAfter(3*24*time.Hour + 2*time.Hour),
+			wantCondition: true,
+			wantStatus:    corev1.ConditionTrue,
+			wantReason:    workloadv1alpha1.SyncerCertExpiringReason,
+			wantDays:      pointer.Int64(3),
+			wantEnqueued:  2 * time.Hour,
+			wantEvent:     true,
+		},
+		{
+			name:          "expired certificate",
+			notAfter:      notAfter(-time.Hour),
+			wantCondition: true,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    workloadv1alpha1.SyncerCertExpiredReason,
+			wantSeverity:  conditionsv1alpha1.ConditionSeverityError,
+			wantDays:      pointer.Int64(0),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var enqueued time.Duration
+			recorder := record.NewFakeRecorder(10)
+			mgr := clusterManager{
+				syncerCertExpiryWarningWindow: 30*24*time.Hour + 30*time.Minute,
+				enqueueClusterAfter: func(_ *workloadv1alpha1.SyncTarget, dur time.Duration) {
+					enqueued = dur
+				},
+				clock:    clocktesting.NewFakePassiveClock(now),
+				recorder: recorder,
+			}
+			cl := &workloadv1alpha1.SyncTarget{
+				Status: workloadv1alpha1.SyncTargetStatus{
+					SyncerCertificateNotAfter: tc.notAfter,
+				},
+			}
+
+			mgr.updateSyncerCertValidity(cl)
+
+			require.Equal(t, tc.wantDays, cl.Status.SyncerCertificateDaysToExpiry)
+			require.Equal(t, tc.wantEnqueued, enqueued)
+			require.Equal(t, tc.wantEvent, len(recorder.Events) > 0, "unexpected events")
+
+			condition := conditions.Get(cl, workloadv1alpha1.SyncerCertValid)
+			if !tc.wantCondition {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, tc.wantStatus, condition.Status)
+			require.Equal(t, tc.wantReason, condition.Reason)
+			require.Equal(t, tc.wantSeverity, condition.Severity)
+
+			// the warning is recorded only once
+			if tc.wantEvent {
+				<-recorder.Events
+				mgr.updateSyncerCertValidity(cl)
+				require.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
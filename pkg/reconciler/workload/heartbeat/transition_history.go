@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// maxTransitionHistory is the maximum number of entries of status.transitionHistory.
+const maxTransitionHistory = 20
+
+// updateTransitionHistory appends an entry to status.transitionHistory for every condition
+// whose status differs from its status in previousConditions, i.e. before the reconcile. The
+// oldest entries are dropped beyond maxTransitionHistory.
+func (c *clusterManager) updateTransitionHistory(cluster *workloadv1alpha1.SyncTarget, previousConditions conditionsapi.Conditions) {
+	previous := map[conditionsapi.ConditionType]corev1.ConditionStatus{}
+	for _, condition := range previousConditions {
+		previous[condition.Type] = condition.Status
+	}
+
+	for _, condition := range cluster.Status.Conditions {
+		from, found := previous[condition.Type]
+		if found && from == condition.Status {
+			continue
+		}
+
+		transition := workloadv1alpha1.ConditionTransition{
+			Type:      condition.Type,
+			From:      from,
+			To:        condition.Status,
+			Timestamp: condition.LastTransitionTime,
+			Reason:    condition.Reason,
+		}
+		if transition.Timestamp.IsZero() {
+			transition.Timestamp = metav1.NewTime(c.clock.Now())
+		}
+		cluster.Status.TransitionHistory = append(cluster.Status.TransitionHistory, transition)
+	}
+
+	if excess := len(cluster.Status.TransitionHistory) - maxTransitionHistory; excess > 0 {
+		cluster.Status.TransitionHistory = append([]workloadv1alpha1.ConditionTransition(nil), cluster.Status.TransitionHistory[excess:]...)
+	}
+}
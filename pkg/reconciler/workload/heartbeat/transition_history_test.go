@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestTransitionHistory(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	mgr := clusterManager{
+		heartbeatThreshold:  time.Minute,
+		enqueueClusterAfter: func(*workloadv1alpha1.SyncTarget, time.Duration) {},
+		clock:               fakeClock,
+	}
+	ctx := context.Background()
+	cl := &workloadv1alpha1.SyncTarget{}
+
+	// reconcile flips Ready and HeartbeatHealthy with a fresh or a stale heartbeat.
+	reconcile := func(healthy bool) {
+		t.Helper()
+		fakeClock.SetTime(fakeClock.Now().Add(2 * time.Minute))
+		if healthy {
+			heartbeat := metav1.NewTime(fakeClock.Now())
+			cl.Status.LastSyncerHeartbeatTime = &heartbeat
+		}
+		if err := mgr.Reconcile(ctx, cl); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+	}
+
+	reconcile(true)
+	reconcile(false)
+	reconcile(false)
+
+	want := []workloadv1alpha1.ConditionTransition{
+		{Type: conditionsv1alpha1.ReadyCondition, To: corev1.ConditionTrue},
+		{Type: workloadv1alpha1.HeartbeatHealthy, To: corev1.ConditionTrue},
+		{Type: conditionsv1alpha1.ReadyCondition, From: corev1.ConditionTrue, To: corev1.ConditionFalse, Reason: string(workloadv1alpha1.HeartbeatHealthy)},
+		{Type: workloadv1alpha1.HeartbeatHealthy, From: corev1.ConditionTrue, To: corev1.ConditionFalse, Reason: workloadv1alpha1.ErrorHeartbeatMissedReason},
+	}
+	if got := cl.Status.TransitionHistory; len(got) != len(want) {
+		t.Fatalf("transition history; got %d entries, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		got := cl.Status.TransitionHistory[i]
+		if got.Type != w.Type || got.From != w.From || got.To != w.To || got.Reason != w.Reason {
+			t.Errorf("transition %d; got %s %q->%q (%s), want %s %q->%q (%s)", i, got.Type, got.From, got.To, got.Reason, w.Type, w.From, w.To, w.Reason)
+		}
+		if got.Timestamp.IsZero() {
+			t.Errorf("transition %d has no timestamp", i)
+		}
+	}
+
+	// flip often enough to exceed the cap.
+	for i := 0; i < maxTransitionHistory; i++ {
+		reconcile(i%2 == 0)
+	}
+
+	history := cl.Status.TransitionHistory
+	if len(history) != maxTransitionHistory {
+		t.Fatalf("transition history; got %d entries, want %d", len(history), maxTransitionHistory)
+	}
+	last := map[conditionsv1alpha1.ConditionType]workloadv1alpha1.ConditionTransition{}
+	for i, transition := range history {
+		if i > 0 && transition.Timestamp.Before(&history[i-1].Timestamp) {
+			t.Errorf("transition %d at %s is before transition %d at %s", i, transition.Timestamp, i-1, history[i-1].Timestamp)
+		}
+		if prev, found := last[transition.Type]; found && prev.To != transition.From {
+			t.Errorf("transition %d of %s is from %q, but the previous one was to %q", i, transition.Type, transition.From, prev.To)
+		}
+		last[transition.Type] = transition
+	}
+	// the last reconcile had a stale heartbeat.
+	if got := last[workloadv1alpha1.HeartbeatHealthy].To; got != corev1.ConditionFalse {
+		t.Errorf("last HeartbeatHealthy transition; got to %q, want %q", got, corev1.ConditionFalse)
+	}
+}
+
+func TestTransitionHistoryOverflow(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	mgr := clusterManager{
+		heartbeatThreshold:  time.Minute,
+		enqueueClusterAfter: func(*workloadv1alpha1.SyncTarget, time.Duration) {},
+		clock:               fakeClock,
+	}
+	ctx := context.Background()
+
+	// a condition of another controller, which is stable while the heartbeat flaps.
+	const stable conditionsv1alpha1.ConditionType = "Stable"
+	cl := &workloadv1alpha1.SyncTarget{
+		Status: workloadv1alpha1.SyncTargetStatus{
+			Conditions: conditionsv1alpha1.Conditions{
+				{Type: stable, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-time.Hour))},
+			},
+		},
+	}
+
+	for i := 0; i < 2*maxTransitionHistory; i++ {
+		fakeClock.SetTime(fakeClock.Now().Add(2 * time.Minute))
+		if i%2 == 0 {
+			heartbeat := metav1.NewTime(fakeClock.Now())
+			cl.Status.LastSyncerHeartbeatTime = &heartbeat
+		}
+		if err := mgr.Reconcile(ctx, cl); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+
+		history := cl.Status.TransitionHistory
+		if len(history) > maxTransitionHistory {
+			t.Fatalf("transition history; got %d entries, want at most %d", len(history), maxTransitionHistory)
+		}
+		for j, transition := range history {
+			if transition.Type == stable {
+				t.Fatalf("reconcile %d: unexpected transition %d of the stable condition: %v", i, j, transition)
+			}
+			if j > 0 && transition.Timestamp.Before(&history[j-1].Timestamp) {
+				t.Errorf("reconcile %d: transition %d at %s is before transition %d at %s", i, j, transition.Timestamp, j-1, history[j-1].Timestamp)
+			}
+		}
+	}
+}
@@ -38,6 +38,7 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"k8s.io/kube-openapi/pkg/util/sets"
@@ -49,6 +50,7 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
 )
 
 const (
@@ -78,6 +80,7 @@ func NewController(
 
 		kubeClusterClient: kubeClusterClient,
 		kcpClusterClient:  kcpClusterClient,
+		recorder:          events.NewRecorder(kubeClusterClient, controllerName),
 
 		namespaceLister:  namespaceInformer.Lister(),
 		namespaceIndexer: namespaceInformer.Informer().GetIndexer(),
@@ -196,6 +199,7 @@ type controller struct {
 
 	kubeClusterClient kubernetesclient.ClusterInterface
 	kcpClusterClient  kcpclient.ClusterInterface
+	recorder          record.EventRecorder
 
 	namespaceLister  corelisters.NamespaceLister
 	namespaceIndexer cache.Indexer
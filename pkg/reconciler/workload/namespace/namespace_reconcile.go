@@ -50,9 +50,11 @@ func (c *controller) reconcile(ctx context.Context, ns *corev1.Namespace) error
 		&placementSchedulingReconciler{
 			listSyncTarget: c.listSyncTarget,
 			listPlacement:  c.listPlacement,
+			listNamespace:  c.listNamespace,
 			getLocation:    c.getLocation,
 			enqueueAfter:   c.enqueueAfter,
 			patchNamespace: c.patchNamespace,
+			recorder:       c.recorder,
 			now:            time.Now,
 		},
 		&statusConditionReconciler{
@@ -101,6 +103,18 @@ func (c *controller) listPlacement(clusterName logicalcluster.Name) ([]*scheduli
 	return ret, nil
 }
 
+func (c *controller) listNamespace(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+	items, err := c.namespaceIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*corev1.Namespace, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*corev1.Namespace))
+	}
+	return ret, nil
+}
+
 func (c *controller) getLocation(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
 	key := clusters.ToClusterAwareKey(clusterName, name)
 	return c.locationLister.Get(key)
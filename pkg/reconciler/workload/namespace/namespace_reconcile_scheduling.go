@@ -19,6 +19,7 @@ package namespace
 import (
 	"context"
 	"encoding/json"
+	"hash/fnv"
 	"math/rand"
 	"strings"
 	"time"
@@ -31,6 +32,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
@@ -38,19 +41,29 @@ import (
 	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
 )
 
-const removingGracePeriod = 5 * time.Second
+const (
+	removingGracePeriod = 5 * time.Second
+
+	// evictedFromSyncTargetReason is the reason of the event on a namespace evicted from a sync target.
+	evictedFromSyncTargetReason = "EvictedFromSyncTarget"
+	// evictedNamespaceReason is the reason of the event on a sync target a namespace is evicted from.
+	evictedNamespaceReason = "EvictedNamespace"
+)
 
 // placementSchedulingReconciler schedules a workload for this ns. It checks the current placement annotation on the ns,
 // and find all valid synctargets.
 type placementSchedulingReconciler struct {
 	listSyncTarget func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
 	listPlacement  func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
+	listNamespace  func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error)
 	getLocation    func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
 
 	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
 
 	enqueueAfter func(*corev1.Namespace, time.Duration)
 
+	recorder record.EventRecorder
+
 	now func() time.Time
 }
 
@@ -127,13 +140,17 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 
 	// 1. pick all sync targets in all bound placements
 	validLocationClusters := map[schedulingv1alpha1.LocationReference]*locationClusters{}
+	evictedClusters := map[string]*workloadv1alpha1.SyncTarget{}
 	var errs []error
 	for _, placement := range validPlacements {
-		clusters, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement, ns)
+		clusters, evicted, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement, ns)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
+		for _, syncTarget := range evicted {
+			evictedClusters[syncTarget.Name] = syncTarget
+		}
 
 		if len(clusters) > 0 {
 			validLocationClusters[*placement.Status.SelectedLocation] = newLocationClusters(clusters)
@@ -168,6 +185,10 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 			now := r.now().UTC().Format(time.RFC3339)
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+cluster] = now
 			klog.V(4).Infof("set cluster %s removing for ns %s|%s since it is not a valid cluster anymore", cluster, clusterName, ns.Name)
+
+			if syncTarget, found := evictedClusters[cluster]; found {
+				r.recordEviction(ns, syncTarget)
+			}
 		}
 	}
 
@@ -215,8 +236,23 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		return reconcileStatusContinue, ns, err
 	}
 
-	// 6. Requeue at last to check if removing cluster should be removed later.
-	if minEnqueueDuration <= removingGracePeriod {
+	// 6. Requeue at last to check if removing cluster should be removed later, or if a
+	// synced cluster is evicted later.
+	requeue := minEnqueueDuration <= removingGracePeriod
+	for _, locationClusters := range validLocationClusters {
+		if !locationClusters.scheduled() {
+			continue
+		}
+		evictionTime, evicting := namespaceEvictionTime(locationClusters.scheduledCluster, ns)
+		if !evicting {
+			continue
+		}
+		if enqueueDuration := evictionTime.Sub(r.now()); !requeue || enqueueDuration < minEnqueueDuration {
+			minEnqueueDuration = enqueueDuration
+			requeue = true
+		}
+	}
+	if requeue {
 		klog.V(2).Infof("enqueue ns %s|%s after %s", clusterName, ns.Name, minEnqueueDuration)
 		r.enqueueAfter(ns, minEnqueueDuration)
 	}
@@ -224,9 +260,11 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	return reconcileStatusContinue, ns, nil
 }
 
-func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement, ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, error) {
+// getAllValidSyncTargetsForPlacement returns the sync targets the namespace can be scheduled to or stay
+// scheduled to for the placement, and the sync targets the namespace is synced to, but evicted from.
+func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement, ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, []*workloadv1alpha1.SyncTarget, error) {
 	if placement.Status.Phase == schedulingv1alpha1.PlacementPending || placement.Status.SelectedLocation == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
@@ -235,27 +273,168 @@ func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clust
 		placement.Status.SelectedLocation.LocationName)
 	switch {
 	case errors.IsNotFound(err):
-		return nil, nil
+		return nil, nil, nil
 	case err != nil:
-		return nil, err
+		return nil, nil, err
 	}
 
 	// find all synctargets in the location workspace
 	syncTargets, err := r.listSyncTarget(locationWorkspace)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// filter the sync targets by location
 	locationClusters, err := locationreconciler.LocationSyncTargets(syncTargets, location)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// find all the valid sync targets.
-	validClusters := locationreconciler.FilterNonEvicting(locationreconciler.FilterReady(locationClusters))
+	validClusters, evictedClusters := r.filterNonEvicting(locationreconciler.FilterReadyIncludingCordoned(locationClusters), ns)
+	validClusters = filterUncordoned(validClusters, ns)
+	validClusters = r.filterCooledDown(validClusters, ns)
+
+	// only keep the sync targets accepting the namespace, with the capabilities required by the
+	// placement, and prefer, or only keep, those of the placement this placement has affinity to.
+	affineSyncTargets, err := r.affineSyncTargets(clusterName, placement)
+	if err != nil {
+		return nil, nil, err
+	}
+	validClusters = locationreconciler.FilterCandidates(placement, validClusters, ns, affineSyncTargets)
+
+	return validClusters, evictedClusters, nil
+}
+
+// affineSyncTargets returns the sync targets the namespaces of the placement referenced by
+// spec.placementAffinity are scheduled to.
+func (r *placementSchedulingReconciler) affineSyncTargets(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement) (sets.String, error) {
+	affinity := placement.Spec.PlacementAffinity
+	if affinity == nil {
+		return nil, nil
+	}
+
+	placements, err := r.listPlacement(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var affine *schedulingv1alpha1.Placement
+	for _, p := range placements {
+		if p.Name == affinity.Placement {
+			affine = p
+			break
+		}
+	}
+
+	affineSyncTargets := sets.NewString()
+	if affine != nil && affine.Status.SelectedLocation != nil && affine.Status.SelectedLocation.Path == placement.Status.SelectedLocation.Path {
+		nss, err := r.listNamespace(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		var affineNamespaces []*corev1.Namespace
+		for _, affineNs := range nss {
+			if _, found := affineNs.Annotations[schedulingv1alpha1.PlacementAnnotationKey]; found && isPlacementValidForNS(affineNs, affine) {
+				affineNamespaces = append(affineNamespaces, affineNs)
+			}
+		}
+		affineSyncTargets = locationreconciler.ScheduledSyncTargets(affineNamespaces)
+	}
+	return affineSyncTargets, nil
+}
+
+// filterNonEvicting returns the sync targets which are not evicting the namespace yet. After
+// spec.evictAfter, a sync target does not get new namespaces, but keeps a namespace synced to
+// it until its eviction time within the eviction grace period. The sync targets the namespace
+// is synced to and whose eviction time has passed are returned as evicted.
+func (r *placementSchedulingReconciler) filterNonEvicting(syncTargets []*workloadv1alpha1.SyncTarget, ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, []*workloadv1alpha1.SyncTarget) {
+	syncedSet := syncedClusterSet(ns)
+	now := r.now()
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	var evicted []*workloadv1alpha1.SyncTarget
+	for _, syncTarget := range syncTargets {
+		evictionTime, evicting := namespaceEvictionTime(syncTarget, ns)
+		if evicting && !now.Before(syncTarget.Spec.EvictAfter.Time) {
+			switch {
+			case !syncedSet[syncTarget.Name]:
+				continue
+			case !now.Before(evictionTime):
+				evicted = append(evicted, syncTarget)
+				continue
+			}
+		}
+		ret = append(ret, syncTarget)
+	}
+	return ret, evicted
+}
+
+// recordEviction records events on the namespace and on the sync target it is evicted from.
+func (r *placementSchedulingReconciler) recordEviction(ns *corev1.Namespace, syncTarget *workloadv1alpha1.SyncTarget) {
+	syncTargetClusterName := logicalcluster.From(syncTarget)
+	r.recorder.Eventf(ns, corev1.EventTypeNormal, evictedFromSyncTargetReason,
+		"Evicted from SyncTarget %s|%s after its spec.evictAfter", syncTargetClusterName, syncTarget.Name)
+	r.recorder.Eventf(syncTarget, corev1.EventTypeNormal, evictedNamespaceReason,
+		"Evicted namespace %s|%s", logicalcluster.From(ns), ns.Name)
+}
+
+// filterUncordoned returns the sync targets which are not cordoned. A cordoned sync target does
+// not get new namespaces, but keeps the namespaces synced to it until they are evicted through
+// spec.evictAfter.
+func filterUncordoned(syncTargets []*workloadv1alpha1.SyncTarget, ns *corev1.Namespace) []*workloadv1alpha1.SyncTarget {
+	syncedSet := syncedClusterSet(ns)
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		if locationreconciler.IsCordoned(syncTarget) && !syncedSet[syncTarget.Name] {
+			continue
+		}
+		ret = append(ret, syncTarget)
+	}
+	return ret
+}
+
+// filterCooledDown returns the sync targets which are not in their reschedule cooldown
+// after returning to Ready. A sync target in cooldown does not get new namespaces, but
+// keeps the namespaces synced to it.
+func (r *placementSchedulingReconciler) filterCooledDown(syncTargets []*workloadv1alpha1.SyncTarget, ns *corev1.Namespace) []*workloadv1alpha1.SyncTarget {
+	syncedSet := syncedClusterSet(ns)
+	now := r.now()
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		if until := syncTarget.Status.RescheduleCooldownUntil; until != nil && now.Before(until.Time) && !syncedSet[syncTarget.Name] {
+			continue
+		}
+		ret = append(ret, syncTarget)
+	}
+	return ret
+}
+
+// syncedClusterSet returns the set of sync targets the namespace is synced to.
+func syncedClusterSet(ns *corev1.Namespace) map[string]bool {
+	synced, _ := syncedRemovingCluster(ns)
+	syncedSet := make(map[string]bool, len(synced))
+	for _, cluster := range synced {
+		syncedSet[cluster] = true
+	}
+	return syncedSet
+}
+
+// namespaceEvictionTime returns the time at which the namespace is evicted from the sync
+// target, and false if the sync target is not evicting. The eviction times of the namespaces
+// are spread over the eviction grace period by hashing the namespace.
+func namespaceEvictionTime(syncTarget *workloadv1alpha1.SyncTarget, ns *corev1.Namespace) (time.Time, bool) {
+	if syncTarget.Spec.EvictAfter == nil {
+		return time.Time{}, false
+	}
+
+	evictionTime := syncTarget.Spec.EvictAfter.Time
+	if syncTarget.Spec.EvictionGracePeriod == nil || syncTarget.Spec.EvictionGracePeriod.Duration <= 0 {
+		return evictionTime, true
+	}
 
-	return validClusters, nil
+	h := fnv.New64a()
+	h.Write([]byte(logicalcluster.From(ns).String() + "|" + ns.Name)) // nolint: errcheck
+	offset := time.Duration(h.Sum64() % uint64(syncTarget.Spec.EvictionGracePeriod.Duration))
+	return evictionTime.Add(offset), true
 }
 
 func (r *placementSchedulingReconciler) patchNamespaceLabelAnnotation(ctx context.Context, clusterName logicalcluster.Name, ns *corev1.Namespace, labels, annotations map[string]interface{}) (*corev1.Namespace, error) {
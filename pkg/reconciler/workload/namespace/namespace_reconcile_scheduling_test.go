@@ -29,9 +29,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
@@ -57,6 +59,7 @@ func TestScheduling(t *testing.T) {
 		wantPatch           bool
 		expectedLabels      map[string]string
 		expectedAnnotations map[string]string
+		expectedEvents      []string
 	}{
 		{
 			name: "placement not found",
@@ -121,6 +124,131 @@ func TestScheduling(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "synctarget namespace selector does not match",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				"tenant": "foo",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withNamespaceSelector(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), map[string]string{"system": "true"}),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				"tenant": "foo",
+			},
+		},
+		{
+			name: "schedule to synctarget whose namespace selector matches",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				"tenant": "foo",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withNamespaceSelector(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), map[string]string{"system": "true"}),
+				withNamespaceSelector(newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue), map[string]string{"tenant": "foo"}),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				"tenant": "foo",
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-2": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "synctarget with namespace selector no longer matching is removed",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withNamespaceSelector(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), map[string]string{"system": "true"}),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                          "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "test-cluster": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "schedule to the synctarget with the required capabilities",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: withRequiredCapabilities(newPlacement("test-placement", "test-location"), "gpu", "storageclass/rwx"),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionTrue),
+				withCapabilities(newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue), "gpu"),
+				withCapabilities(newSyncTarget("test-cluster-3", nil, corev1.ConditionTrue), "storageclass/rwx", "storageclass/standard"),
+				withCapabilities(newSyncTarget("test-cluster-4", nil, corev1.ConditionTrue), "gpu", "storageclass/rwx", "storageclass/standard"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-4": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "no synctarget with the required capabilities",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: withRequiredCapabilities(newPlacement("test-placement", "test-location"), "gpu"),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionTrue),
+				withCapabilities(newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue), "storageclass/rwx"),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "synctarget losing a required capability is removed",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: withRequiredCapabilities(newPlacement("test-placement", "test-location"), "gpu"),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withCapabilities(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), "storageclass/standard"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                          "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "test-cluster": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "no update when synctargets is scheduled",
 			annotations: map[string]string{
@@ -213,6 +341,270 @@ func TestScheduling(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "evicting synctarget keeps synced namespace until its eviction time",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(-time.Second), 1000*time.Hour),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "evicting synctarget removes synced namespace after its eviction time",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withClusterName(withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(-2*time.Hour), time.Hour), "root:org:location"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                          "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "test-cluster": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			expectedEvents: []string{
+				"Normal EvictedFromSyncTarget Evicted from SyncTarget root:org:location|test-cluster after its spec.evictAfter",
+				"Normal EvictedNamespace Evicted namespace root:org:ws|test-ns",
+			},
+		},
+		{
+			name: "evicting synctarget is not scheduled to new namespaces",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(-time.Second), 1000*time.Hour),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "cordoned synctarget is not scheduled to new namespaces",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withUnschedulable(newSyncTarget("test-cluster", nil, corev1.ConditionTrue)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "cordoned synctarget keeps synced namespace",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withUnschedulable(newSyncTarget("test-cluster", nil, corev1.ConditionTrue)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "cordoned synctarget keeps synced namespace until evictAfter",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withUnschedulable(withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(time.Hour), time.Hour)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "cordoned evicting synctarget keeps synced namespace until its eviction time",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withUnschedulable(withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(-time.Second), 1000*time.Hour)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "synctarget in a maintenance window is not scheduled to new namespaces",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				inMaintenanceWindow(newSyncTarget("test-cluster", nil, corev1.ConditionTrue)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "synctarget keeps synced namespace when a maintenance window starts",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				inMaintenanceWindow(newSyncTarget("test-cluster", nil, corev1.ConditionTrue)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "synctarget in import mode is not scheduled to new namespaces",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withMode(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), workloadv1alpha1.SyncTargetModeImport),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "schedule to synctarget in sync mode rather than in import mode",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withMode(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), workloadv1alpha1.SyncTargetModeImport),
+				withMode(newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue), workloadv1alpha1.SyncTargetModeSync),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-2": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "synctarget in reschedule cooldown is not scheduled to new namespaces",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withRescheduleCooldownUntil(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(time.Minute)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+		{
+			name: "synctarget in reschedule cooldown keeps synced namespace",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withRescheduleCooldownUntil(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now.Add(time.Minute)),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "synctarget is scheduled after reschedule cooldown",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withRescheduleCooldownUntil(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), now),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "remove clusters which is removing after grace period",
 			annotations: map[string]string{
@@ -236,6 +628,8 @@ func TestScheduling(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			ns := &corev1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-ns",
+					ClusterName: "root:org:ws",
 					Labels:      testCase.labels,
 					Annotations: testCase.annotations,
 				},
@@ -265,12 +659,14 @@ func TestScheduling(t *testing.T) {
 			}
 
 			var patched bool
+			recorder := record.NewFakeRecorder(10)
 			reconciler := &placementSchedulingReconciler{
 				listPlacement:  listPlacement,
 				getLocation:    getLoaction,
 				listSyncTarget: listSyncTarget,
 				patchNamespace: patchNamespaceFunc(&patched, ns),
 				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
+				recorder:       recorder,
 				now:            func() time.Time { return now },
 			}
 
@@ -279,6 +675,12 @@ func TestScheduling(t *testing.T) {
 			require.Equal(t, testCase.wantPatch, patched)
 			require.Equal(t, testCase.expectedAnnotations, updated.Annotations)
 			require.Equal(t, testCase.expectedLabels, updated.Labels)
+
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			require.Equal(t, testCase.expectedEvents, events)
 		})
 	}
 }
@@ -459,6 +861,7 @@ func TestMultiplePlacements(t *testing.T) {
 				listSyncTarget: listSyncTarget,
 				patchNamespace: patchNamespaceFunc(&patched, ns),
 				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
+				recorder:       record.NewFakeRecorder(10),
 				now:            func() time.Time { return now },
 			}
 
@@ -471,6 +874,162 @@ func TestMultiplePlacements(t *testing.T) {
 	}
 }
 
+func TestPlacementAffinity(t *testing.T) {
+	affinePlacement := newPlacement("affine-placement", "test-location")
+	affinePlacement.Spec.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "db"},
+	}
+	affineNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "affine",
+			Labels: map[string]string{
+				"app": "db",
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster2": string(workloadv1alpha1.ResourceStateSync),
+			},
+			Annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+		},
+	}
+
+	testCases := []struct {
+		name string
+
+		required    bool
+		syncTargets []*workloadv1alpha1.SyncTarget
+		labels      map[string]string
+
+		wantPatch      bool
+		expectedLabels map[string]string
+	}{
+		{
+			name: "schedule to the synctarget of the affine placement",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("cluster1", nil, corev1.ConditionTrue),
+				newSyncTarget("cluster2", nil, corev1.ConditionTrue),
+				newSyncTarget("cluster3", nil, corev1.ConditionTrue),
+			},
+			wantPatch: true,
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster2": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "schedule to another synctarget if the synctarget of the affine placement is not ready",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("cluster1", nil, corev1.ConditionTrue),
+				newSyncTarget("cluster2", nil, corev1.ConditionFalse),
+			},
+			wantPatch: true,
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name:     "do not schedule if the required affinity cannot be satisfied",
+			required: true,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("cluster1", nil, corev1.ConditionTrue),
+				newSyncTarget("cluster2", nil, corev1.ConditionFalse),
+			},
+			wantPatch: false,
+		},
+		{
+			name: "keep the synced synctarget",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("cluster1", nil, corev1.ConditionTrue),
+				newSyncTarget("cluster2", nil, corev1.ConditionTrue),
+			},
+			wantPatch: false,
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: testCase.labels,
+					Annotations: map[string]string{
+						schedulingv1alpha1.PlacementAnnotationKey: "",
+					},
+				},
+			}
+
+			placement := newPlacement("test-placement", "test-location")
+			placement.Spec.PlacementAffinity = &schedulingv1alpha1.PlacementAffinity{
+				Placement: affinePlacement.Name,
+				Required:  testCase.required,
+			}
+
+			var patched bool
+			reconciler := &placementSchedulingReconciler{
+				listPlacement: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+					return []*schedulingv1alpha1.Placement{placement, affinePlacement}, nil
+				},
+				listNamespace: func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+					return []*corev1.Namespace{ns, affineNamespace}, nil
+				},
+				getLocation: func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
+					return newLocation("test-location", map[string]string{}), nil
+				},
+				listSyncTarget: func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
+					return testCase.syncTargets, nil
+				},
+				patchNamespace: patchNamespaceFunc(&patched, ns),
+				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
+				recorder:       record.NewFakeRecorder(10),
+				now:            time.Now,
+			}
+
+			_, updated, err := reconciler.reconcile(context.TODO(), ns)
+			require.NoError(t, err)
+			require.Equal(t, testCase.wantPatch, patched)
+			require.Equal(t, testCase.expectedLabels, updated.Labels)
+		})
+	}
+}
+
+func TestNamespaceEvictionTime(t *testing.T) {
+	evictAfter := time.Now()
+	gracePeriod := time.Hour
+	syncTarget := withEviction(newSyncTarget("test-cluster", nil, corev1.ConditionTrue), evictAfter, gracePeriod)
+
+	evictionTimes := map[time.Time]bool{}
+	for i := 0; i < 100; i++ {
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName: "root:org:ws",
+				Name:        fmt.Sprintf("ns-%d", i),
+			},
+		}
+		evictionTime, evicting := namespaceEvictionTime(syncTarget, ns)
+		require.True(t, evicting)
+		require.False(t, evictionTime.Before(evictAfter), "eviction time %s is before evictAfter", evictionTime)
+		require.True(t, evictionTime.Before(evictAfter.Add(gracePeriod)), "eviction time %s is after the grace period", evictionTime)
+
+		again, _ := namespaceEvictionTime(syncTarget, ns)
+		require.Equal(t, evictionTime, again, "eviction time must be stable")
+		evictionTimes[evictionTime] = true
+	}
+	require.Greater(t, len(evictionTimes), 1, "eviction times must be spread over the grace period")
+
+	syncTarget.Spec.EvictionGracePeriod = nil
+	evictionTime, evicting := namespaceEvictionTime(syncTarget, &corev1.Namespace{})
+	require.True(t, evicting)
+	require.Equal(t, evictAfter, evictionTime)
+
+	syncTarget.Spec.EvictAfter = nil
+	_, evicting = namespaceEvictionTime(syncTarget, &corev1.Namespace{})
+	require.False(t, evicting)
+}
+
 func newSyncTarget(name string, labels map[string]string, status corev1.ConditionStatus) *workloadv1alpha1.SyncTarget {
 	return &workloadv1alpha1.SyncTarget{
 		ObjectMeta: metav1.ObjectMeta{
@@ -488,6 +1047,54 @@ func newSyncTarget(name string, labels map[string]string, status corev1.Conditio
 	}
 }
 
+func withNamespaceSelector(syncTarget *workloadv1alpha1.SyncTarget, selector map[string]string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: selector,
+	}
+	return syncTarget
+}
+
+func withCapabilities(syncTarget *workloadv1alpha1.SyncTarget, capabilities ...string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Capabilities = capabilities
+	return syncTarget
+}
+
+func withRequiredCapabilities(placement *schedulingv1alpha1.Placement, capabilities ...string) *schedulingv1alpha1.Placement {
+	placement.Spec.RequiredCapabilities = capabilities
+	return placement
+}
+
+func withEviction(syncTarget *workloadv1alpha1.SyncTarget, evictAfter time.Time, gracePeriod time.Duration) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.EvictAfter = &metav1.Time{Time: evictAfter}
+	syncTarget.Spec.EvictionGracePeriod = &metav1.Duration{Duration: gracePeriod}
+	return syncTarget
+}
+
+func withClusterName(syncTarget *workloadv1alpha1.SyncTarget, clusterName string) *workloadv1alpha1.SyncTarget {
+	syncTarget.ClusterName = clusterName
+	return syncTarget
+}
+
+func withMode(syncTarget *workloadv1alpha1.SyncTarget, mode workloadv1alpha1.SyncTargetMode) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.Mode = mode
+	return syncTarget
+}
+
+func withUnschedulable(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.Unschedulable = true
+	return syncTarget
+}
+
+func inMaintenanceWindow(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	conditions.MarkFalse(syncTarget, workloadv1alpha1.OutsideMaintenanceWindow, workloadv1alpha1.InMaintenanceWindowReason, conditionsapi.ConditionSeverityInfo, "")
+	return syncTarget
+}
+
+func withRescheduleCooldownUntil(syncTarget *workloadv1alpha1.SyncTarget, until time.Time) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.RescheduleCooldownUntil = &metav1.Time{Time: until}
+	return syncTarget
+}
+
 func newLocation(name string, selector map[string]string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
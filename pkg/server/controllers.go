@@ -568,16 +568,22 @@ func (s *Server) installApiResourceController(ctx context.Context, config *rest.
 
 func (s *Server) installSyncTargetHeartbeatController(ctx context.Context, config *rest.Config) error {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-synctarget-heartbeat-controller")
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
 	if err != nil {
 		return err
 	}
 
 	c, err := heartbeat.NewController(
+		kubeClusterClient,
 		kcpClusterClient,
 		s.kcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
 		s.kcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		s.options.Controllers.SyncTargetHeartbeat.HeartbeatThreshold,
+		s.options.Controllers.SyncTargetHeartbeat.SyncerCertExpiryWarningWindow,
 	)
 	if err != nil {
 		return err
@@ -855,6 +861,7 @@ func (s *Server) installSchedulingPlacementController(ctx context.Context, confi
 		s.kubeSharedInformerFactory.Core().V1().Namespaces(),
 		s.kcpSharedInformerFactory.Scheduling().V1alpha1().Locations(),
 		s.kcpSharedInformerFactory.Scheduling().V1alpha1().Placements(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
 	)
 	if err != nil {
 		return err
@@ -890,6 +897,7 @@ func (s *Server) installWorkloadsAPIExportController(ctx context.Context, config
 		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
 		s.kcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
 		s.kcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
 	)
 	if err != nil {
 		return err
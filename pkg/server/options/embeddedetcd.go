@@ -19,9 +19,12 @@ package options
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/pflag"
 	etcdtypes "go.etcd.io/etcd/client/pkg/v3/types"
+
+	"github.com/kcp-dev/kcp/pkg/etcd"
 )
 
 type EmbeddedEtcd struct {
@@ -31,16 +34,27 @@ type EmbeddedEtcd struct {
 	PeerPort          string
 	ClientPort        string
 	ListenMetricsURLs []string
+	ServerMetrics     bool
 	WalSizeBytes      int64
 	QuotaBackendBytes int64
 	ForceNewCluster   bool
+	StartTimeout      time.Duration
+	InMemory          bool
+	SizeLogInterval   time.Duration
+
+	HeartbeatIntervalMs uint
+	ElectionTimeoutMs   uint
 }
 
 func NewEmbeddedEtcd(rootDir string) *EmbeddedEtcd {
 	return &EmbeddedEtcd{
-		Directory:  filepath.Join(rootDir, "etcd-server"),
-		PeerPort:   "2380",
-		ClientPort: "2379",
+		Directory:    filepath.Join(rootDir, "etcd-server"),
+		PeerPort:     "2380",
+		ClientPort:   "2379",
+		StartTimeout: etcd.DefaultStartTimeout,
+
+		HeartbeatIntervalMs: etcd.DefaultHeartbeatIntervalMs,
+		ElectionTimeoutMs:   etcd.DefaultElectionTimeoutMs,
 	}
 }
 
@@ -49,9 +63,15 @@ func (e *EmbeddedEtcd) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&e.PeerPort, "embedded-etcd-peer-port", e.PeerPort, "Port for embedded etcd peer")
 	fs.StringVar(&e.ClientPort, "embedded-etcd-client-port", e.ClientPort, "Port for embedded etcd client")
 	fs.StringSliceVar(&e.ListenMetricsURLs, "embedded-etcd-listen-metrics-urls", e.ListenMetricsURLs, "The list of protocol://host:port where embedded etcd server listens for Prometheus scrapes")
+	fs.BoolVar(&e.ServerMetrics, "embedded-etcd-server-metrics", e.ServerMetrics, "Expose the embedded etcd metrics on the /metrics endpoint of the server instead of a separate listener. Mutually exclusive with --embedded-etcd-listen-metrics-urls")
 	fs.Int64Var(&e.WalSizeBytes, "embedded-etcd-wal-size-bytes", e.WalSizeBytes, "Size of embedded etcd WAL")
 	fs.Int64Var(&e.QuotaBackendBytes, "embedded-etcd-quota-backend-bytes", e.WalSizeBytes, "Alarm threshold for embedded etcd backend bytes")
 	fs.BoolVar(&e.ForceNewCluster, "embedded-etcd-force-new-cluster", e.ForceNewCluster, "Starts a new cluster from existing data restored from a different system")
+	fs.BoolVar(&e.InMemory, "embedded-etcd-in-memory", e.InMemory, "Store the embedded etcd data in a temporary directory on tmpfs, if available, that is removed on shutdown, instead of --embedded-etcd-directory. The data does not survive restarts, i.e. this is only meant for tests")
+	fs.DurationVar(&e.StartTimeout, "embedded-etcd-start-timeout", e.StartTimeout, "Duration to wait for embedded etcd to become ready before failing the server start")
+	fs.DurationVar(&e.SizeLogInterval, "embedded-etcd-size-log-interval", e.SizeLogInterval, "Interval at which the backend size and the number of keys of embedded etcd are logged. 0 disables logging")
+	fs.UintVar(&e.HeartbeatIntervalMs, "embedded-etcd-heartbeat-interval", e.HeartbeatIntervalMs, "Time in milliseconds of an embedded etcd heartbeat interval")
+	fs.UintVar(&e.ElectionTimeoutMs, "embedded-etcd-election-timeout", e.ElectionTimeoutMs, "Time in milliseconds for an embedded etcd election to time out. Raise it on slow disks to avoid spurious leader elections. It must be at least 5 times --embedded-etcd-heartbeat-interval")
 }
 
 func (e *EmbeddedEtcd) Validate() []error {
@@ -64,11 +84,34 @@ func (e *EmbeddedEtcd) Validate() []error {
 		if e.ClientPort == "" {
 			errs = append(errs, fmt.Errorf("--embedded-etcd-client-port must be specified"))
 		}
+		if e.StartTimeout <= 0 {
+			errs = append(errs, fmt.Errorf("--embedded-etcd-start-timeout must be positive"))
+		}
+		if e.SizeLogInterval < 0 {
+			errs = append(errs, fmt.Errorf("--embedded-etcd-size-log-interval must not be negative"))
+		}
+		if e.HeartbeatIntervalMs == 0 {
+			errs = append(errs, fmt.Errorf("--embedded-etcd-heartbeat-interval must be positive"))
+		}
+		if e.ElectionTimeoutMs < 5*e.HeartbeatIntervalMs {
+			errs = append(errs, fmt.Errorf("--embedded-etcd-election-timeout (%d) must be at least 5 times --embedded-etcd-heartbeat-interval (%d)", e.ElectionTimeoutMs, e.HeartbeatIntervalMs))
+		}
 		if len(e.ListenMetricsURLs) > 0 {
 			_, err := etcdtypes.NewURLs(e.ListenMetricsURLs)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("--embedded-etcd-listen-metrics-urls parse failure: %w", err))
 			}
+			if e.ServerMetrics {
+				errs = append(errs, fmt.Errorf("only one of --embedded-etcd-listen-metrics-urls and --embedded-etcd-server-metrics can be specified"))
+			}
+		}
+		if e.InMemory && e.ForceNewCluster {
+			errs = append(errs, fmt.Errorf("--embedded-etcd-force-new-cluster cannot be used with --embedded-etcd-in-memory, which always starts with empty data"))
+		}
+		if !e.InMemory {
+			if err := etcd.CheckDataDirUnused(e.Directory); err != nil {
+				errs = append(errs, fmt.Errorf("--embedded-etcd-directory: %w", err))
+			}
 		}
 	}
 
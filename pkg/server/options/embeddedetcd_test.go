@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedEtcdValidateRaftTimeouts(t *testing.T) {
+	tests := []struct {
+		name                string
+		heartbeatIntervalMs uint
+		electionTimeoutMs   uint
+		wantErrs            int
+	}{
+		{name: "defaults", heartbeatIntervalMs: 100, electionTimeoutMs: 1000},
+		{name: "exactly 5 times", heartbeatIntervalMs: 200, electionTimeoutMs: 1000},
+		{name: "less than 5 times", heartbeatIntervalMs: 300, electionTimeoutMs: 1000, wantErrs: 1},
+		{name: "zero election timeout", heartbeatIntervalMs: 100, electionTimeoutMs: 0, wantErrs: 1},
+		{name: "zero heartbeat interval", heartbeatIntervalMs: 0, electionTimeoutMs: 1000, wantErrs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEmbeddedEtcd(t.TempDir())
+			e.Enabled = true
+			e.InMemory = true
+			e.HeartbeatIntervalMs = tt.heartbeatIntervalMs
+			e.ElectionTimeoutMs = tt.electionTimeoutMs
+
+			require.Len(t, e.Validate(), tt.wantErrs)
+		})
+	}
+}
@@ -153,17 +153,24 @@ var (
 		"embedded-etcd-directory",           // Directory for embedded etcd
 		"embedded-etcd-peer-port",           // Port for embedded etcd peer
 		"embedded-etcd-listen-metrics-urls", // The list of protocol://host:port where embedded etcd server listens for Prometheus scrapes
+		"embedded-etcd-server-metrics",      // Expose the embedded etcd metrics on the /metrics endpoint of the server instead of a separate listener. Mutually exclusive with --embedded-etcd-listen-metrics-urls
 		"embedded-etcd-wal-size-bytes",      // Size of embedded etcd WAL
 		"embedded-etcd-quota-backend-bytes", // Alarm threshold for embedded etcd backend bytes
 		"embedded-etcd-force-new-cluster",   // Starts a new cluster from existing data restored from a different system
+		"embedded-etcd-start-timeout",       // Duration to wait for embedded etcd to become ready before failing the server start
+		"embedded-etcd-heartbeat-interval",  // Time in milliseconds of an embedded etcd heartbeat interval
+		"embedded-etcd-election-timeout",    // Time in milliseconds for an embedded etcd election to time out. Raise it on slow disks to avoid spurious leader elections. It must be at least 5 times --embedded-etcd-heartbeat-interval
+		"embedded-etcd-in-memory",           // Store the embedded etcd data in a temporary directory on tmpfs, if available, that is removed on shutdown, instead of --embedded-etcd-directory. The data does not survive restarts, i.e. this is only meant for tests
+		"embedded-etcd-size-log-interval",   // Interval at which the backend size and the number of keys of embedded etcd are logged. 0 disables logging
 
 		// KCP Controllers flags
-		"auto-publish-apis",                      // If true, the APIs imported from physical clusters will be published automatically as CRDs
-		"apiresource-controller-threads",         // Number of threads to use for the apiresource controller.
-		"run-controllers",                        // Run the controllers in-process
-		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
-		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
-		"sync-target-heartbeat-threshold",        // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"auto-publish-apis",                             // If true, the APIs imported from physical clusters will be published automatically as CRDs
+		"apiresource-controller-threads",                // Number of threads to use for the apiresource controller.
+		"run-controllers",                               // Run the controllers in-process
+		"run-virtual-workspaces",                        // Run the virtual workspaces apiservers in-process
+		"unsupported-run-individual-controllers",        // Run individual controllers in-process. The controller names can change at any time.
+		"sync-target-heartbeat-threshold",               // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"sync-target-syncer-cert-expiry-warning-window", // Amount of time before the expiry of the client certificate of a syncer in which the SyncerCertValid condition of the SyncTarget warns about the expiry
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
@@ -25,8 +25,10 @@ import (
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
+	"github.com/prometheus/client_golang/prometheus"
 	etcdtypes "go.etcd.io/etcd/client/pkg/v3/types"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apiextensionsexternalversions "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -44,6 +46,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 
@@ -130,9 +133,19 @@ func (s *Server) Run(ctx context.Context) error {
 		go http.ListenAndServe(s.options.Extra.ProfilerAddress, nil)
 	}
 	if s.options.EmbeddedEtcd.Enabled {
-		es := &etcd.Server{
-			Dir: s.options.EmbeddedEtcd.Directory,
+		var etcdOpts []etcd.ServerOption
+		if s.options.EmbeddedEtcd.ServerMetrics {
+			etcdOpts = append(etcdOpts, etcd.WithMetricsRegistry(legacyRegistryRegisterer{}))
 		}
+		etcdOpts = append(etcdOpts, etcd.WithStartTimeout(s.options.EmbeddedEtcd.StartTimeout))
+		etcdOpts = append(etcdOpts, etcd.WithRaftTimeouts(s.options.EmbeddedEtcd.HeartbeatIntervalMs, s.options.EmbeddedEtcd.ElectionTimeoutMs))
+		if s.options.EmbeddedEtcd.InMemory {
+			etcdOpts = append(etcdOpts, etcd.WithInMemory())
+		}
+		if s.options.EmbeddedEtcd.SizeLogInterval > 0 {
+			etcdOpts = append(etcdOpts, etcd.WithSizeLogInterval(s.options.EmbeddedEtcd.SizeLogInterval))
+		}
+		es := etcd.NewServer(s.options.EmbeddedEtcd.Directory, etcdOpts...)
 		var listenMetricsURLs []url.URL
 		if len(s.options.EmbeddedEtcd.ListenMetricsURLs) > 0 {
 			var err error
@@ -354,6 +367,11 @@ func (s *Server) Run(ctx context.Context) error {
 		kubeClusterClient.DiscoveryClient,
 		metadataClusterClient.Cluster(logicalcluster.Wildcard),
 		func(obj interface{}) bool { return true }, s.options.Extra.DiscoveryPollInterval,
+		// these are needed by our kubeQuota controller
+		informer.WithBaseGVRs(
+			apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions"),
+			apisv1alpha1.SchemeGroupVersion.WithResource("apibindings"),
+		),
 	)
 	if err := s.dynamicDiscoverySharedInformerFactory.AddIndexers(indexers.NamespaceScoped()); err != nil {
 		return err
@@ -647,3 +665,27 @@ func (mxs handlerChainMuxes) Handle(pattern string, handler http.Handler) {
 		mx.Handle(pattern, handler)
 	}
 }
+
+// legacyRegistryRegisterer adapts the legacy registry, whose metrics are served on
+// /metrics, to a prometheus.Registerer.
+type legacyRegistryRegisterer struct{}
+
+var _ prometheus.Registerer = legacyRegistryRegisterer{}
+
+func (legacyRegistryRegisterer) Register(c prometheus.Collector) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	legacyregistry.RawMustRegister(c)
+	return nil
+}
+
+func (legacyRegistryRegisterer) MustRegister(cs ...prometheus.Collector) {
+	legacyregistry.RawMustRegister(cs...)
+}
+
+func (legacyRegistryRegisterer) Unregister(prometheus.Collector) bool {
+	return false
+}
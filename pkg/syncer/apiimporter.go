@@ -18,14 +18,20 @@ package syncer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -35,6 +41,8 @@ import (
 	"k8s.io/klog/v2"
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
@@ -168,12 +176,32 @@ func (i *APIImporter) Stop() {
 
 func (i *APIImporter) ImportAPIs(ctx context.Context) {
 	klog.Infof("Importing APIs from location %s in logical cluster %s (resources=%v)", i.location, i.logicalClusterName, i.resourcesToSync)
-	crds, err := i.schemaPuller.PullCRDs(ctx, i.resourcesToSync...)
+
+	var preferredVersions map[schema.GroupResource]string
+	syncTarget, err := i.getSyncTarget()
+	if err != nil {
+		klog.Errorf("error getting SyncTarget for location %s in logical cluster %s: %v", i.location, i.logicalClusterName, err)
+	} else {
+		preferredVersions = preferredAPIVersions(syncTarget)
+	}
+
+	crds, err := i.schemaPuller.PullCRDsWithPreferredVersions(ctx, preferredVersions, i.resourcesToSync...)
 	if err != nil {
 		klog.Errorf("error pulling CRDs: %v", err)
 		return
 	}
 
+	var skipped []string
+	if syncTarget != nil {
+		crds, skipped = truncateImports(crds, syncTarget.Spec.MaxImportedResources, i.SyncedGVRs)
+		if len(skipped) > 0 {
+			klog.Warningf("Not importing %d resources from location %s in logical cluster %s due to maxImportedResources: %s", len(skipped), i.location, i.logicalClusterName, strings.Join(skipped, ", "))
+		}
+	}
+
+	// in Sync mode, the resources are synced, but the APIs of the cluster are not exported to kcp.
+	exportAPIs := syncTarget == nil || syncTarget.Spec.Mode != workloadv1alpha1.SyncTargetModeSync
+
 	gvrsToSync := map[string]metav1.GroupVersionResource{}
 	for groupResource, pulledCrd := range crds {
 		crdVersion := pulledCrd.Spec.Versions[0]
@@ -182,6 +210,9 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 			Version:  crdVersion.Name,
 			Resource: groupResource.Resource,
 		}
+		// pulled resources are kept even if writing their APIResourceImport fails below, such
+		// that a transient error does not delete a valid import. The write is retried next time.
+		gvrsToSync[gvr.String()] = gvr
 
 		objs, err := i.apiresourceImportIndexer.ByIndex(
 			clusterctl.GVRForLocationInLogicalClusterIndexName,
@@ -195,6 +226,17 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 			klog.Errorf("There should be only one APIResourceImport of GVR %s for location %s in logical cluster %s, but there was %d", gvr.String(), i.location, i.logicalClusterName, len(objs))
 			continue
 		}
+		if !exportAPIs {
+			if len(objs) == 1 {
+				apiResourceImportToRemove := objs[0].(*apiresourcev1alpha1.APIResourceImport)
+				klog.Infof("Deleting APIResourceImport %s|%s of SyncTarget %s in Sync mode", i.logicalClusterName, apiResourceImportToRemove.Name, i.location)
+				if err := i.kcpClusterClient.Cluster(i.logicalClusterName).ApiresourceV1alpha1().APIResourceImports().Delete(ctx, apiResourceImportToRemove.Name, metav1.DeleteOptions{}); err != nil {
+					klog.Errorf("error deleting APIResourceImport %s: %v", apiResourceImportToRemove.Name, err)
+					continue
+				}
+			}
+			continue
+		}
 		if len(objs) == 1 {
 			apiResourceImport := objs[0].(*apiresourcev1alpha1.APIResourceImport).DeepCopy()
 			if err := apiResourceImport.Spec.SetSchema(crdVersion.Schema.OpenAPIV3Schema); err != nil {
@@ -214,30 +256,11 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 				apiResourceImportName = apiResourceImportName + gvr.Group
 			}
 
-			clusterKey, err := cache.MetaNamespaceKeyFunc(&metav1.PartialObjectMetadata{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        i.location,
-					ClusterName: i.logicalClusterName.String(),
-				},
-			})
+			cluster, err := i.getSyncTarget()
 			if err != nil {
 				klog.Errorf("error creating APIResourceImport %s: %v", apiResourceImportName, err)
 				continue
 			}
-			clusterObj, exists, err := i.clusterIndexer.GetByKey(clusterKey)
-			if err != nil {
-				klog.Errorf("error creating APIResourceImport %s: %v", apiResourceImportName, err)
-				continue
-			}
-			if !exists {
-				klog.Errorf("error creating APIResourceImport %s: the cluster object should exist in the index for location %s in logical cluster %s", apiResourceImportName, i.location, i.logicalClusterName)
-				continue
-			}
-			cluster, isCluster := clusterObj.(*workloadv1alpha1.SyncTarget)
-			if !isCluster {
-				klog.Errorf("error creating APIResourceImport %s: the object retrieved from the cluster index for location %s in logical cluster %s should be a cluster object, but is of type: %T", apiResourceImportName, i.location, i.logicalClusterName, clusterObj)
-				continue
-			}
 			groupVersion := apiresourcev1alpha1.GroupVersion{
 				Group:   gvr.Group,
 				Version: gvr.Version,
@@ -282,7 +305,6 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 				continue
 			}
 		}
-		gvrsToSync[gvr.String()] = gvr
 	}
 
 	gvrsToRemove := sets.StringKeySet(i.SyncedGVRs).Difference(sets.StringKeySet(gvrsToSync))
@@ -310,4 +332,167 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 			}
 		}
 	}
+	i.SyncedGVRs = gvrsToSync
+
+	if syncTarget != nil {
+		if err := i.updateImportedResources(ctx, syncTarget, gvrsToSync, skipped); err != nil {
+			klog.Errorf("error updating imported resources of SyncTarget %s|%s: %v", i.logicalClusterName, i.location, err)
+		}
+	}
+}
+
+// getSyncTarget returns the SyncTarget of the importer from the informer cache.
+func (i *APIImporter) getSyncTarget() (*workloadv1alpha1.SyncTarget, error) {
+	clusterKey, err := cache.MetaNamespaceKeyFunc(&metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        i.location,
+			ClusterName: i.logicalClusterName.String(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	clusterObj, exists, err := i.clusterIndexer.GetByKey(clusterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("the cluster object should exist in the index for location %s in logical cluster %s", i.location, i.logicalClusterName)
+	}
+	cluster, isCluster := clusterObj.(*workloadv1alpha1.SyncTarget)
+	if !isCluster {
+		return nil, fmt.Errorf("the object retrieved from the cluster index for location %s in logical cluster %s should be a cluster object, but is of type: %T", i.location, i.logicalClusterName, clusterObj)
+	}
+	return cluster, nil
+}
+
+// updateImportedResources records the synced resources and their imported versions, and the
+// resources skipped due to spec.maxImportedResources, in the SyncTarget status.
+func (i *APIImporter) updateImportedResources(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, gvrs map[string]metav1.GroupVersionResource, skipped []string) error {
+	importedVersions := map[string]string{}
+	for _, gvr := range gvrs {
+		importedVersions[schema.GroupResource{Group: gvr.Group, Resource: gvr.Resource}.String()] = gvr.Version
+	}
+	syncedResources := sets.StringKeySet(importedVersions).List()
+
+	versionsChanged := !(len(importedVersions) == 0 && len(syncTarget.Status.ImportedVersions) == 0) &&
+		!equality.Semantic.DeepEqual(importedVersions, syncTarget.Status.ImportedVersions)
+	resourcesChanged := !sets.NewString(syncedResources...).Equal(sets.NewString(syncTarget.Status.SyncedResources...))
+	updated := syncTarget.DeepCopy()
+	setAPIImportCompleteCondition(updated, skipped)
+	conditionsChanged := !equality.Semantic.DeepEqual(syncTarget.Status.Conditions, updated.Status.Conditions)
+	if !versionsChanged && !resourcesChanged && !conditionsChanged {
+		return nil
+	}
+
+	// a merge patch removes keys set to null
+	patchVersions := map[string]interface{}{}
+	for resource := range syncTarget.Status.ImportedVersions {
+		patchVersions[resource] = nil
+	}
+	for resource, version := range importedVersions {
+		patchVersions[resource] = version
+	}
+	status := map[string]interface{}{
+		"importedVersions": patchVersions,
+		"syncedResources":  syncedResources,
+	}
+	patchObj := map[string]interface{}{
+		"status": status,
+	}
+	if conditionsChanged {
+		// a merge patch replaces the whole list, hence guard against concurrent condition updates
+		status["conditions"] = updated.Status.Conditions
+		patchObj["metadata"] = map[string]interface{}{
+			"resourceVersion": syncTarget.ResourceVersion,
+		}
+	}
+	patch, err := json.Marshal(patchObj)
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Updating imported resources of SyncTarget %s|%s: %s", i.logicalClusterName, syncTarget.Name, string(patch))
+	_, err = i.kcpClusterClient.Cluster(i.logicalClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTarget.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// truncateImports returns at most max of the given CRDs, and the sorted names of the skipped
+// resources. The CRDs of resources imported already are kept first, in order to not flap
+// between resources, then the others in alphabetical order. If max is nil, all CRDs are returned.
+func truncateImports(crds map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, max *int32, imported map[string]metav1.GroupVersionResource) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, []string) {
+	if max == nil || len(crds) <= int(*max) {
+		return crds, nil
+	}
+
+	wasImported := map[schema.GroupResource]bool{}
+	for _, gvr := range imported {
+		wasImported[schema.GroupResource{Group: gvr.Group, Resource: gvr.Resource}] = true
+	}
+
+	groupResources := make([]schema.GroupResource, 0, len(crds))
+	for gr := range crds {
+		groupResources = append(groupResources, gr)
+	}
+	sort.Slice(groupResources, func(a, b int) bool {
+		if wasImported[groupResources[a]] != wasImported[groupResources[b]] {
+			return wasImported[groupResources[a]]
+		}
+		return groupResources[a].String() < groupResources[b].String()
+	})
+
+	truncated := make(map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, *max)
+	var skipped []string
+	for n, gr := range groupResources {
+		if n < int(*max) {
+			truncated[gr] = crds[gr]
+			continue
+		}
+		skipped = append(skipped, gr.String())
+	}
+	sort.Strings(skipped)
+
+	return truncated, skipped
+}
+
+// setAPIImportCompleteCondition marks APIImportComplete false if resources have been skipped
+// due to spec.maxImportedResources. Without the cap, the condition is removed.
+func setAPIImportCompleteCondition(syncTarget *workloadv1alpha1.SyncTarget, skipped []string) {
+	if syncTarget.Spec.MaxImportedResources == nil {
+		conditions.Delete(syncTarget, workloadv1alpha1.APIImportComplete)
+		return
+	}
+	if len(skipped) == 0 {
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.APIImportComplete)
+		return
+	}
+	conditions.MarkFalse(syncTarget,
+		workloadv1alpha1.APIImportComplete,
+		workloadv1alpha1.ImportTruncatedReason,
+		conditionsapi.ConditionSeverityWarning,
+		"Resources not imported due to maxImportedResources %d: %s", *syncTarget.Spec.MaxImportedResources, strings.Join(skipped, ", "))
+}
+
+// preferredAPIVersions parses the workload.kcp.dev/preferred-api-versions annotation of
+// the SyncTarget. Invalid entries are skipped.
+func preferredAPIVersions(syncTarget *workloadv1alpha1.SyncTarget) map[schema.GroupResource]string {
+	value := syncTarget.Annotations[workloadv1alpha1.PreferredAPIVersionsAnnotationKey]
+	if value == "" {
+		return nil
+	}
+
+	preferredVersions := map[schema.GroupResource]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			klog.Errorf("invalid entry %q in annotation %s of SyncTarget %s|%s, expected <resource>.<group>=<version>", entry, workloadv1alpha1.PreferredAPIVersionsAnnotationKey, logicalcluster.From(syncTarget), syncTarget.Name)
+			continue
+		}
+		preferredVersions[schema.ParseGroupResource(parts[0])] = parts[1]
+	}
+	return preferredVersions
 }
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/crdpuller"
+	clusterctl "github.com/kcp-dev/kcp/pkg/reconciler/workload/basecontroller"
+)
+
+func TestTruncateImports(t *testing.T) {
+	crds := map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition{}
+	for _, gr := range []schema.GroupResource{
+		{Resource: "services"},
+		{Resource: "configmaps"},
+		{Group: "apps", Resource: "deployments"},
+		{Group: "example.io", Resource: "widgets"},
+	} {
+		crds[gr] = &apiextensionsv1.CustomResourceDefinition{}
+	}
+	imported := map[string]metav1.GroupVersionResource{
+		"example.io/v1, Resource=widgets": {Group: "example.io", Version: "v1", Resource: "widgets"},
+	}
+
+	got, skipped := truncateImports(crds, nil, imported)
+	require.Len(t, got, 4, "expected no cap without maxImportedResources")
+	require.Empty(t, skipped)
+
+	got, skipped = truncateImports(crds, pointer.Int32(4), imported)
+	require.Len(t, got, 4)
+	require.Empty(t, skipped)
+
+	got, skipped = truncateImports(crds, pointer.Int32(2), imported)
+	require.Len(t, got, 2)
+	require.Contains(t, got, schema.GroupResource{Group: "example.io", Resource: "widgets"}, "expected imported resources to be kept")
+	require.Contains(t, got, schema.GroupResource{Resource: "configmaps"})
+	require.Equal(t, []string{"deployments.apps", "services"}, skipped)
+
+	got, skipped = truncateImports(crds, pointer.Int32(0), imported)
+	require.Empty(t, got)
+	require.Len(t, skipped, 4)
+}
+
+func TestSetAPIImportCompleteCondition(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{}
+
+	setAPIImportCompleteCondition(syncTarget, nil)
+	require.False(t, conditions.Has(syncTarget, workloadv1alpha1.APIImportComplete), "expected no condition without maxImportedResources")
+
+	syncTarget.Spec.MaxImportedResources = pointer.Int32(2)
+	setAPIImportCompleteCondition(syncTarget, []string{"deployments.apps", "services"})
+	require.True(t, conditions.IsFalse(syncTarget, workloadv1alpha1.APIImportComplete))
+	require.Equal(t, workloadv1alpha1.ImportTruncatedReason, conditions.GetReason(syncTarget, workloadv1alpha1.APIImportComplete))
+	require.Equal(t, "Resources not imported due to maxImportedResources 2: deployments.apps, services", conditions.GetMessage(syncTarget, workloadv1alpha1.APIImportComplete))
+
+	setAPIImportCompleteCondition(syncTarget, nil)
+	require.True(t, conditions.IsTrue(syncTarget, workloadv1alpha1.APIImportComplete))
+
+	syncTarget.Spec.MaxImportedResources = nil
+	setAPIImportCompleteCondition(syncTarget, nil)
+	require.False(t, conditions.Has(syncTarget, workloadv1alpha1.APIImportComplete))
+}
+
+type fakeSchemaPuller struct {
+	crdpuller.SchemaPuller
+	crds map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition
+}
+
+func (p fakeSchemaPuller) PullCRDsWithPreferredVersions(_ context.Context, _ map[schema.GroupResource]string, _ ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
+	return p.crds, nil
+}
+
+func TestImportAPIsKeepsImportsOnFailedUpdate(t *testing.T) {
+	clusterName := logicalcluster.New("root:org")
+	widgets := metav1.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}
+
+	var lock sync.Mutex
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		methods = append(methods, req.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Conflict","code":409}`)) // nolint: errcheck
+	}))
+	defer server.Close()
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	importIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		clusterctl.GVRForLocationInLogicalClusterIndexName: func(obj interface{}) ([]string, error) {
+			apiResourceImport := obj.(*apiresourcev1alpha1.APIResourceImport)
+			return []string{clusterctl.GetGVRForLocationInLogicalClusterIndexKey(apiResourceImport.Spec.Location, logicalcluster.From(apiResourceImport), apiResourceImport.GVR())}, nil
+		},
+	})
+	existing := &apiresourcev1alpha1.APIResourceImport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.test.v1.example.io", ClusterName: clusterName.String()},
+		Spec: apiresourcev1alpha1.APIResourceImportSpec{
+			Location: "test",
+			CommonAPIResourceSpec: apiresourcev1alpha1.CommonAPIResourceSpec{
+				GroupVersion:                  apiresourcev1alpha1.GroupVersion{Group: "example.io", Version: "v1"},
+				CustomResourceDefinitionNames: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets"},
+			},
+		},
+	}
+	require.NoError(t, importIndexer.Add(existing))
+
+	importer := &APIImporter{
+		kcpClusterClient:         kcpClusterClient,
+		apiresourceImportIndexer: importIndexer,
+		clusterIndexer:           cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		location:                 "test",
+		logicalClusterName:       clusterName,
+		schemaPuller: fakeSchemaPuller{crds: map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition{
+			{Group: "example.io", Resource: "widgets"}: {
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "example.io",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets"},
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{Name: "v1", Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"}}},
+					},
+				},
+			},
+		}},
+		SyncedGVRs: map[string]metav1.GroupVersionResource{widgets.String(): widgets},
+	}
+
+	importer.ImportAPIs(context.Background())
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []string{http.MethodPut}, methods, "expected only the failed update, and no deletion")
+	require.Equal(t, map[string]metav1.GroupVersionResource{widgets.String(): widgets}, importer.SyncedGVRs)
+}
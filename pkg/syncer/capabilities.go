@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// gpuResourceNames are the extended resources of nodes which are GPUs.
+var gpuResourceNames = []corev1.ResourceName{"nvidia.com/gpu", "amd.com/gpu"}
+
+// reportCapabilities records the capabilities of the downstream cluster in status.capabilities of
+// the SyncTarget.
+func reportCapabilities(ctx context.Context, kcpClient kcpclient.Interface, downstreamClient kubernetes.Interface, syncTargetName string) error {
+	storageClasses, err := downstreamClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	nodes, err := downstreamClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var storageClassNames []string
+	for _, storageClass := range storageClasses.Items {
+		storageClassNames = append(storageClassNames, storageClass.Name)
+	}
+	capabilities := clusterCapabilities(storageClassNames, nodes.Items)
+
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, syncTargetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(syncTarget.Status.Capabilities, capabilities) {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"capabilities": capabilities,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Updating capabilities of SyncTarget %s: %s", syncTargetName, string(patch))
+	_, err = kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// clusterCapabilities returns the sorted capabilities of a cluster with the given storage classes
+// and nodes, or nil if it has none.
+func clusterCapabilities(storageClassNames []string, nodes []corev1.Node) []string {
+	var capabilities []string
+	for _, name := range storageClassNames {
+		capabilities = append(capabilities, workloadv1alpha1.StorageClassCapabilityPrefix+name)
+	}
+	if hasGPUs(nodes) {
+		capabilities = append(capabilities, workloadv1alpha1.GPUCapability)
+	}
+	sort.Strings(capabilities)
+	return capabilities
+}
+
+// hasGPUs returns whether any of the nodes has allocatable GPUs.
+func hasGPUs(nodes []corev1.Node) bool {
+	for _, node := range nodes {
+		for _, name := range gpuResourceNames {
+			if quantity, found := node.Status.Allocatable[name]; found && !quantity.IsZero() {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func newNode(name string, allocatable corev1.ResourceList) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Allocatable: allocatable},
+	}
+}
+
+func TestClusterCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		storageClasses []string
+		nodes          []corev1.Node
+		want           []string
+	}{
+		{
+			name:  "none",
+			nodes: []corev1.Node{*newNode("a", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")})},
+		},
+		{
+			name:           "storage classes",
+			storageClasses: []string{"standard", "rwx"},
+			want:           []string{"storageclass/rwx", "storageclass/standard"},
+		},
+		{
+			name: "gpus",
+			nodes: []corev1.Node{
+				*newNode("a", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}),
+				*newNode("b", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")}),
+			},
+			want: []string{"gpu"},
+		},
+		{
+			name:  "no allocatable gpus",
+			nodes: []corev1.Node{*newNode("a", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")})},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, clusterCapabilities(tc.storageClasses, tc.nodes))
+		})
+	}
+}
+
+func TestReportCapabilities(t *testing.T) {
+	kcpClient := kcpfakeclient.NewSimpleClientset(&workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+	})
+	downstreamClient := kubefakeclient.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}},
+		newNode("a", corev1.ResourceList{"amd.com/gpu": resource.MustParse("1")}),
+	)
+
+	err := reportCapabilities(context.Background(), kcpClient, downstreamClient, "cluster")
+	require.NoError(t, err)
+
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(context.Background(), "cluster", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"gpu", "storageclass/standard"}, syncTarget.Status.Capabilities)
+
+	t.Log("No update without changes")
+	kcpClient.ClearActions()
+	err = reportCapabilities(context.Background(), kcpClient, downstreamClient, "cluster")
+	require.NoError(t, err)
+	for _, action := range kcpClient.Actions() {
+		require.NotEqual(t, "patch", action.GetVerb())
+	}
+}
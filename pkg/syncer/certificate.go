@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// clientCertificateNotAfter returns the expiry time of the client certificate in config, or nil
+// if config does not authenticate with a client certificate.
+func clientCertificateNotAfter(config *rest.Config) (*time.Time, error) {
+	data := config.CertData
+	if len(data) == 0 && config.CertFile != "" {
+		var err error
+		if data, err = os.ReadFile(config.CertFile); err != nil {
+			return nil, fmt.Errorf("failed to read the client certificate: %w", err)
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the client certificate: %w", err)
+	}
+	// the first certificate is the client certificate, the others are intermediates
+	notAfter := certs[0].NotAfter
+	return &notAfter, nil
+}
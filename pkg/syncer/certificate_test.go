@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestClientCertificateNotAfter(t *testing.T) {
+	certPEM, _, err := certutil.GenerateSelfSignedCertKey("syncer", nil, nil)
+	require.NoError(t, err)
+	certs, err := certutil.ParseCertsPEM(certPEM)
+	require.NoError(t, err)
+	want := certs[0].NotAfter
+
+	certFile := filepath.Join(t.TempDir(), "syncer.crt")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+
+	for _, tc := range []struct {
+		name    string
+		config  *rest.Config
+		want    *time.Time
+		wantErr bool
+	}{
+		{name: "cert data", config: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: certPEM}}, want: &want},
+		{name: "cert file", config: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CertFile: certFile}}, want: &want},
+		{name: "bearer token", config: &rest.Config{BearerToken: "token"}},
+		{name: "missing cert file", config: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CertFile: filepath.Join(t.TempDir(), "missing.crt")}}, wantErr: true},
+		{name: "invalid cert data", config: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: []byte("invalid")}}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := clientCertificateNotAfter(tc.config)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// SyncActivity records when the spec and status syncers last synced an object,
+// and how many objects they synced, overall and per resource, together with the
+// sync errors per resource and the namespace collisions. It is reported in the
+// SyncTarget status together with the heartbeat.
+type SyncActivity struct {
+	lock         sync.Mutex
+	lastSyncTime time.Time
+	count        int64
+	resources    map[schema.GroupVersionResource]*resourceActivity
+
+	// namespaceCollisions are keyed by the upstream namespace which is not synced.
+	namespaceCollisions map[upstreamNamespace]workloadv1alpha1.NamespaceCollision
+	// namespaceCollisionsRecorded is true once any collision has been recorded, such
+	// that resolved collisions are reported as well.
+	namespaceCollisionsRecorded bool
+
+	now func() time.Time
+}
+
+// resourceActivity is the sync activity of a single resource.
+type resourceActivity struct {
+	lastSyncTime time.Time
+	errorCount   int64
+	lastError    string
+}
+
+type upstreamNamespace struct {
+	workspace logicalcluster.Name
+	namespace string
+}
+
+// NewSyncActivity returns a SyncActivity without any recorded syncs.
+func NewSyncActivity() *SyncActivity {
+	return &SyncActivity{
+		resources:           map[schema.GroupVersionResource]*resourceActivity{},
+		namespaceCollisions: map[upstreamNamespace]workloadv1alpha1.NamespaceCollision{},
+		now:                 time.Now,
+	}
+}
+
+// RecordSync records that an object of the given resource has been synced. It
+// resets the errors of the resource. It is a no-op on a nil SyncActivity.
+func (a *SyncActivity) RecordSync(gvr schema.GroupVersionResource) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.lastSyncTime = a.now()
+	a.count++
+
+	r := a.resourceLocked(gvr)
+	r.lastSyncTime = a.lastSyncTime
+	r.errorCount = 0
+	r.lastError = ""
+}
+
+// RecordError records that syncing an object of the given resource failed with
+// err. It is a no-op on a nil SyncActivity.
+func (a *SyncActivity) RecordError(gvr schema.GroupVersionResource, err error) {
+	if a == nil || err == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	r := a.resourceLocked(gvr)
+	r.errorCount++
+	r.lastError = err.Error()
+}
+
+func (a *SyncActivity) resourceLocked(gvr schema.GroupVersionResource) *resourceActivity {
+	r, ok := a.resources[gvr]
+	if !ok {
+		r = &resourceActivity{}
+		a.resources[gvr] = r
+	}
+	return r
+}
+
+// Get returns the time of the last sync and the number of synced objects. The
+// time is zero if nothing has been synced yet.
+func (a *SyncActivity) Get() (lastSyncTime time.Time, count int64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.lastSyncTime, a.count
+}
+
+// ResourceStatuses returns the sync status of every resource with a recorded sync
+// or error, sorted by group, resource and version.
+func (a *SyncActivity) ResourceStatuses() []workloadv1alpha1.ResourceSyncStatus {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	statuses := make([]workloadv1alpha1.ResourceSyncStatus, 0, len(a.resources))
+	for gvr, r := range a.resources {
+		status := workloadv1alpha1.ResourceSyncStatus{
+			Group:      gvr.Group,
+			Version:    gvr.Version,
+			Resource:   gvr.Resource,
+			ErrorCount: r.errorCount,
+			LastError:  r.lastError,
+		}
+		if !r.lastSyncTime.IsZero() {
+			status.LastSyncTime = &metav1.Time{Time: r.lastSyncTime}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Group != statuses[j].Group {
+			return statuses[i].Group < statuses[j].Group
+		}
+		if statuses[i].Resource != statuses[j].Resource {
+			return statuses[i].Resource < statuses[j].Resource
+		}
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
+
+// RecordNamespaceCollision records that the upstream namespace of the collision is not
+// synced because its downstream namespace belongs to another upstream namespace. It is
+// a no-op on a nil SyncActivity.
+func (a *SyncActivity) RecordNamespaceCollision(collision workloadv1alpha1.NamespaceCollision) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.namespaceCollisions[upstreamNamespace{logicalcluster.New(collision.Workspace), collision.Namespace}] = collision
+	a.namespaceCollisionsRecorded = true
+}
+
+// ResolveNamespaceCollision removes the collision of the given upstream namespace, if
+// any. It is a no-op on a nil SyncActivity.
+func (a *SyncActivity) ResolveNamespaceCollision(workspace logicalcluster.Name, namespace string) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.namespaceCollisions, upstreamNamespace{workspace, namespace})
+}
+
+// NamespaceCollisions returns the current namespace collisions, sorted by downstream
+// namespace, workspace and namespace. recorded is false as long as no collision has
+// ever been recorded.
+func (a *SyncActivity) NamespaceCollisions() (collisions []workloadv1alpha1.NamespaceCollision, recorded bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	collisions = make([]workloadv1alpha1.NamespaceCollision, 0, len(a.namespaceCollisions))
+	for _, collision := range a.namespaceCollisions {
+		collisions = append(collisions, collision)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		if collisions[i].DownstreamNamespace != collisions[j].DownstreamNamespace {
+			return collisions[i].DownstreamNamespace < collisions[j].DownstreamNamespace
+		}
+		if collisions[i].Workspace != collisions[j].Workspace {
+			return collisions[i].Workspace < collisions[j].Workspace
+		}
+		return collisions[i].Namespace < collisions[j].Namespace
+	})
+	return collisions, a.namespaceCollisionsRecorded
+}
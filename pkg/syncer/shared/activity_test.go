@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+func TestSyncActivity(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	a := NewSyncActivity()
+	a.now = func() time.Time { return now }
+
+	lastSyncTime, count := a.Get()
+	require.True(t, lastSyncTime.IsZero())
+	require.Zero(t, count)
+
+	a.RecordSync(deploymentsGVR)
+	lastSyncTime, count = a.Get()
+	require.Equal(t, now, lastSyncTime)
+	require.Equal(t, int64(1), count)
+
+	now = now.Add(time.Minute)
+	a.RecordSync(deploymentsGVR)
+	a.RecordSync(configMapsGVR)
+	lastSyncTime, count = a.Get()
+	require.Equal(t, now, lastSyncTime)
+	require.Equal(t, int64(3), count)
+
+	var nilActivity *SyncActivity
+	nilActivity.RecordSync(deploymentsGVR)
+	nilActivity.RecordError(deploymentsGVR, errors.New("failed"))
+}
+
+func TestSyncActivityResourceStatuses(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	a := NewSyncActivity()
+	a.now = func() time.Time { return now }
+	require.Empty(t, a.ResourceStatuses())
+
+	a.RecordSync(deploymentsGVR)
+	a.RecordSync(configMapsGVR)
+	a.RecordError(deploymentsGVR, errors.New("first"))
+	a.RecordError(deploymentsGVR, errors.New("second"))
+	require.Equal(t, []workloadv1alpha1.ResourceSyncStatus{
+		{Version: "v1", Resource: "configmaps", LastSyncTime: &metav1.Time{Time: now}},
+		{Group: "apps", Version: "v1", Resource: "deployments", LastSyncTime: &metav1.Time{Time: now}, ErrorCount: 2, LastError: "second"},
+	}, a.ResourceStatuses())
+
+	t.Log("A successful sync resets the errors of the resource")
+	now = now.Add(time.Minute)
+	a.RecordSync(deploymentsGVR)
+	require.Equal(t, []workloadv1alpha1.ResourceSyncStatus{
+		{Version: "v1", Resource: "configmaps", LastSyncTime: &metav1.Time{Time: now.Add(-time.Minute)}},
+		{Group: "apps", Version: "v1", Resource: "deployments", LastSyncTime: &metav1.Time{Time: now}},
+	}, a.ResourceStatuses())
+}
+
+func TestSyncActivityNamespaceCollisions(t *testing.T) {
+	a := NewSyncActivity()
+	collisions, recorded := a.NamespaceCollisions()
+	require.Empty(t, collisions)
+	require.False(t, recorded)
+
+	t.Log("Two upstream namespaces of different workspaces collide in the same downstream namespace")
+	first := workloadv1alpha1.NamespaceCollision{DownstreamNamespace: "kcp-abc", Workspace: "root:org:b", Namespace: "test"}
+	second := workloadv1alpha1.NamespaceCollision{DownstreamNamespace: "kcp-abc", Workspace: "root:org:a", Namespace: "test"}
+	a.RecordNamespaceCollision(first)
+	a.RecordNamespaceCollision(second)
+	a.RecordNamespaceCollision(first)
+	collisions, recorded = a.NamespaceCollisions()
+	require.Equal(t, []workloadv1alpha1.NamespaceCollision{second, first}, collisions)
+	require.True(t, recorded)
+
+	t.Log("Resolved collisions are removed, but still recorded")
+	a.ResolveNamespaceCollision(logicalcluster.New("root:org:a"), "test")
+	a.ResolveNamespaceCollision(logicalcluster.New("root:org:b"), "test")
+	collisions, recorded = a.NamespaceCollisions()
+	require.Empty(t, collisions)
+	require.NotNil(t, collisions, "expected an empty list to clear the reported collisions")
+	require.True(t, recorded)
+}
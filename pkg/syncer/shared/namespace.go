@@ -26,6 +26,8 @@ import (
 	"github.com/martinlindhe/base36"
 
 	"k8s.io/apimachinery/pkg/types"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 const (
@@ -85,3 +87,13 @@ func PhysicalClusterNamespaceName(l NamespaceLocator) (string, error) {
 	// keep the namespaces short enough.
 	return fmt.Sprintf("kcp-%s", base36hash[:12]), nil
 }
+
+// DownstreamNamespaceName returns the name of the downstream namespace of the upstream
+// namespace of the NamespaceLocator with the given mapping strategy. An empty strategy
+// means Prefixed.
+func DownstreamNamespaceName(strategy workloadv1alpha1.NamespaceMappingStrategy, l NamespaceLocator) (string, error) {
+	if strategy == workloadv1alpha1.NamespaceMappingStrategyMirror {
+		return l.Namespace, nil
+	}
+	return PhysicalClusterNamespaceName(l)
+}
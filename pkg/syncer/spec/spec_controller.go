@@ -37,6 +37,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
@@ -61,10 +62,14 @@ type Controller struct {
 	syncTargetClusterName     logicalcluster.Name
 	syncTargetUID             types.UID
 	advancedSchedulingEnabled bool
+	namespaceMappingStrategy  workloadv1alpha1.NamespaceMappingStrategy
+	syncGeneration            int64
+
+	syncActivity *shared.SyncActivity
 }
 
-func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName logicalcluster.Name, syncTargetName string, upstreamURL *url.URL, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName logicalcluster.Name, syncTargetName string, upstreamURL *url.URL, advancedSchedulingEnabled bool, namespaceMappingStrategy workloadv1alpha1.NamespaceMappingStrategy, syncGeneration int64,
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID, syncActivity *shared.SyncActivity) (*Controller, error) {
 
 	c := Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
@@ -78,6 +83,10 @@ func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName log
 		syncTargetClusterName:     syncTargetClusterName,
 		syncTargetUID:             syncTargetUID,
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
+		namespaceMappingStrategy:  namespaceMappingStrategy,
+		syncGeneration:            syncGeneration,
+
+		syncActivity: syncActivity,
 	}
 
 	namespaceGVR := schema.GroupVersionResource{
@@ -232,6 +241,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	defer c.queue.Done(key)
 
 	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+		c.syncActivity.RecordError(qk.gvr, err)
 		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -140,7 +141,7 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		return fmt.Errorf("(namespace collision) found multiple downstream namespaces: %s for upstream namespace %s|%s", strings.Join(namespacesCollisions, ","), clusterName, upstreamNamespace)
 	} else {
 		klog.V(4).Infof("No downstream namespaces found for %s", key)
-		downstreamNamespace, err = shared.PhysicalClusterNamespaceName(desiredNSLocator)
+		downstreamNamespace, err = shared.DownstreamNamespaceName(c.namespaceMappingStrategy, desiredNSLocator)
 		if err != nil {
 			klog.Errorf("Error hashing namespace %s|%s: %v", clusterName, upstreamNamespace, err)
 			return nil
@@ -155,9 +156,13 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	if !exists {
 		// deleted upstream => delete downstream
 		klog.Infof("Deleting downstream GVR %q object %s/%s for upstream cluster %q", gvr.String(), upstreamNamespace, name, clusterName)
-		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
 			return err
 		}
+		c.syncActivity.RecordSync(gvr)
 		return nil
 	}
 
@@ -209,6 +214,7 @@ func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downst
 			return err
 		}
 		klog.Infof("Created downstream namespace %s for upstream namespace %s|%s", newNamespace.GetName(), desiredNSLocator.Workspace, desiredNSLocator.Namespace)
+		c.syncActivity.ResolveNamespaceCollision(desiredNSLocator.Workspace, desiredNSLocator.Namespace)
 		return nil
 	} else if err != nil {
 		return err
@@ -218,18 +224,34 @@ func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downst
 	unstrNamespace := namespace.(*unstructured.Unstructured)
 	nsLocator, exists, err := shared.LocatorFromAnnotations(unstrNamespace.GetAnnotations())
 	if err != nil {
+		c.recordNamespaceCollision(desiredNSLocator, newNamespace.GetName(), "has an invalid namespace locator")
 		return fmt.Errorf("(possible namespace collision) namespace %s already exists, but found an error when trying to decode the annotation: %w", newNamespace.GetName(), err)
 	}
 	if !exists {
+		c.recordNamespaceCollision(desiredNSLocator, newNamespace.GetName(), "is not managed by the syncer")
 		return fmt.Errorf("(namespace collision) namespace %s has no namespace locator", unstrNamespace.GetName())
 	}
 	if !reflect.DeepEqual(desiredNSLocator, *nsLocator) {
+		c.recordNamespaceCollision(desiredNSLocator, newNamespace.GetName(), fmt.Sprintf("belongs to upstream namespace %s|%s of SyncTarget %s|%s", nsLocator.Workspace, nsLocator.Namespace, nsLocator.SyncTarget.Path, nsLocator.SyncTarget.Name))
 		return fmt.Errorf("(namespace collision) namespace %s already exists, but has a different namespace locator annotation: %+v vs %+v", newNamespace.GetName(), nsLocator, desiredNSLocator)
 	}
 
+	c.syncActivity.ResolveNamespaceCollision(desiredNSLocator.Workspace, desiredNSLocator.Namespace)
 	return nil
 }
 
+// recordNamespaceCollision records that the upstream namespace of the locator is not synced
+// because the downstream namespace is taken, for the syncer to report it in the SyncTarget status.
+func (c *Controller) recordNamespaceCollision(locator shared.NamespaceLocator, downstreamNamespace, reason string) {
+	klog.Warningf("Upstream namespace %s|%s is not synced, downstream namespace %s %s", locator.Workspace, locator.Namespace, downstreamNamespace, reason)
+	c.syncActivity.RecordNamespaceCollision(workloadv1alpha1.NamespaceCollision{
+		DownstreamNamespace: downstreamNamespace,
+		Workspace:           locator.Workspace.String(),
+		Namespace:           locator.Namespace,
+		Message:             fmt.Sprintf("Downstream namespace %s %s", downstreamNamespace, reason),
+	})
+}
+
 func (c *Controller) ensureSyncerFinalizer(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) error {
 	upstreamFinalizers := upstreamObj.GetFinalizers()
 	hasFinalizer := false
@@ -298,6 +320,9 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	labels := downstreamObj.GetLabels()
 	delete(labels, workloadv1alpha1.ClusterResourceStateLabelPrefix+c.syncTargetName)
 	labels[workloadv1alpha1.InternalDownstreamClusterLabel] = c.syncTargetName
+	if c.syncGeneration > 0 {
+		labels[workloadv1alpha1.SyncGenerationLabel] = strconv.FormatInt(c.syncGeneration, 10)
+	}
 	downstreamObj.SetLabels(labels)
 
 	if c.advancedSchedulingEnabled {
@@ -356,6 +381,7 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 			return err
 		}
 		klog.V(2).Infof("Deleted %s %s/%s from downstream %s|%s/%s", gvr.Resource, upstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), downstreamNamespace, downstreamObj.GetName())
+		c.syncActivity.RecordSync(gvr)
 		return nil
 	}
 
@@ -370,6 +396,7 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 		return err
 	}
 	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName())
+	c.syncActivity.RecordSync(gvr)
 
 	return nil
 }
@@ -474,10 +474,13 @@ func TestSyncerProcess(t *testing.T) {
 		syncTargetName            string
 		syncTargetUID             types.UID
 		advancedSchedulingEnabled bool
+		namespaceMappingStrategy  workloadv1alpha1.NamespaceMappingStrategy
+		syncGeneration            int64
 
-		expectError         bool
-		expectActionsOnFrom []clienttesting.Action
-		expectActionsOnTo   []clienttesting.Action
+		expectError               bool
+		expectActionsOnFrom       []clienttesting.Action
+		expectActionsOnTo         []clienttesting.Action
+		expectNamespaceCollisions []workloadv1alpha1.NamespaceCollision
 	}{
 		"SpecSyncer sync deployment to downstream, upstream gets patched with the finalizer and the object is created downstream": {
 			upstreamLogicalCluster: "root:org:ws",
@@ -592,6 +595,115 @@ func TestSyncerProcess(t *testing.T) {
 				),
 			},
 		},
+		"SpecSyncer sync to downstream with the Mirror namespace mapping strategy, downstream namespace has the upstream name": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"state.workload.kcp.dev/us-west1": "Sync",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, []string{"workload.kcp.dev/syncer-us-west1"}),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+			namespaceMappingStrategy:            workloadv1alpha1.NamespaceMappingStrategyMirror,
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("test", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
+					),
+				),
+				patchDeploymentAction(
+					"theDeployment",
+					"test",
+					types.ApplyPatchType,
+					toJson(t,
+						changeUnstructured(
+							toUnstructured(t, deployment("theDeployment", "test", "", map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							}, nil, nil)),
+							setNestedField(map[string]interface{}{}, "status"),
+							setPodSpecServiceAccount("spec", "template", "spec"),
+						),
+					),
+				),
+			},
+		},
+		"SpecSyncer sync to downstream with a sync generation, the downstream object is labeled with the generation": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"state.workload.kcp.dev/us-west1": "Sync",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, []string{"workload.kcp.dev/syncer-us-west1"}),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+			syncGeneration:                      3,
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("kcp-2r7hmup1y2r1", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
+					),
+				),
+				patchDeploymentAction(
+					"theDeployment",
+					"kcp-2r7hmup1y2r1",
+					types.ApplyPatchType,
+					toJson(t,
+						changeUnstructured(
+							toUnstructured(t, deployment("theDeployment", "kcp-2r7hmup1y2r1", "", map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+								"workload.kcp.dev/sync-generation":  "3",
+							}, nil, nil)),
+							setNestedField(map[string]interface{}{}, "status"),
+							setPodSpecServiceAccount("spec", "template", "spec"),
+						),
+					),
+				),
+			},
+		},
 		"SpecSyncer upstream resource has the state workload annotation removed, expect deletion downstream": {
 			upstreamLogicalCluster: "root:org:ws",
 			fromNamespace: namespace("test", "root:org:ws", map[string]string{
@@ -893,6 +1005,51 @@ func TestSyncerProcess(t *testing.T) {
 			expectError:                         true,
 			expectActionsOnFrom:                 []clienttesting.Action{},
 			expectActionsOnTo:                   []clienttesting.Action{},
+			expectNamespaceCollisions: []workloadv1alpha1.NamespaceCollision{{
+				DownstreamNamespace: "kcp-2r7hmup1y2r1",
+				Workspace:           "root:org:ws",
+				Namespace:           "test",
+				Message:             "Downstream namespace kcp-2r7hmup1y2r1 belongs to upstream namespace root:org:ws|ANOTHERNAMESPACE of SyncTarget root:org:ws|us-west1",
+			}},
+		},
+		"SpecSyncer namespace conflict: the downstream namespace belongs to the same namespace of another workspace, expect a collision": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"internal.workload.kcp.dev/cluster": "us-west1",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, []string{"workload.kcp.dev/syncer-us-west1"}),
+			},
+			toResources: []runtime.Object{
+				namespace("kcp-2r7hmup1y2r1", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+					"state.workload.kcp.dev/us-west1":   "Sync",
+				}, map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:other","namespace":"test"}`,
+				}),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+			expectError:                         true,
+			expectActionsOnFrom:                 []clienttesting.Action{},
+			expectActionsOnTo:                   []clienttesting.Action{},
+			expectNamespaceCollisions: []workloadv1alpha1.NamespaceCollision{{
+				DownstreamNamespace: "kcp-2r7hmup1y2r1",
+				Workspace:           "root:org:ws",
+				Namespace:           "test",
+				Message:             "Downstream namespace kcp-2r7hmup1y2r1 belongs to upstream namespace root:org:other|test of SyncTarget root:org:ws|us-west1",
+			}},
 		},
 		"SpecSyncer namespace conflict: try to sync to an already existing namespace without a namespace-locator, expect error": {
 			upstreamLogicalCluster: "root:org:ws",
@@ -925,6 +1082,12 @@ func TestSyncerProcess(t *testing.T) {
 			expectError:                         true,
 			expectActionsOnFrom:                 []clienttesting.Action{},
 			expectActionsOnTo:                   []clienttesting.Action{},
+			expectNamespaceCollisions: []workloadv1alpha1.NamespaceCollision{{
+				DownstreamNamespace: "kcp-2r7hmup1y2r1",
+				Workspace:           "root:org:ws",
+				Namespace:           "test",
+				Message:             "Downstream namespace kcp-2r7hmup1y2r1 is not managed by the syncer",
+			}},
 		},
 	}
 
@@ -970,7 +1133,8 @@ func TestSyncerProcess(t *testing.T) {
 			}
 			upstreamURL, err := url.Parse("https://kcp.dev:6443")
 			require.NoError(t, err)
-			controller, err := NewSpecSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID)
+			syncActivity := shared.NewSyncActivity()
+			controller, err := NewSpecSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, upstreamURL, tc.advancedSchedulingEnabled, tc.namespaceMappingStrategy, tc.syncGeneration, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID, syncActivity)
 			require.NoError(t, err)
 
 			fromInformers.Start(ctx.Done())
@@ -1001,6 +1165,12 @@ func TestSyncerProcess(t *testing.T) {
 			}
 			assert.EqualValues(t, tc.expectActionsOnFrom, fromClient.Actions())
 			assert.EqualValues(t, tc.expectActionsOnTo, toClient.Actions())
+			collisions, _ := syncActivity.NamespaceCollisions()
+			if len(tc.expectNamespaceCollisions) == 0 {
+				assert.Empty(t, collisions)
+			} else {
+				assert.Equal(t, tc.expectNamespaceCollisions, collisions)
+			}
 		})
 	}
 }
@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -53,10 +54,12 @@ type Controller struct {
 	syncTargetClusterName     logicalcluster.Name
 	syncTargetUID             types.UID
 	advancedSchedulingEnabled bool
+
+	syncActivity *shared.SyncActivity
 }
 
 func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName logicalcluster.Name, syncTargetName string, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID, syncActivity *shared.SyncActivity) (*Controller, error) {
 
 	c := &Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
@@ -71,6 +74,8 @@ func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName l
 		syncTargetClusterName:     syncTargetClusterName,
 		syncTargetUID:             syncTargetUID,
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
+
+		syncActivity: syncActivity,
 	}
 
 	for _, gvr := range gvrs {
@@ -152,6 +157,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	defer c.queue.Done(key)
 
 	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+		c.syncActivity.RecordError(qk.gvr, err)
 		runtime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
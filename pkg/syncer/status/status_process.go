@@ -138,6 +138,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 
 	labels := upstreamObj.GetLabels()
 	delete(labels, workloadv1alpha1.InternalDownstreamClusterLabel)
+	delete(labels, workloadv1alpha1.SyncGenerationLabel)
 	labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+c.syncTargetName] = string(workloadv1alpha1.ResourceStateSync)
 	upstreamObj.SetLabels(labels)
 
@@ -169,6 +170,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 			return err
 		}
 		klog.Infof("Updated status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace())
+		c.syncActivity.RecordSync(gvr)
 		return nil
 	}
 
@@ -177,6 +179,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 		return err
 	}
 	klog.Infof("Updated status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace())
+	c.syncActivity.RecordSync(gvr)
 	return nil
 }
 
@@ -18,6 +18,7 @@ package syncer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -33,6 +34,7 @@ import (
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -40,6 +42,7 @@ import (
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/pkg/syncer/spec"
 	"github.com/kcp-dev/kcp/pkg/syncer/status"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
@@ -55,6 +58,12 @@ const (
 
 	// TODO(marun) Ensure backoff rather than using a constant to avoid thundering herds
 	gvrQueryInterval = 1 * time.Second
+
+	// versionReportInterval is the interval in which the Kubernetes version of the downstream cluster is reported.
+	versionReportInterval = 5 * time.Minute
+
+	// capabilitiesReportInterval is the interval in which the capabilities of the downstream cluster are reported.
+	capabilitiesReportInterval = 5 * time.Minute
 )
 
 // SyncerConfig defines the syncer configuration that is guaranteed to
@@ -117,7 +126,10 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	// Start api import first because spec and status syncers are blocked by
 	// gvr discovery finding all the configured resource types in the kcp
 	// workspace.
-	apiImporter, err := NewAPIImporter(cfg.UpstreamConfig, cfg.DownstreamConfig, resources, cfg.KCPClusterName, cfg.SyncTargetName)
+	rateLimitedDownstreamConfig := rest.CopyConfig(cfg.DownstreamConfig)
+	applySyncTargetRateLimits(rateLimitedDownstreamConfig, syncTarget)
+
+	apiImporter, err := NewAPIImporter(cfg.UpstreamConfig, rateLimitedDownstreamConfig, resources, cfg.KCPClusterName, cfg.SyncTargetName)
 	if err != nil {
 		return err
 	}
@@ -126,7 +138,7 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	upstreamConfig := rest.CopyConfig(cfg.UpstreamConfig)
 	upstreamConfig.Host = syncerVirtualWorkspaceURL
 	upstreamConfig.UserAgent = "kcp#spec-syncer/" + kcpVersion
-	downstreamConfig := rest.CopyConfig(cfg.DownstreamConfig)
+	downstreamConfig := rest.CopyConfig(rateLimitedDownstreamConfig)
 	downstreamConfig.UserAgent = "kcp#status-syncer/" + kcpVersion
 
 	upstreamDynamicClusterClient, err := dynamic.NewClusterForConfig(upstreamConfig)
@@ -184,20 +196,38 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		advancedSchedulingEnabled = true
 	}
 
+	namespaceMappingStrategy := syncTarget.Spec.NamespaceMappingStrategy
+	if namespaceMappingStrategy == "" {
+		namespaceMappingStrategy = workloadv1alpha1.NamespaceMappingStrategyPrefixed
+	}
+	klog.Infof("Using the %s namespace mapping strategy for syncTarget %s", namespaceMappingStrategy, cfg.SyncTargetName)
+
+	syncGeneration := nextSyncGeneration(syncTarget)
+	klog.Infof("Syncing with generation %d for syncTarget %s", syncGeneration, cfg.SyncTargetName)
+
+	certificateNotAfter, err := clientCertificateNotAfter(cfg.UpstreamConfig)
+	if err != nil {
+		return err
+	}
+	if certificateNotAfter != nil {
+		klog.Infof("The client certificate of the syncer for syncTarget %s expires at %s", cfg.SyncTargetName, certificateNotAfter.Format(time.RFC3339))
+	}
+
 	klog.Infof("Creating spec syncer for clusterName %s to pcluster %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, resources)
 	upstreamURL, err := url.Parse(cfg.UpstreamConfig.Host)
 	if err != nil {
 		return err
 	}
-	specSyncer, err := spec.NewSpecSyncer(gvrs, cfg.KCPClusterName, cfg.SyncTargetName, upstreamURL, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
+	syncActivity := shared.NewSyncActivity()
+	specSyncer, err := spec.NewSpecSyncer(gvrs, cfg.KCPClusterName, cfg.SyncTargetName, upstreamURL, advancedSchedulingEnabled, namespaceMappingStrategy, syncGeneration,
+		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID(), syncActivity)
 	if err != nil {
 		return err
 	}
 
 	klog.Infof("Creating status syncer for clusterName %s from pcluster %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, resources)
 	statusSyncer, err := status.NewStatusSyncer(gvrs, cfg.KCPClusterName, cfg.SyncTargetName, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
+		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID(), syncActivity)
 	if err != nil {
 		return err
 	}
@@ -211,6 +241,27 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	go specSyncer.Start(ctx, numSyncerThreads)
 	go statusSyncer.Start(ctx, numSyncerThreads)
 
+	downstreamDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(downstreamConfig)
+	if err != nil {
+		return err
+	}
+	kubeVersion := version.Get()
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := reportVersion(ctx, kcpClusterClient.Cluster(cfg.KCPClusterName), downstreamDiscoveryClient, cfg.SyncTargetName, &kubeVersion); err != nil {
+			klog.Errorf("failed to report the Kubernetes version of SyncTarget %s|%s: %v", cfg.KCPClusterName, cfg.SyncTargetName, err)
+		}
+	}, versionReportInterval)
+
+	downstreamKubeClient, err := kubernetes.NewForConfig(downstreamConfig)
+	if err != nil {
+		return err
+	}
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := reportCapabilities(ctx, kcpClusterClient.Cluster(cfg.KCPClusterName), downstreamKubeClient, cfg.SyncTargetName); err != nil {
+			klog.Errorf("failed to report the capabilities of SyncTarget %s|%s: %v", cfg.KCPClusterName, cfg.SyncTargetName, err)
+		}
+	}, capabilitiesReportInterval)
+
 	// Attempt to heartbeat every interval
 	go wait.UntilWithContext(ctx, func(ctx context.Context) {
 		var heartbeatTime time.Time
@@ -220,7 +271,10 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
 		// poll error can be safely ignored.
 		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
-			patchBytes := []byte(fmt.Sprintf(`[{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, time.Now().Format(time.RFC3339)))
+			patchBytes, err := heartbeatPatch(time.Now(), namespaceMappingStrategy, syncGeneration, certificateNotAfter, syncActivity)
+			if err != nil {
+				return false, err
+			}
 			syncTarget, err := kcpClusterClient.Cluster(cfg.KCPClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
 			if err != nil {
 				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for SyncTarget %s|%s: %v", cfg.KCPClusterName, cfg.SyncTargetName, err)
@@ -237,6 +291,58 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	return nil
 }
 
+// applySyncTargetRateLimits overrides the client rate limits of config with spec.syncerQPS
+// and spec.syncerBurst of the SyncTarget, if set.
+func applySyncTargetRateLimits(config *rest.Config, syncTarget *workloadv1alpha1.SyncTarget) {
+	if qps := syncTarget.Spec.SyncerQPS; qps != nil && *qps > 0 {
+		klog.Infof("Limiting the QPS of the syncer to SyncTarget %s to %d", syncTarget.Name, *qps)
+		config.QPS = float32(*qps)
+	}
+	if burst := syncTarget.Spec.SyncerBurst; burst != nil && *burst > 0 {
+		klog.Infof("Limiting the burst of the syncer to SyncTarget %s to %d", syncTarget.Name, *burst)
+		config.Burst = int(*burst)
+	}
+}
+
+// nextSyncGeneration returns the sync generation of a syncer starting for the SyncTarget, i.e.
+// one more than the generation of the previous syncer.
+func nextSyncGeneration(syncTarget *workloadv1alpha1.SyncTarget) int64 {
+	return syncTarget.Status.SyncGeneration + 1
+}
+
+// heartbeatPatch returns the JSON patch of the SyncTarget status for a heartbeat. It always
+// sets the heartbeat time, the namespace mapping strategy and the sync generation. The expiry
+// of the client certificate, the sync activity, the per-resource sync status and namespace
+// collisions are only set once known.
+func heartbeatPatch(now time.Time, namespaceMappingStrategy workloadv1alpha1.NamespaceMappingStrategy, syncGeneration int64, certificateNotAfter *time.Time, syncActivity *shared.SyncActivity) ([]byte, error) {
+	type op struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	ops := []op{
+		{Op: "replace", Path: "/status/lastSyncerHeartbeatTime", Value: now.Format(time.RFC3339)},
+		{Op: "add", Path: "/status/namespaceMappingStrategy", Value: namespaceMappingStrategy},
+		{Op: "add", Path: "/status/syncGeneration", Value: syncGeneration},
+	}
+	if certificateNotAfter != nil {
+		ops = append(ops, op{Op: "add", Path: "/status/syncerCertificateNotAfter", Value: certificateNotAfter.UTC().Format(time.RFC3339)})
+	}
+	if lastSyncTime, count := syncActivity.Get(); !lastSyncTime.IsZero() {
+		ops = append(ops,
+			op{Op: "add", Path: "/status/lastSyncTime", Value: lastSyncTime.Format(time.RFC3339)},
+			op{Op: "add", Path: "/status/syncedObjectCount", Value: count},
+		)
+	}
+	if statuses := syncActivity.ResourceStatuses(); len(statuses) > 0 {
+		ops = append(ops, op{Op: "add", Path: "/status/resourceSyncStatus", Value: statuses})
+	}
+	if collisions, recorded := syncActivity.NamespaceCollisions(); recorded {
+		ops = append(ops, op{Op: "add", Path: "/status/namespaceCollisions", Value: collisions})
+	}
+	return json.Marshal(ops)
+}
+
 func contains(ss []string, s string) bool {
 	for _, n := range ss {
 		if n == s {
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+func TestHeartbeatPatch(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	syncActivity := shared.NewSyncActivity()
+	var certificateNotAfter *time.Time
+
+	apply := func(status workloadv1alpha1.SyncTargetStatus, now time.Time) workloadv1alpha1.SyncTargetStatus {
+		patch, err := heartbeatPatch(now, workloadv1alpha1.NamespaceMappingStrategyMirror, 2, certificateNotAfter, syncActivity)
+		require.NoError(t, err)
+		decoded, err := jsonpatch.DecodePatch(patch)
+		require.NoError(t, err)
+		doc, err := json.Marshal(workloadv1alpha1.SyncTarget{Status: status})
+		require.NoError(t, err)
+		patched, err := decoded.Apply(doc)
+		require.NoError(t, err)
+		var syncTarget workloadv1alpha1.SyncTarget
+		require.NoError(t, json.Unmarshal(patched, &syncTarget))
+		return syncTarget.Status
+	}
+
+	// nothing synced yet: only the heartbeat, the namespace mapping strategy and the sync generation are set
+	status := apply(workloadv1alpha1.SyncTargetStatus{LastSyncerHeartbeatTime: &metav1.Time{Time: now.Add(-time.Minute)}, SyncGeneration: 1}, now)
+	require.Equal(t, now, status.LastSyncerHeartbeatTime.UTC())
+	require.Equal(t, workloadv1alpha1.NamespaceMappingStrategyMirror, status.NamespaceMappingStrategy)
+	require.Equal(t, int64(2), status.SyncGeneration)
+	require.Nil(t, status.LastSyncTime)
+	require.Zero(t, status.SyncedObjectCount)
+	require.Nil(t, status.SyncerCertificateNotAfter)
+
+	// objects synced: the fields advance with every heartbeat
+	syncActivity.RecordSync(deploymentsGVR)
+	status = apply(status, now.Add(time.Minute))
+	require.NotNil(t, status.LastSyncTime)
+	require.Equal(t, int64(1), status.SyncedObjectCount)
+
+	syncActivity.RecordSync(deploymentsGVR)
+	syncActivity.RecordSync(configMapsGVR)
+	status = apply(status, now.Add(2*time.Minute))
+	require.Equal(t, now.Add(2*time.Minute), status.LastSyncerHeartbeatTime.UTC())
+	require.NotNil(t, status.LastSyncTime)
+	require.Equal(t, int64(3), status.SyncedObjectCount)
+	require.Len(t, status.ResourceSyncStatus, 2)
+
+	// a sync error of one resource is reported for that resource only
+	syncActivity.RecordError(deploymentsGVR, errors.New("admission webhook denied the request"))
+	status = apply(status, now.Add(3*time.Minute))
+	require.Len(t, status.ResourceSyncStatus, 2)
+	configMaps, deployments := status.ResourceSyncStatus[0], status.ResourceSyncStatus[1]
+	require.Equal(t, "configmaps", configMaps.Resource)
+	require.Zero(t, configMaps.ErrorCount)
+	require.Empty(t, configMaps.LastError)
+	require.NotNil(t, configMaps.LastSyncTime)
+	require.Equal(t, "apps", deployments.Group)
+	require.Equal(t, "deployments", deployments.Resource)
+	require.Equal(t, int64(1), deployments.ErrorCount)
+	require.Equal(t, "admission webhook denied the request", deployments.LastError)
+	require.Empty(t, status.NamespaceCollisions)
+
+	// a namespace collision is reported until it is resolved
+	collision := workloadv1alpha1.NamespaceCollision{DownstreamNamespace: "kcp-abc", Workspace: "root:org:ws", Namespace: "test"}
+	syncActivity.RecordNamespaceCollision(collision)
+	status = apply(status, now.Add(4*time.Minute))
+	require.Equal(t, []workloadv1alpha1.NamespaceCollision{collision}, status.NamespaceCollisions)
+
+	syncActivity.ResolveNamespaceCollision(logicalcluster.New("root:org:ws"), "test")
+	status = apply(status, now.Add(5*time.Minute))
+	require.Empty(t, status.NamespaceCollisions)
+
+	// the expiry of the client certificate is reported if the syncer has one
+	notAfter := now.AddDate(0, 0, 30)
+	certificateNotAfter = &notAfter
+	status = apply(status, now.Add(6*time.Minute))
+	require.NotNil(t, status.SyncerCertificateNotAfter)
+	require.Equal(t, notAfter, status.SyncerCertificateNotAfter.UTC())
+}
+
+func TestNextSyncGeneration(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{}
+	require.Equal(t, int64(1), nextSyncGeneration(syncTarget), "expected the first syncer to start with generation 1")
+
+	syncTarget.Status.SyncGeneration = 5
+	require.Equal(t, int64(6), nextSyncGeneration(syncTarget), "expected a restarted syncer to advance the generation")
+}
+
+func TestApplySyncTargetRateLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		spec      workloadv1alpha1.SyncTargetSpec
+		wantQPS   float32
+		wantBurst int
+	}{
+		{
+			name:      "flags apply by default",
+			wantQPS:   30,
+			wantBurst: 20,
+		},
+		{
+			name:      "spec overrides the flags",
+			spec:      workloadv1alpha1.SyncTargetSpec{SyncerQPS: pointer.Int32(5), SyncerBurst: pointer.Int32(10)},
+			wantQPS:   5,
+			wantBurst: 10,
+		},
+		{
+			name:      "only qps is overridden",
+			spec:      workloadv1alpha1.SyncTargetSpec{SyncerQPS: pointer.Int32(5)},
+			wantQPS:   5,
+			wantBurst: 20,
+		},
+		{
+			name:      "zero is ignored",
+			spec:      workloadv1alpha1.SyncTargetSpec{SyncerQPS: pointer.Int32(0), SyncerBurst: pointer.Int32(0)},
+			wantQPS:   30,
+			wantBurst: 20,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &rest.Config{QPS: 30, Burst: 20}
+			applySyncTargetRateLimits(config, &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       tc.spec,
+			})
+			require.Equal(t, tc.wantQPS, config.QPS)
+			require.Equal(t, tc.wantBurst, config.Burst)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// maxMinorVersionSkew is the maximum number of minor versions the Kubernetes version of the
+// downstream cluster may differ from the one of kcp, in either direction.
+const maxMinorVersionSkew = 2
+
+// reportVersion records the Kubernetes version of the downstream cluster in the status of the
+// SyncTarget, and whether it is compatible with the Kubernetes version of kcp.
+func reportVersion(ctx context.Context, kcpClient kcpclient.Interface, downstreamDiscovery discovery.ServerVersionInterface, syncTargetName string, kcpVersion *apimachineryversion.Info) error {
+	downstreamVersion, err := downstreamDiscovery.ServerVersion()
+	if err != nil {
+		return err
+	}
+
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, syncTargetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	updated := syncTarget.DeepCopy()
+	setVersionCompatibleCondition(updated, kcpVersion, downstreamVersion)
+	if equality.Semantic.DeepEqual(syncTarget.Status, updated.Status) {
+		return nil
+	}
+
+	// a merge patch replaces the whole list, hence guard against concurrent condition updates
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": syncTarget.ResourceVersion,
+		},
+		"status": map[string]interface{}{
+			"kubernetesVersion": updated.Status.KubernetesVersion,
+			"conditions":        updated.Status.Conditions,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Updating Kubernetes version of SyncTarget %s: %s", syncTargetName, string(patch))
+	_, err = kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// setVersionCompatibleCondition sets status.kubernetesVersion to the version of the downstream
+// cluster, and marks VersionCompatible false if its minor version differs from the one of kcp by
+// more than maxMinorVersionSkew. If either version cannot be determined, e.g. for development builds
+// of kcp, the condition is removed.
+func setVersionCompatibleCondition(syncTarget *workloadv1alpha1.SyncTarget, kcpVersion, downstreamVersion *apimachineryversion.Info) {
+	syncTarget.Status.KubernetesVersion = downstreamVersion.GitVersion
+
+	kcpMajor, kcpMinor, kcpOK := majorMinor(kcpVersion)
+	major, minor, ok := majorMinor(downstreamVersion)
+	if !kcpOK || !ok {
+		conditions.Delete(syncTarget, workloadv1alpha1.VersionCompatible)
+		return
+	}
+
+	skew := minor - kcpMinor
+	if skew < 0 {
+		skew = -skew
+	}
+	if major != kcpMajor || skew > maxMinorVersionSkew {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.VersionCompatible,
+			workloadv1alpha1.VersionSkewTooLargeReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"Kubernetes version %d.%d of the cluster is more than %d minor versions away from version %d.%d of kcp",
+			major, minor, maxMinorVersionSkew, kcpMajor, kcpMinor)
+		return
+	}
+	conditions.MarkTrue(syncTarget, workloadv1alpha1.VersionCompatible)
+}
+
+// majorMinor returns the major and the minor version of info. Providers append a "+" to the
+// minor version of patched builds, which is ignored.
+func majorMinor(info *apimachineryversion.Info) (major, minor int, ok bool) {
+	major, err := strconv.Atoi(info.Major)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestSetVersionCompatibleCondition(t *testing.T) {
+	kcpVersion := &apimachineryversion.Info{Major: "1", Minor: "24"}
+
+	for _, tc := range []struct {
+		name       string
+		kcp        *apimachineryversion.Info
+		downstream *apimachineryversion.Info
+		want       *bool
+	}{
+		{
+			name:       "same version",
+			kcp:        kcpVersion,
+			downstream: &apimachineryversion.Info{Major: "1", Minor: "24", GitVersion: "v1.24.3"},
+			want:       pointer.Bool(true),
+		},
+		{
+			name:       "within the supported skew",
+			kcp:        kcpVersion,
+			downstream: &apimachineryversion.Info{Major: "1", Minor: "22+", GitVersion: "v1.22.12-gke.300"},
+			want:       pointer.Bool(true),
+		},
+		{
+			name:       "older than the supported skew",
+			kcp:        kcpVersion,
+			downstream: &apimachineryversion.Info{Major: "1", Minor: "21", GitVersion: "v1.21.14"},
+			want:       pointer.Bool(false),
+		},
+		{
+			name:       "newer than the supported skew",
+			kcp:        kcpVersion,
+			downstream: &apimachineryversion.Info{Major: "1", Minor: "27", GitVersion: "v1.27.0"},
+			want:       pointer.Bool(false),
+		},
+		{
+			name:       "different major version",
+			kcp:        kcpVersion,
+			downstream: &apimachineryversion.Info{Major: "2", Minor: "24", GitVersion: "v2.24.0"},
+			want:       pointer.Bool(false),
+		},
+		{
+			name:       "unknown kcp version",
+			kcp:        &apimachineryversion.Info{},
+			downstream: &apimachineryversion.Info{Major: "1", Minor: "24", GitVersion: "v1.24.3"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			syncTarget := &workloadv1alpha1.SyncTarget{}
+			conditions.MarkTrue(syncTarget, workloadv1alpha1.VersionCompatible)
+
+			setVersionCompatibleCondition(syncTarget, tc.kcp, tc.downstream)
+			require.Equal(t, tc.downstream.GitVersion, syncTarget.Status.KubernetesVersion)
+			if tc.want == nil {
+				require.False(t, conditions.Has(syncTarget, workloadv1alpha1.VersionCompatible))
+				return
+			}
+			require.Equal(t, *tc.want, conditions.IsTrue(syncTarget, workloadv1alpha1.VersionCompatible))
+			if !*tc.want {
+				require.Equal(t, workloadv1alpha1.VersionSkewTooLargeReason, conditions.GetReason(syncTarget, workloadv1alpha1.VersionCompatible))
+			}
+		})
+	}
+}
+
+func TestReportVersion(t *testing.T) {
+	kcpClient := kcpfakeclient.NewSimpleClientset(&workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", ResourceVersion: "1"},
+	})
+	downstreamDiscovery := &discoveryfake.FakeDiscovery{
+		Fake:               &clienttesting.Fake{},
+		FakedServerVersion: &apimachineryversion.Info{Major: "1", Minor: "19", GitVersion: "v1.19.16"},
+	}
+
+	err := reportVersion(context.Background(), kcpClient, downstreamDiscovery, "cluster", &apimachineryversion.Info{Major: "1", Minor: "24"})
+	require.NoError(t, err)
+
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(context.Background(), "cluster", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "v1.19.16", syncTarget.Status.KubernetesVersion)
+	require.True(t, conditions.IsFalse(syncTarget, workloadv1alpha1.VersionCompatible))
+	require.Equal(t, workloadv1alpha1.VersionSkewTooLargeReason, conditions.GetReason(syncTarget, workloadv1alpha1.VersionCompatible))
+	require.Equal(t, "Kubernetes version 1.19 of the cluster is more than 2 minor versions away from version 1.24 of kcp", conditions.GetMessage(syncTarget, workloadv1alpha1.VersionCompatible))
+
+	t.Log("No update without changes")
+	kcpClient.ClearActions()
+	err = reportVersion(context.Background(), kcpClient, downstreamDiscovery, "cluster", &apimachineryversion.Info{Major: "1", Minor: "24"})
+	require.NoError(t, err)
+	for _, action := range kcpClient.Actions() {
+		require.NotEqual(t, "patch", action.GetVerb())
+	}
+}
@@ -22,8 +22,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/util/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -38,7 +41,6 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	tenancylistersv1alpha1 "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
-	"github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 )
@@ -77,6 +79,9 @@ func NewAPIReconciler(
 
 		apiSets: map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet{},
 	}
+	c.updateSyncTargetStatus = func(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+		return c.kcpClusterClient.Cluster(clusterName).WorkloadV1alpha1().SyncTargets().UpdateStatus(ctx, syncTarget, metav1.UpdateOptions{})
+	}
 
 	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
 		byWorkspace: indexByWorkspace,
@@ -100,6 +105,19 @@ func NewAPIReconciler(
 		AddFunc: func(obj interface{}) {
 			c.enqueueSyncTarget(obj)
 		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			oldSyncTarget, ok := oldObj.(*workloadv1alpha1.SyncTarget)
+			if !ok {
+				return
+			}
+			syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+			if !ok {
+				return
+			}
+			if !equality.Semantic.DeepEqual(oldSyncTarget.Spec.SupportedAPIExportSelector, syncTarget.Spec.SupportedAPIExportSelector) {
+				c.enqueueSyncTarget(obj)
+			}
+		},
 		DeleteFunc: func(obj interface{}) {
 			c.enqueueSyncTarget(obj)
 		},
@@ -114,25 +132,15 @@ func NewAPIReconciler(
 		},
 	})
 
-	apiExportInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: func(obj interface{}) bool {
-			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-			if err != nil {
-				return false
-			}
-			_, name := clusters.SplitClusterAwareKey(key)
-			return name == apiexport.TemporaryComputeServiceExportName
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIExport(obj)
 		},
-		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				c.enqueueAPIExport(obj)
-			},
-			UpdateFunc: func(_, obj interface{}) {
-				c.enqueueAPIExport(obj)
-			},
-			DeleteFunc: func(obj interface{}) {
-				c.enqueueAPIExport(obj)
-			},
+		UpdateFunc: func(_, obj interface{}) {
+			c.enqueueAPIExport(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueAPIExport(obj)
 		},
 	})
 
@@ -140,7 +148,8 @@ func NewAPIReconciler(
 }
 
 // APIReconciler is a controller watching APIExports, APIResourceSchemas and SyncTargets, and updates the
-// API definitions driving the virtual workspace.
+// API definitions driving the virtual workspace. Every SyncTarget is served the APIs of the APIExports
+// selected by its spec.supportedAPIExportSelector, which are recorded in status.supportedAPIExports.
 type APIReconciler struct {
 	kcpClusterClient kcpclient.ClusterInterface
 
@@ -155,7 +164,8 @@ type APIReconciler struct {
 
 	queue workqueue.RateLimitingInterface
 
-	createAPIDefinition CreateAPIDefinitionFunc
+	createAPIDefinition    CreateAPIDefinitionFunc
+	updateSyncTargetStatus func(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error)
 
 	mutex   sync.RWMutex // protects the map, not the values!
 	apiSets map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet
@@ -167,24 +177,7 @@ func (c *APIReconciler) enqueueSyncTarget(obj interface{}) {
 		runtime.HandleError(err)
 		return
 	}
-
-	clusterName, name := clusters.SplitClusterAwareKey(key)
-	exports, err := c.apiExportIndexer.ByIndex(byWorkspace, clusterName.String())
-	if err != nil {
-		runtime.HandleError(err)
-		return
-	}
-
-	if len(exports) == 0 {
-		klog.V(2).Infof("No kubernetes APIExport found for SyncTarget %s|%s", clusterName, name)
-		return
-	}
-
-	for _, obj := range exports {
-		export := obj.(*apisv1alpha1.APIExport)
-		klog.V(2).Infof("Queueing APIExport %s|%s for SyncTarget %s", clusterName, export.Name, name)
-		c.enqueueAPIExport(obj)
-	}
+	c.queue.Add(key)
 }
 
 func (c *APIReconciler) enqueueAPIResourceSchema(obj interface{}) {
@@ -195,26 +188,34 @@ func (c *APIReconciler) enqueueAPIResourceSchema(obj interface{}) {
 	}
 
 	clusterName, name := clusters.SplitClusterAwareKey(key)
-	exports, err := c.apiExportIndexer.ByIndex(byWorkspace, clusterName.String())
+	c.enqueueSyncTargetsInWorkspace(clusterName, "APIResourceSchema", name)
+}
+
+func (c *APIReconciler) enqueueAPIExport(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 
-	for _, obj := range exports {
-		export := obj.(*apisv1alpha1.APIExport)
-		klog.V(2).Infof("Queueing APIExport %s|%s for APIResourceSchema %s", clusterName, export.Name, name)
-		c.enqueueAPIExport(obj)
-	}
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	c.enqueueSyncTargetsInWorkspace(clusterName, "APIExport", name)
 }
 
-func (c *APIReconciler) enqueueAPIExport(obj interface{}) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+// enqueueSyncTargetsInWorkspace enqueues all SyncTargets of the workspace, as every one of them
+// might select APIExports, and their APIResourceSchemas, in the same workspace.
+func (c *APIReconciler) enqueueSyncTargetsInWorkspace(clusterName logicalcluster.Name, kind, name string) {
+	syncTargets, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
-	c.queue.Add(key)
+
+	for _, obj := range syncTargets {
+		syncTarget := obj.(*workloadv1alpha1.SyncTarget)
+		klog.V(2).Infof("Queueing SyncTarget %s|%s for %s %s", clusterName, syncTarget.Name, kind, name)
+		c.enqueueSyncTarget(obj)
+	}
 }
 
 func (c *APIReconciler) startWorker(ctx context.Context) {
@@ -272,29 +273,30 @@ func (c *APIReconciler) processNextWorkItem(ctx context.Context) bool {
 }
 
 func (c *APIReconciler) process(ctx context.Context, key string) error {
-	clusterName, apiExportName := clusters.SplitClusterAwareKey(key)
-	apiExport, err := c.apiExportLister.Get(key)
-	if err != nil && !apierrors.IsNotFound(err) {
-		klog.Errorf("failed to get APIExport %s|%s from lister: %v", clusterName, apiExportName, err)
+	clusterName, syncTargetName := clusters.SplitClusterAwareKey(key)
+	apiDomainKey := dynamiccontext.APIDomainKey(key)
+
+	syncTarget, err := c.syncTargetLister.Get(key)
+	if apierrors.IsNotFound(err) {
+		c.removeAPIDefinitionSet(apiDomainKey)
+		return nil
+	}
+	if err != nil {
+		klog.Errorf("failed to get SyncTarget %s|%s from lister: %v", clusterName, syncTargetName, err)
 		return nil // nothing we can do here
 	}
 
-	cs, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err := c.apiExportIndexer.ByIndex(byWorkspace, clusterName.String())
 	if err != nil {
-		klog.Errorf("Failed to get SyncTargets in %q: %v", clusterName, err)
+		klog.Errorf("Failed to get APIExports in %q: %v", clusterName, err)
 		return nil // nothing we can do here
 	}
-
-	var errs []error
-	for _, obj := range cs {
-		cluster := obj.(*workloadv1alpha1.SyncTarget)
-		apiDomainKey := dynamiccontext.APIDomainKey(clusters.ToClusterAwareKey(clusterName, cluster.Name))
-		if err := c.reconcile(ctx, apiExport, apiDomainKey, cluster.Name); err != nil {
-			errs = append(errs, err)
-		}
+	apiExports := make([]*apisv1alpha1.APIExport, 0, len(objs))
+	for _, obj := range objs {
+		apiExports = append(apiExports, obj.(*apisv1alpha1.APIExport))
 	}
 
-	return errors.NewAggregate(errs)
+	return c.reconcile(ctx, syncTarget, apiExports)
 }
 
 func (c *APIReconciler) GetAPIDefinitionSet(_ context.Context, key dynamiccontext.APIDomainKey) (apidefinition.APIDefinitionSet, bool, error) {
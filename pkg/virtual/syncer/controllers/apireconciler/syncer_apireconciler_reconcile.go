@@ -19,65 +19,144 @@ package apireconciler
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 
 	"github.com/kcp-dev/logicalcluster"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/internalapis"
 )
 
-func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.APIExport, apiDomainKey dynamiccontext.APIDomainKey, syncTargetName string) error {
-	if apiExport == nil || apiExport.Status.IdentityHash == "" {
-		// new APIExport that is not ready yet, or export got deleted
+func (c *APIReconciler) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, apiExports []*apisv1alpha1.APIExport) error {
+	clusterName := logicalcluster.From(syncTarget)
+	apiDomainKey := dynamiccontext.APIDomainKey(clusters.ToClusterAwareKey(clusterName, syncTarget.Name))
 
-		c.mutex.RLock()
-		_, found := c.apiSets[apiDomainKey]
-		c.mutex.RUnlock()
+	selected, err := supportedAPIExports(syncTarget, apiExports)
+	if err != nil {
+		klog.Errorf("Invalid supportedAPIExportSelector of SyncTarget %s|%s: %v", clusterName, syncTarget.Name, err)
+	}
 
-		if !found {
-			klog.V(3).Infof("No APIs found for API domain key %s", apiDomainKey)
-			return nil
+	// new APIExports that are not ready yet are not served
+	var ready []*apisv1alpha1.APIExport
+	for _, apiExport := range selected {
+		if apiExport.Status.IdentityHash != "" {
+			ready = append(ready, apiExport)
 		}
+	}
 
-		// remove the APIDomain
-		c.mutex.Lock()
-		defer c.mutex.Unlock()
-		klog.V(2).Infof("Deleting APIs for API domain key %s", apiDomainKey)
-		delete(c.apiSets, apiDomainKey)
+	if len(ready) == 0 {
+		c.removeAPIDefinitionSet(apiDomainKey)
+	} else if err := c.reconcileAPIDefinitionSet(apiDomainKey, syncTarget.Name, ready); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(ready))
+	for _, apiExport := range ready {
+		names = append(names, apiExport.Name)
+	}
+	if len(names) == 0 {
+		names = nil
+	}
+	if reflect.DeepEqual(syncTarget.Status.SupportedAPIExports, names) {
 		return nil
 	}
+	updated := syncTarget.DeepCopy()
+	updated.Status.SupportedAPIExports = names
+	klog.V(2).Infof("Updating supported APIExports of SyncTarget %s|%s: %v", clusterName, syncTarget.Name, names)
+	_, err = c.updateSyncTargetStatus(ctx, clusterName, updated)
+	return err
+}
 
+// supportedAPIExports returns the APIExports selected by the supportedAPIExportSelector of the
+// SyncTarget, sorted by name. Without a selector, only the compute APIExport is selected.
+func supportedAPIExports(syncTarget *workloadv1alpha1.SyncTarget, apiExports []*apisv1alpha1.APIExport) ([]*apisv1alpha1.APIExport, error) {
+	var selected []*apisv1alpha1.APIExport
+	if syncTarget.Spec.SupportedAPIExportSelector == nil {
+		for _, apiExport := range apiExports {
+			if apiExport.Name == apiexport.TemporaryComputeServiceExportName {
+				selected = append(selected, apiExport)
+			}
+		}
+		return selected, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(syncTarget.Spec.SupportedAPIExportSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, apiExport := range apiExports {
+		if selector.Matches(labels.Set(apiExport.Labels)) {
+			selected = append(selected, apiExport)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+	return selected, nil
+}
+
+func (c *APIReconciler) removeAPIDefinitionSet(apiDomainKey dynamiccontext.APIDomainKey) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	oldSet, found := c.apiSets[apiDomainKey]
+	if !found {
+		klog.V(3).Infof("No APIs found for API domain key %s", apiDomainKey)
+		return
+	}
+
+	klog.V(2).Infof("Deleting APIs for API domain key %s", apiDomainKey)
+	for _, oldDef := range oldSet {
+		oldDef.TearDown()
+	}
+	delete(c.apiSets, apiDomainKey)
+}
+
+// reconcileAPIDefinitionSet serves the APIs of the given APIExports for the API domain key. If
+// several APIExports contain the same APIResourceSchema or resource, the first one wins.
+func (c *APIReconciler) reconcileAPIDefinitionSet(apiDomainKey dynamiccontext.APIDomainKey, syncTargetName string, apiExports []*apisv1alpha1.APIExport) error {
 	c.mutex.RLock()
 	oldSet := c.apiSets[apiDomainKey]
 	c.mutex.RUnlock()
 
 	// collect APIResourceSchemas
+	clusterName := logicalcluster.From(apiExports[0])
 	schemaIdentity := map[string]string{}
-	apiResourceSchemas := make([]*apisv1alpha1.APIResourceSchema, 0, len(apiExport.Spec.LatestResourceSchemas)+len(internalapis.Schemas))
+	apiResourceSchemas := make([]*apisv1alpha1.APIResourceSchema, 0, len(internalapis.Schemas))
 	for _, schema := range internalapis.Schemas {
 		shallow := *schema
-		shallow.ClusterName = logicalcluster.From(apiExport).String() // intentionally no direct assignment
+		shallow.ClusterName = clusterName.String() // intentionally no direct assignment
 		apiResourceSchemas = append(apiResourceSchemas, &shallow)
 	}
-	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
-		apiResourceSchema, err := c.apiResourceSchemaLister.Get(clusters.ToClusterAwareKey(logicalcluster.From(apiExport), schemaName))
-		if err != nil && !apierrors.IsNotFound(err) {
-			return err
-		}
-		if apierrors.IsNotFound(err) {
-			klog.V(3).Infof("APIResourceSchema %s in APIExport %s|% not found", schemaName, apiExport.Namespace, apiExport.Name)
-			continue
+	for _, apiExport := range apiExports {
+		for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
+			if _, found := schemaIdentity[schemaName]; found {
+				continue
+			}
+			apiResourceSchema, err := c.apiResourceSchemaLister.Get(clusters.ToClusterAwareKey(clusterName, schemaName))
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			if apierrors.IsNotFound(err) {
+				klog.V(3).Infof("APIResourceSchema %s in APIExport %s|%s not found", schemaName, clusterName, apiExport.Name)
+				continue
+			}
+			apiResourceSchemas = append(apiResourceSchemas, apiResourceSchema)
+			schemaIdentity[apiResourceSchema.Name] = apiExport.Status.IdentityHash
 		}
-		apiResourceSchemas = append(apiResourceSchemas, apiResourceSchema)
-		schemaIdentity[apiResourceSchema.Name] = apiExport.Status.IdentityHash
 	}
 
 	// reconcile APIs for APIResourceSchemas
@@ -96,6 +175,11 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 				Resource: apiResourceSchema.Spec.Names.Plural,
 			}
 
+			if _, found := newSet[gvr]; found {
+				klog.V(3).Infof("Skipping APIResourceSchema %s|%s for API domain key %s, %s is served already", logicalcluster.From(apiResourceSchema), apiResourceSchema.Name, apiDomainKey, gvrString(gvr))
+				continue
+			}
+
 			oldDef, found := oldSet[gvr]
 			if found {
 				oldDef := oldDef.(apiResourceSchemaApiDefinition)
@@ -137,7 +221,7 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 		}
 	}
 
-	klog.V(2).Infof("Updating APIs for APIExport %s|%s and APIDomainKey %s: new=%v, preserved=%v, removed=%v", logicalcluster.From(apiExport), apiExport.Name, apiDomainKey, newGVRs, preservedGVR, removedGVRs)
+	klog.V(2).Infof("Updating APIs for APIDomainKey %s: new=%v, preserved=%v, removed=%v", apiDomainKey, newGVRs, preservedGVR, removedGVRs)
 
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
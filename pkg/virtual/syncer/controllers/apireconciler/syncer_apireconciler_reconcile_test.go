@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apireconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+type fakeAPIDefinition struct {
+	apidefinition.APIDefinition
+	tornDown bool
+}
+
+func (d *fakeAPIDefinition) TearDown() {
+	d.tornDown = true
+}
+
+func TestReconcileSupportedAPIExports(t *testing.T) {
+	clusterName := logicalcluster.New("root:org:ws")
+	newSchema := func(name, group, resource string) *apisv1alpha1.APIResourceSchema {
+		return &apisv1alpha1.APIResourceSchema{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				ClusterName: clusterName.String(),
+			},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: resource},
+				Versions: []apisv1alpha1.APIResourceVersion{
+					{Name: "v1", Served: true},
+				},
+			},
+		}
+	}
+	newExport := func(name string, labels map[string]string, schemas ...string) *apisv1alpha1.APIExport {
+		return &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				ClusterName: clusterName.String(),
+				Labels:      labels,
+			},
+			Spec:   apisv1alpha1.APIExportSpec{LatestResourceSchemas: schemas},
+			Status: apisv1alpha1.APIExportStatus{IdentityHash: name + "-identity"},
+		}
+	}
+
+	schemaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, schemaIndexer.Add(newSchema("v1.deployments.apps", "apps", "deployments")))
+	require.NoError(t, schemaIndexer.Add(newSchema("v1.widgets.example.io", "example.io", "widgets")))
+
+	apiExports := []*apisv1alpha1.APIExport{
+		newExport("kubernetes", nil, "v1.deployments.apps"),
+		newExport("widgets", map[string]string{"sync": "true"}, "v1.widgets.example.io"),
+	}
+
+	var updated *workloadv1alpha1.SyncTarget
+	c := &APIReconciler{
+		apiResourceSchemaLister: apislisters.NewAPIResourceSchemaLister(schemaIndexer),
+		createAPIDefinition: func(syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string) (apidefinition.APIDefinition, error) {
+			return &fakeAPIDefinition{}, nil
+		},
+		updateSyncTargetStatus: func(ctx context.Context, clusterName logicalcluster.Name, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+			updated = syncTarget
+			return syncTarget, nil
+		},
+		apiSets: map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet{},
+	}
+
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster",
+			ClusterName: clusterName.String(),
+		},
+	}
+	apiDomainKey := dynamiccontext.APIDomainKey(clusters.ToClusterAwareKey(clusterName, "cluster"))
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	widgets := schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}
+
+	t.Log("Without a selector, only the kubernetes APIExport is served")
+	require.NoError(t, c.reconcile(context.Background(), syncTarget, apiExports))
+	require.Contains(t, c.apiSets[apiDomainKey], deployments)
+	require.NotContains(t, c.apiSets[apiDomainKey], widgets)
+	require.NotNil(t, updated)
+	require.Equal(t, []string{"kubernetes"}, updated.Status.SupportedAPIExports)
+
+	t.Log("With a selector, only the matching APIExport is served")
+	syncTarget = updated.DeepCopy()
+	syncTarget.Spec.SupportedAPIExportSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"sync": "true"}}
+	deploymentsDef := c.apiSets[apiDomainKey][deployments].(apiResourceSchemaApiDefinition).APIDefinition.(*fakeAPIDefinition)
+	updated = nil
+	require.NoError(t, c.reconcile(context.Background(), syncTarget, apiExports))
+	require.Contains(t, c.apiSets[apiDomainKey], widgets)
+	require.NotContains(t, c.apiSets[apiDomainKey], deployments)
+	require.True(t, deploymentsDef.tornDown, "expected the APIs of the unselected APIExport to be torn down")
+	require.NotNil(t, updated)
+	require.Equal(t, []string{"widgets"}, updated.Status.SupportedAPIExports)
+
+	t.Log("Unchanged status is not updated")
+	syncTarget = updated.DeepCopy()
+	updated = nil
+	require.NoError(t, c.reconcile(context.Background(), syncTarget, apiExports))
+	require.Nil(t, updated)
+
+	t.Log("Without matching APIExports, no APIs are served")
+	syncTarget.Spec.SupportedAPIExportSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"sync": "never"}}
+	require.NoError(t, c.reconcile(context.Background(), syncTarget, apiExports))
+	require.NotContains(t, c.apiSets, apiDomainKey)
+	require.NotNil(t, updated)
+	require.Empty(t, updated.Status.SupportedAPIExports)
+}
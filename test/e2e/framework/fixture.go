@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -57,6 +58,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	workloadcliplugin "github.com/kcp-dev/kcp/pkg/cliplugins/workload/plugin"
@@ -376,6 +378,12 @@ type SyncerFixture struct {
 	SyncTargetName               string
 	SyncTargetUID                types.UID
 	InstallCRDs                  func(config *rest.Config, isLogicalCluster bool)
+	// ReadyTimeout is the overall deadline for the sync target to become ready after
+	// the syncer has been started. Defaults to wait.ForeverTestTimeout.
+	ReadyTimeout time.Duration
+	// SyncerImage is pinned in spec.syncerImage of the sync target, which the plugin
+	// then uses for the syncer deployment. Defaults to --syncer-image.
+	SyncerImage string
 }
 
 // SetDefaults ensures a valid configuration even if not all values are explicitly provided.
@@ -396,6 +404,9 @@ func (sf *SyncerFixture) setDefaults() {
 		// values means default types will still be synced.
 		sf.ResourcesToSync = sets.NewString()
 	}
+	if sf.ReadyTimeout == 0 {
+		sf.ReadyTimeout = wait.ForeverTestTimeout
+	}
 }
 
 // Start starts a new syncer against the given upstream kcp workspace. Whether the syncer run
@@ -412,9 +423,22 @@ func (sf SyncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 	useDeployedSyncer := len(TestConfig.PClusterKubeconfig()) > 0
 
 	syncerImage := TestConfig.SyncerImage()
-	if useDeployedSyncer {
+	switch {
+	case len(sf.SyncerImage) > 0:
+		// Create the sync target with the pinned image up front, the plugin picks it up
+		// like for a sync target pinned by a user.
+		t.Logf("Pinning syncer image %q for sync target %q", sf.SyncerImage, sf.SyncTargetName)
+		kcpClusterClient, err := kcpclient.NewClusterForConfig(sf.UpstreamServer.DefaultConfig(t))
+		require.NoError(t, err)
+		_, err = kcpClusterClient.Cluster(sf.WorkspaceClusterName).WorkloadV1alpha1().SyncTargets().Create(context.Background(), &workloadv1alpha1.SyncTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: sf.SyncTargetName},
+			Spec:       workloadv1alpha1.SyncTargetSpec{SyncerImage: sf.SyncerImage},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err, "failed to create sync target %q with pinned syncer image", sf.SyncTargetName)
+		syncerImage = ""
+	case useDeployedSyncer:
 		require.NotZero(t, len(syncerImage), "--syncer-image must be specified if testing with a deployed syncer")
-	} else {
+	default:
 		// The image needs to be a non-empty string for the plugin command but the value
 		// doesn't matter if not deploying a syncer.
 		syncerImage = "not-a-valid-image"
@@ -426,10 +450,12 @@ func (sf SyncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 		"workload",
 		"sync",
 		sf.SyncTargetName,
-		"--syncer-image", syncerImage,
 		"--output-file", "-",
 		"--qps", "-1",
 	}
+	if len(syncerImage) > 0 {
+		pluginArgs = append(pluginArgs, "--syncer-image", syncerImage)
+	}
 	for _, resource := range sf.ResourcesToSync.List() {
 		pluginArgs = append(pluginArgs, "--resources", resource)
 	}
@@ -617,13 +643,14 @@ func (sf SyncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 
 	startedSyncer := &StartedSyncerFixture{
 		SyncerConfig:         syncerConfig,
+		SyncerID:             syncerID,
 		DownstreamConfig:     downstreamConfig,
 		DownstreamKubeClient: downstreamKubeClient,
 	}
 
 	// The sync target becoming ready indicates the syncer is healthy and has
 	// successfully sent a heartbeat to kcp.
-	startedSyncer.WaitForClusterReadyReason(t, ctx, "")
+	startedSyncer.waitForClusterReadyReason(t, ctx, "", sf.ReadyTimeout)
 
 	return startedSyncer
 }
@@ -632,6 +659,8 @@ func (sf SyncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 // downstream cluster.
 type StartedSyncerFixture struct {
 	SyncerConfig *syncer.SyncerConfig
+	// SyncerID is the name of the downstream namespace and deployment of the syncer.
+	SyncerID string
 
 	// Provide cluster-admin config and client for test purposes. The downstream config in
 	// SyncerConfig will be less privileged.
@@ -641,30 +670,73 @@ type StartedSyncerFixture struct {
 
 // WaitForClusterReadyReason waits for the cluster to be ready with the given reason.
 func (sf *StartedSyncerFixture) WaitForClusterReadyReason(t *testing.T, ctx context.Context, reason string) {
+	sf.waitForClusterReadyReason(t, ctx, reason, wait.ForeverTestTimeout)
+}
+
+// waitForClusterReadyReason polls the sync target with exponential backoff and jitter
+// until its Ready condition has the given reason, or fails the test after timeout.
+// Errors getting the sync target are logged and retried, such that transient failures
+// do not fail the test.
+func (sf *StartedSyncerFixture) waitForClusterReadyReason(t *testing.T, ctx context.Context, reason string, timeout time.Duration) {
+	t.Helper()
+
 	cfg := sf.SyncerConfig
 
 	t.Logf("Waiting for cluster %q condition %q to have reason %q", cfg.SyncTargetName, conditionsapi.ReadyCondition, reason)
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(cfg.UpstreamConfig)
 	require.NoError(t, err)
 	kcpClient := kcpClusterClient.Cluster(cfg.KCPClusterName)
-	Eventually(t, func() (bool, string) {
-		cluster, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, cfg.SyncTargetName, metav1.GetOptions{})
-		require.NoError(t, err)
 
-		// A reason is only supplied to indicate why a cluster is 'not ready'
-		wantReady := len(reason) == 0
-		if wantReady {
-			return conditions.IsTrue(cluster, conditionsapi.ReadyCondition), toYaml(t, cluster)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := wait.Backoff{
+		Duration: 100 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      5 * time.Second,
+	}
+	var last string
+	for {
+		cluster, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, cfg.SyncTargetName, metav1.GetOptions{})
+		var msg string
+		if err != nil {
+			msg = fmt.Sprintf("failed to get cluster %q: %v", cfg.SyncTargetName, err)
 		} else {
-			conditionReason := conditions.GetReason(cluster, conditionsapi.ReadyCondition)
-			return conditions.IsFalse(cluster, conditionsapi.ReadyCondition) && reason == conditionReason, toYaml(t, cluster)
+			// A reason is only supplied to indicate why a cluster is 'not ready'
+			var done bool
+			if len(reason) == 0 {
+				done = conditions.IsTrue(cluster, conditionsapi.ReadyCondition)
+			} else {
+				done = conditions.IsFalse(cluster, conditionsapi.ReadyCondition) && reason == conditions.GetReason(cluster, conditionsapi.ReadyCondition)
+			}
+			if done {
+				break
+			}
+			// only log the Ready condition, the rest of the status changes with every heartbeat
+			if ready := conditions.Get(cluster, conditionsapi.ReadyCondition); ready == nil {
+				msg = fmt.Sprintf("no %s condition", conditionsapi.ReadyCondition)
+			} else {
+				msg = fmt.Sprintf("%s=%s reason=%q message=%q", ready.Type, ready.Status, ready.Reason, ready.Message)
+			}
+		}
+		if msg != last {
+			t.Logf("Waiting for cluster %q, but got: %s", cfg.SyncTargetName, msg)
+			last = msg
 		}
 
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
+		select {
+		case <-ctx.Done():
+			require.FailNowf(t, "timed out waiting for cluster", "cluster %q condition %q did not get reason %q within %s: %s", cfg.SyncTargetName, conditionsapi.ReadyCondition, reason, timeout, last)
+		case <-time.After(backoff.Step()):
+		}
+	}
+
 	if len(reason) == 0 {
 		t.Logf("Cluster %q is %s", cfg.SyncTargetName, conditionsapi.ReadyCondition)
 	} else {
-		t.Logf("Cluster %q condition %s has reason %q", conditionsapi.ReadyCondition, cfg.SyncTargetName, reason)
+		t.Logf("Cluster %q condition %s has reason %q", cfg.SyncTargetName, conditionsapi.ReadyCondition, reason)
 	}
 }
 
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// WorkloadWorkspace is a user workspace bound to a compute service, whose default
+// Placement is ready and which serves the synced resources.
+type WorkloadWorkspace struct {
+	ClusterName logicalcluster.Name
+
+	KubeClusterClient kubernetes.ClusterInterface
+	KcpClusterClient  kcpclient.ClusterInterface
+
+	Binding   *apisv1alpha1.APIBinding
+	Placement *schedulingv1alpha1.Placement
+}
+
+// SetupWorkloadWorkspace binds the user workspace to the kubernetes APIExport of the compute
+// workspace, and waits for the binding to be bound, the default Placement to be ready, and
+// Services to be listable in the user workspace. The SyncTarget of the compute workspace
+// is expected to sync Services.
+func SetupWorkloadWorkspace(t *testing.T, ctx context.Context, server RunningServer, userClusterName, computeClusterName logicalcluster.Name) *WorkloadWorkspace {
+	t.Helper()
+
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(server.DefaultConfig(t))
+	require.NoError(t, err)
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(server.DefaultConfig(t))
+	require.NoError(t, err)
+
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kubernetes",
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{
+					Path:       computeClusterName.String(),
+					ExportName: "kubernetes",
+				},
+			},
+		},
+	}
+
+	t.Logf("Create a binding in the user workspace %s", userClusterName)
+	_, err = kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for binding to be ready")
+	Eventually(t, func() (bool, string) {
+		binding, err = kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get binding: %v", err)
+		}
+		return conditions.IsTrue(binding, apisv1alpha1.InitialBindingCompleted), fmt.Sprintf("binding not bound: %s", toYaml(t, binding))
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Wait for placement to be ready")
+	var placement *schedulingv1alpha1.Placement
+	Eventually(t, func() (bool, string) {
+		placement, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, "default", metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get placement: %v", err)
+		}
+		return conditions.IsTrue(placement, schedulingv1alpha1.PlacementReady), fmt.Sprintf("placement is not ready: %s", toYaml(t, placement))
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Wait for being able to list Services in the user workspace")
+	Eventually(t, func() (bool, string) {
+		_, err := kubeClusterClient.Cluster(userClusterName).CoreV1().Services("").List(ctx, metav1.ListOptions{})
+		if errors.IsNotFound(err) {
+			return false, "services are not served yet"
+		} else if err != nil {
+			return false, fmt.Sprintf("failed to list services: %v", err)
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	return &WorkloadWorkspace{
+		ClusterName:       userClusterName,
+		KubeClusterClient: kubeClusterClient,
+		KcpClusterClient:  kcpClusterClient,
+		Binding:           binding,
+		Placement:         placement,
+	}
+}
@@ -38,7 +38,6 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
-	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kubefixtures "github.com/kcp-dev/kcp/test/e2e/fixtures/kube"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
@@ -173,90 +172,8 @@ func TestScheduling(t *testing.T) {
 		return location.Status.AvailableInstances != nil && *location.Status.AvailableInstances == 1, fmt.Sprintf("instances in status not updated:\n%s", toYaml(location))
 	}, wait.ForeverTestTimeout, time.Millisecond*100)
 
-	binding := &apisv1alpha1.APIBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "kubernetes",
-		},
-		Spec: apisv1alpha1.APIBindingSpec{
-			Reference: apisv1alpha1.ExportReference{
-				Workspace: &apisv1alpha1.WorkspaceExportReference{
-					Path:       negotiationClusterName.String(),
-					ExportName: "kubernetes",
-				},
-			},
-		},
-	}
-
-	t.Logf("Create a binding in the user workspace")
-	_, err = kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
-	require.NoError(t, err)
-
-	t.Logf("Wait for binding to be ready")
-	framework.Eventually(t, func() (bool, string) {
-		binding, err := kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Get(ctx, binding.Name, metav1.GetOptions{})
-		if err != nil {
-			return false, fmt.Sprintf("failed to list Locations: %v", err)
-		}
-		return conditions.IsTrue(binding, apisv1alpha1.InitialBindingCompleted), fmt.Sprintf("binding not bound: %s", toYaml(binding))
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
-
-	t.Logf("Wait for placement to be ready")
-	framework.Eventually(t, func() (bool, string) {
-		placement, err := kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, "default", metav1.GetOptions{})
-		if err != nil {
-			return false, fmt.Sprintf("failed to get placement: %v", err)
-		}
-
-		return conditions.IsTrue(placement, schedulingv1alpha1.PlacementReady), fmt.Sprintf("placement is not ready: %s", toYaml(binding))
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
-
-	t.Logf("Wait for being able to list Services in the user workspace")
-	require.Eventually(t, func() bool {
-		_, err := kubeClusterClient.Cluster(userClusterName).CoreV1().Services("").List(ctx, metav1.ListOptions{})
-		if errors.IsNotFound(err) {
-			return false
-		} else if err != nil {
-			klog.Errorf("Failed to list Services: %v", err)
-			return false
-		}
-		return true
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
-
-	t.Logf("Create a binding in the second user workspace")
-	_, err = kcpClusterClient.Cluster(secondUserClusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
-	require.NoError(t, err)
-
-	t.Logf("Wait for binding to be ready")
-	framework.Eventually(t, func() (bool, string) {
-		binding, err := kcpClusterClient.Cluster(secondUserClusterName).ApisV1alpha1().APIBindings().Get(ctx, binding.Name, metav1.GetOptions{})
-		if err != nil {
-			return false, fmt.Sprintf("failed to list Locations: %v", err)
-		}
-
-		return conditions.IsTrue(binding, apisv1alpha1.InitialBindingCompleted), fmt.Sprintf("binding not bound: %s", toYaml(binding))
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
-
-	t.Logf("Wait for placement to be ready")
-	framework.Eventually(t, func() (bool, string) {
-		placement, err := kcpClusterClient.Cluster(secondUserClusterName).SchedulingV1alpha1().Placements().Get(ctx, "default", metav1.GetOptions{})
-		if err != nil {
-			return false, fmt.Sprintf("failed to get placement: %v", err)
-		}
-
-		return conditions.IsTrue(placement, schedulingv1alpha1.PlacementReady), fmt.Sprintf("placement is not ready: %s", toYaml(binding))
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
-
-	t.Logf("Wait for being able to list Services in the user workspace")
-	require.Eventually(t, func() bool {
-		_, err := kubeClusterClient.Cluster(secondUserClusterName).CoreV1().Services("").List(ctx, metav1.ListOptions{})
-		if errors.IsNotFound(err) {
-			return false
-		} else if err != nil {
-			klog.Errorf("Failed to list Services: %v", err)
-			return false
-		}
-		return true
-	}, wait.ForeverTestTimeout, time.Millisecond*100)
+	framework.SetupWorkloadWorkspace(t, ctx, source, userClusterName, negotiationClusterName)
+	framework.SetupWorkloadWorkspace(t, ctx, source, secondUserClusterName, negotiationClusterName)
 
 	t.Logf("Create a service in the user workspace")
 	_, err = kubeClusterClient.Cluster(userClusterName).CoreV1().Services("default").Create(ctx, &corev1.Service{
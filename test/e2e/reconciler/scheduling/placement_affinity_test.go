@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestPlacementAffinity(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	source := framework.SharedKcpServer(t)
+
+	orgClusterName := framework.NewOrganizationFixture(t, source)
+	locationClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName)
+	userClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName)
+
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(source.DefaultConfig(t))
+	require.NoError(t, err)
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(source.DefaultConfig(t))
+	require.NoError(t, err)
+
+	syncTargetNames := []string{fmt.Sprintf("synctarget-%d", +rand.Intn(1000000)), fmt.Sprintf("synctarget-%d", +rand.Intn(1000000))}
+	for _, syncTargetName := range syncTargetNames {
+		t.Logf("Creating SyncTarget %s and syncer in %s", syncTargetName, locationClusterName)
+		framework.SyncerFixture{
+			ResourcesToSync:      sets.NewString("services"),
+			UpstreamServer:       source,
+			WorkspaceClusterName: locationClusterName,
+			SyncTargetName:       syncTargetName,
+		}.Start(t)
+
+		t.Logf("Label SyncTarget %s", syncTargetName)
+		_, err = kcpClusterClient.Cluster(locationClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.MergePatchType, []byte(`{"metadata":{"labels":{"loc":"affinity"}}}`), metav1.PatchOptions{})
+		require.NoError(t, err)
+	}
+
+	t.Log("Create a location")
+	location := &schedulingv1alpha1.Location{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "affinity",
+			Labels: map[string]string{"loc": "affinity"},
+		},
+		Spec: schedulingv1alpha1.LocationSpec{
+			Resource: schedulingv1alpha1.GroupVersionResource{
+				Group:    "workload.kcp.dev",
+				Version:  "v1alpha1",
+				Resource: "synctargets",
+			},
+			InstanceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"loc": "affinity"},
+			},
+		},
+	}
+	_, err = kcpClusterClient.Cluster(locationClusterName).SchedulingV1alpha1().Locations().Create(ctx, location, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Create a binding in the user workspace")
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kubernetes",
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{
+					Path:       locationClusterName.String(),
+					ExportName: "kubernetes",
+				},
+			},
+		},
+	}
+	_, err = kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for binding to be ready")
+	framework.Eventually(t, func() (bool, string) {
+		binding, err := kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+
+		return conditions.IsTrue(binding, apisv1alpha1.InitialBindingCompleted), fmt.Sprintf("binding not bound: %s", toYaml(binding))
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Disable the default placement")
+	framework.Eventually(t, func() (bool, string) {
+		placement, err := kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, "default", metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get placement %v", err)
+		}
+
+		placement.Spec.NamespaceSelector = nil
+		_, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Update(ctx, placement, metav1.UpdateOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("Failed to update placement: %v", err)
+		}
+
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	newPlacement := func(name string, affinity *schedulingv1alpha1.PlacementAffinity) *schedulingv1alpha1.Placement {
+		return &schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: schedulingv1alpha1.PlacementSpec{
+				LocationSelectors: []metav1.LabelSelector{{
+					MatchLabels: map[string]string{"loc": "affinity"},
+				}},
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": name},
+				},
+				LocationResource: schedulingv1alpha1.GroupVersionResource{
+					Group:    "workload.kcp.dev",
+					Version:  "v1alpha1",
+					Resource: "synctargets",
+				},
+				LocationWorkspace: locationClusterName.String(),
+				PlacementAffinity: affinity,
+			},
+		}
+	}
+
+	scheduledSyncTargets := func(name string) (sets.String, error) {
+		ns, err := kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		ret := sets.NewString()
+		for _, syncTargetName := range syncTargetNames {
+			if ns.Labels[workloadv1alpha1.ClusterResourceStateLabel(syncTargetName)] == string(workloadv1alpha1.ResourceStateSync) {
+				ret.Insert(syncTargetName)
+			}
+		}
+		return ret, nil
+	}
+
+	t.Logf("Create the db placement and namespace")
+	_, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Create(ctx, newPlacement("db", nil), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "db",
+			Labels: map[string]string{"app": "db"},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for the db namespace to be scheduled")
+	var dbSyncTarget string
+	framework.Eventually(t, func() (bool, string) {
+		scheduled, err := scheduledSyncTargets("db")
+		if err != nil {
+			return false, fmt.Sprintf("failed to get namespace db: %v", err)
+		}
+		if scheduled.Len() != 1 {
+			return false, fmt.Sprintf("namespace db is scheduled to %v", scheduled.List())
+		}
+		dbSyncTarget = scheduled.List()[0]
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Create the web placement with affinity to the db placement")
+	_, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Create(ctx, newPlacement("web", &schedulingv1alpha1.PlacementAffinity{Placement: "db"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for the affinity of the web placement to be satisfied")
+	framework.Eventually(t, func() (bool, string) {
+		placement, err := kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, "web", metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get placement %v", err)
+		}
+		return conditions.IsTrue(placement, schedulingv1alpha1.PlacementAffinitySatisfied), fmt.Sprintf("placement affinity not satisfied: %s", toYaml(placement))
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	namespaceNames := []string{"web-1", "web-2", "web-3", "web-4"}
+	t.Logf("Create namespaces %v in the user workspace", namespaceNames)
+	for _, name := range namespaceNames {
+		_, err = kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"app": "web"},
+			},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	t.Logf("Wait for all web namespaces to be co-located with the db namespace on %s", dbSyncTarget)
+	framework.Eventually(t, func() (bool, string) {
+		for _, name := range namespaceNames {
+			scheduled, err := scheduledSyncTargets(name)
+			if err != nil {
+				return false, fmt.Sprintf("failed to get namespace %s: %v", name, err)
+			}
+			if !scheduled.Equal(sets.NewString(dbSyncTarget)) {
+				return false, fmt.Sprintf("namespace %s is scheduled to %v, expected %s", name, scheduled.List(), dbSyncTarget)
+			}
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestPlacementRebalance(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	source := framework.SharedKcpServer(t)
+
+	orgClusterName := framework.NewOrganizationFixture(t, source)
+	locationClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName)
+	userClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName)
+
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(source.DefaultConfig(t))
+	require.NoError(t, err)
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(source.DefaultConfig(t))
+	require.NoError(t, err)
+
+	firstSyncTargetName := fmt.Sprintf("synctarget-%d", +rand.Intn(1000000))
+	t.Logf("Creating a SyncTarget and syncer in %s", locationClusterName)
+	framework.SyncerFixture{
+		ResourcesToSync:      sets.NewString("services"),
+		UpstreamServer:       source,
+		WorkspaceClusterName: locationClusterName,
+		SyncTargetName:       firstSyncTargetName,
+	}.Start(t)
+
+	t.Log("Label the first synctarget")
+	_, err = kcpClusterClient.Cluster(locationClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, firstSyncTargetName, types.MergePatchType, []byte(`{"metadata":{"labels":{"loc":"rebalance"}}}`), metav1.PatchOptions{})
+	require.NoError(t, err)
+
+	t.Log("Create a location")
+	location := &schedulingv1alpha1.Location{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "rebalance",
+			Labels: map[string]string{"loc": "rebalance"},
+		},
+		Spec: schedulingv1alpha1.LocationSpec{
+			Resource: schedulingv1alpha1.GroupVersionResource{
+				Group:    "workload.kcp.dev",
+				Version:  "v1alpha1",
+				Resource: "synctargets",
+			},
+			InstanceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"loc": "rebalance"},
+			},
+		},
+	}
+	_, err = kcpClusterClient.Cluster(locationClusterName).SchedulingV1alpha1().Locations().Create(ctx, location, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Create a binding in the user workspace")
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kubernetes",
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{
+					Path:       locationClusterName.String(),
+					ExportName: "kubernetes",
+				},
+			},
+		},
+	}
+	_, err = kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for binding to be ready")
+	framework.Eventually(t, func() (bool, string) {
+		binding, err := kcpClusterClient.Cluster(userClusterName).ApisV1alpha1().APIBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+
+		return conditions.IsTrue(binding, apisv1alpha1.InitialBindingCompleted), fmt.Sprintf("binding not bound: %s", toYaml(binding))
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Create a placement with rebalancing")
+	placement := &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rebalance",
+		},
+		Spec: schedulingv1alpha1.PlacementSpec{
+			LocationSelectors: []metav1.LabelSelector{{
+				MatchLabels: map[string]string{"loc": "rebalance"},
+			}},
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"rebalance": "true"},
+			},
+			LocationResource: schedulingv1alpha1.GroupVersionResource{
+				Group:    "workload.kcp.dev",
+				Version:  "v1alpha1",
+				Resource: "synctargets",
+			},
+			LocationWorkspace: locationClusterName.String(),
+			Rebalance: &schedulingv1alpha1.RebalancePolicy{
+				Interval:                 metav1.Duration{Duration: 5 * time.Second},
+				MaxNamespacesPerInterval: 1,
+			},
+		},
+	}
+	_, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Create(ctx, placement, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Disable the default placement")
+	framework.Eventually(t, func() (bool, string) {
+		placement, err := kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, "default", metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get placement %v", err)
+		}
+
+		placement.Spec.NamespaceSelector = nil
+		_, err = kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Update(ctx, placement, metav1.UpdateOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("Failed to update placement: %v", err)
+		}
+
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	namespaceNames := []string{"rebalance-1", "rebalance-2", "rebalance-3", "rebalance-4"}
+	t.Logf("Create namespaces %v in the user workspace", namespaceNames)
+	for _, name := range namespaceNames {
+		_, err = kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"rebalance": "true"},
+			},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	t.Logf("Wait for all namespaces to be scheduled to the first synctarget")
+	framework.Eventually(t, func() (bool, string) {
+		for _, name := range namespaceNames {
+			ns, err := kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Sprintf("failed to get namespace %s: %v", name, err)
+			}
+			if ns.Labels[workloadv1alpha1.ClusterResourceStateLabel(firstSyncTargetName)] != string(workloadv1alpha1.ResourceStateSync) {
+				return false, fmt.Sprintf("namespace %s is not scheduled to %s: %v", name, firstSyncTargetName, ns.Labels)
+			}
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	secondSyncTargetName := fmt.Sprintf("synctarget-%d", +rand.Intn(1000000))
+	t.Logf("Creating a second SyncTarget and syncer in %s", locationClusterName)
+	framework.SyncerFixture{
+		ResourcesToSync:      sets.NewString("services"),
+		UpstreamServer:       source,
+		WorkspaceClusterName: locationClusterName,
+		SyncTargetName:       secondSyncTargetName,
+	}.Start(t)
+
+	t.Log("Label the second synctarget")
+	_, err = kcpClusterClient.Cluster(locationClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, secondSyncTargetName, types.MergePatchType, []byte(`{"metadata":{"labels":{"loc":"rebalance"}}}`), metav1.PatchOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Wait for some namespaces to migrate to the second synctarget")
+	framework.Eventually(t, func() (bool, string) {
+		var migrated []string
+		for _, name := range namespaceNames {
+			ns, err := kubeClusterClient.Cluster(userClusterName).CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Sprintf("failed to get namespace %s: %v", name, err)
+			}
+			if ns.Labels[workloadv1alpha1.ClusterResourceStateLabel(secondSyncTargetName)] == string(workloadv1alpha1.ResourceStateSync) {
+				migrated = append(migrated, name)
+			}
+		}
+		return len(migrated) == len(namespaceNames)/2, fmt.Sprintf("namespaces %v migrated to %s, expected %d", migrated, secondSyncTargetName, len(namespaceNames)/2)
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	t.Logf("Wait for the placement to record the last rebalance time")
+	framework.Eventually(t, func() (bool, string) {
+		placement, err := kcpClusterClient.Cluster(userClusterName).SchedulingV1alpha1().Placements().Get(ctx, placement.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to get placement %v", err)
+		}
+		return placement.Status.LastRebalanceTime != nil, fmt.Sprintf("placement %s has no last rebalance time", placement.Name)
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+}
@@ -466,6 +466,47 @@ func TestSyncWorkload(t *testing.T) {
 
 }
 
+func TestSyncerFixturePinnedImage(t *testing.T) {
+	t.Parallel()
+
+	upstreamServer := framework.SharedKcpServer(t)
+
+	t.Log("Creating an organization")
+	orgClusterName := framework.NewOrganizationFixture(t, upstreamServer)
+
+	t.Log("Creating a workspace")
+	wsClusterName := framework.NewWorkspaceFixture(t, upstreamServer, orgClusterName)
+
+	// A deployed syncer has to run, so it can only be pinned to the image under test.
+	pinnedImage := "ghcr.io/kcp-dev/kcp/syncer:pinned"
+	if len(framework.TestConfig.PClusterKubeconfig()) > 0 {
+		pinnedImage = framework.TestConfig.SyncerImage()
+	}
+
+	syncerFixture := framework.SyncerFixture{
+		UpstreamServer:       upstreamServer,
+		WorkspaceClusterName: wsClusterName,
+		SyncerImage:          pinnedImage,
+	}.Start(t)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(upstreamServer.DefaultConfig(t))
+	require.NoError(t, err)
+
+	t.Log("Check the sync target pins the image")
+	syncTarget, err := kcpClusterClient.Cluster(wsClusterName).WorkloadV1alpha1().SyncTargets().Get(ctx, syncerFixture.SyncerConfig.SyncTargetName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, pinnedImage, syncTarget.Spec.SyncerImage)
+
+	t.Log("Check the syncer deployment uses the pinned image")
+	deployment, err := syncerFixture.DownstreamKubeClient.AppsV1().Deployments(syncerFixture.SyncerID).Get(ctx, syncerFixture.SyncerID, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, deployment.Spec.Template.Spec.Containers, 1)
+	require.Equal(t, pinnedImage, deployment.Spec.Template.Spec.Containers[0].Image)
+}
+
 func TestCordonUncordonDrain(t *testing.T) {
 	t.Parallel()
 